@@ -10,6 +10,7 @@ require (
 	github.com/lithammer/dedent v1.1.0
 	github.com/openkruise/kruise-api v0.8.0
 	github.com/pkg/errors v0.9.1
+	github.com/pmezard/go-difflib v1.0.0
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/pflag v1.0.5
 	github.com/xlab/handysort v0.0.0-20150421192137-fb3537ed64a1 // indirect
@@ -25,6 +26,7 @@ require (
 	sigs.k8s.io/controller-runtime v0.6.3
 	sigs.k8s.io/structured-merge-diff v1.0.1 // indirect
 	sigs.k8s.io/structured-merge-diff/v2 v2.0.1 // indirect
+	sigs.k8s.io/yaml v1.2.0
 	vbom.ml/util v0.0.0-20160121211510-db5cfe13f5cc // indirect
 )
 
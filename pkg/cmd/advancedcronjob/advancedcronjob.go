@@ -0,0 +1,95 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package advancedcronjob
+
+import (
+	"fmt"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// advancedCronJobResource is the resource AdvancedCronJob names are resolved as.
+const advancedCronJobResource = "advancedcronjobs.apps.kruise.io"
+
+var (
+	advancedCronJobLong = templates.LongDesc(`
+		Manage AdvancedCronJobs
+
+		These commands show the jobs an AdvancedCronJob has run.
+
+		Use "kubectl-kruise create job NAME --from=advancedcronjob/NAME" to run the
+		job of an AdvancedCronJob now, and "kubectl-kruise rollout pause" or
+		"kubectl-kruise rollout resume" to stop or restart its schedule.`)
+)
+
+// NewCmdAdvancedCronJob returns an initialized Command instance for 'advancedcronjob' sub command
+func NewCmdAdvancedCronJob(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "advancedcronjob SUBCOMMAND",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"acj"},
+		Short:                 i18n.T("Manage AdvancedCronJobs"),
+		Long:                  advancedCronJobLong,
+		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+
+	// add subcommands
+	cmd.AddCommand(NewCmdAdvancedCronJobHistory(f, streams))
+
+	return cmd
+}
+
+// getAdvancedCronJob returns the AdvancedCronJob named name in namespace.
+func getAdvancedCronJob(builder *resource.Builder, namespace, name string) (*kruiseappsv1alpha1.AdvancedCronJob, error) {
+	infos, err := builder.
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(true, advancedCronJobResource, name).
+		Latest().
+		Flatten().
+		Do().
+		Infos()
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != 1 {
+		return nil, fmt.Errorf("AdvancedCronJob %q not found", name)
+	}
+
+	switch obj := infos[0].Object.(type) {
+	case *kruiseappsv1alpha1.AdvancedCronJob:
+		return obj, nil
+	case runtime.Unstructured:
+		acj := &kruiseappsv1alpha1.AdvancedCronJob{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), acj); err != nil {
+			return nil, fmt.Errorf("failed to convert %T to %T: %v", obj, acj, err)
+		}
+		return acj, nil
+	default:
+		return nil, fmt.Errorf("%s %q is not an AdvancedCronJob", infos[0].Mapping.Resource.Resource, infos[0].Name)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package advancedcronjob
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// HistoryOptions holds the options for 'advancedcronjob history' sub command
+type HistoryOptions struct {
+	Name      string
+	Namespace string
+	Limit     int
+	NoHeaders bool
+
+	Builder   func() *resource.Builder
+	Clientset kubernetes.Interface
+	Reader    client.Reader
+
+	genericclioptions.IOStreams
+}
+
+var (
+	historyLong = templates.LongDesc(`
+		List the recent runs of an AdvancedCronJob.
+
+		The Jobs or BroadcastJobs created by the AdvancedCronJob are listed newest
+		first, along with their outcome, whether they are still active and how long
+		they ran. Only the runs kept by the successful and failed jobs history limits
+		of the AdvancedCronJob can be shown.`)
+
+	historyExample = templates.Examples(`
+		# List the recent runs of the AdvancedCronJob backup
+		kubectl-kruise advancedcronjob history backup
+
+		# List all runs of the AdvancedCronJob backup that are still kept
+		kubectl-kruise advancedcronjob history backup --limit=0`)
+)
+
+// jobRun is a single run of an AdvancedCronJob.
+type jobRun struct {
+	Name       string
+	Kind       string
+	Status     string
+	Active     bool
+	Start      metav1.Time
+	Completion *metav1.Time
+}
+
+// NewCmdAdvancedCronJobHistory returns a Command instance for 'advancedcronjob history' sub command
+func NewCmdAdvancedCronJobHistory(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &HistoryOptions{
+		Limit:     10,
+		IOStreams: streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "history NAME",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List the recent runs of an AdvancedCronJob"),
+		Long:                  historyLong,
+		Example:               historyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().IntVar(&o.Limit, "limit", o.Limit, "The maximum number of runs to list, newest first. All runs are listed if 0.")
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", o.NoHeaders, "If present, print output without headers")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *HistoryOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "exactly one AdvancedCronJob name is required")
+	}
+	o.Name = args[0]
+	o.Builder = f.NewBuilder
+
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Clientset, err = f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	o.Reader = internalclient.NewManager().GetAPIReader()
+	return nil
+}
+
+// Validate makes sure all the provided values for command-line options are valid
+func (o *HistoryOptions) Validate() error {
+	if o.Limit < 0 {
+		return fmt.Errorf("--limit must not be negative")
+	}
+	return nil
+}
+
+// Run performs the execution of 'advancedcronjob history' sub command
+func (o *HistoryOptions) Run() error {
+	acj, err := getAdvancedCronJob(o.Builder(), o.Namespace, o.Name)
+	if err != nil {
+		return err
+	}
+
+	jobs, broadcastJobs, err := o.listJobs(acj)
+	if err != nil {
+		return err
+	}
+
+	runs := jobRuns(acj, jobs, broadcastJobs)
+	if len(runs) == 0 {
+		fmt.Fprintf(o.ErrOut, "No runs of AdvancedCronJob %q found\n", acj.Name)
+		return nil
+	}
+	if o.Limit > 0 && len(runs) > o.Limit {
+		runs = runs[:o.Limit]
+	}
+
+	now := time.Now()
+	w := printers.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NAME\tKIND\tSTATUS\tACTIVE\tSTARTED\tDURATION")
+	}
+	for _, run := range runs {
+		end := now
+		if run.Completion != nil {
+			end = run.Completion.Time
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%t\t%s\t%s\n", run.Name, run.Kind, run.Status, run.Active,
+			duration.HumanDuration(now.Sub(run.Start.Time)), duration.HumanDuration(end.Sub(run.Start.Time)))
+	}
+	return nil
+}
+
+// listJobs lists the Jobs or BroadcastJobs that may have been created by acj. They are selected by
+// the labels of its job template, which every job created from it has.
+func (o *HistoryOptions) listJobs(acj *kruiseappsv1alpha1.AdvancedCronJob) ([]batchv1.Job, []kruiseappsv1alpha1.BroadcastJob, error) {
+	if template := acj.Spec.Template.BroadcastJobTemplate; template != nil {
+		if len(template.Labels) == 0 {
+			return nil, nil, emptyTemplateLabelsErr(acj)
+		}
+		jobList := &kruiseappsv1alpha1.BroadcastJobList{}
+		err := o.Reader.List(context.TODO(), jobList, client.InNamespace(acj.Namespace), client.MatchingLabels(template.Labels))
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, jobList.Items, nil
+	}
+
+	var jobLabels map[string]string
+	if template := acj.Spec.Template.JobTemplate; template != nil {
+		jobLabels = template.Labels
+	}
+	if len(jobLabels) == 0 {
+		return nil, nil, emptyTemplateLabelsErr(acj)
+	}
+	selector := labels.SelectorFromSet(jobLabels).String()
+	jobList, err := o.Clientset.BatchV1().Jobs(acj.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, nil, err
+	}
+	return jobList.Items, nil, nil
+}
+
+// emptyTemplateLabelsErr is returned for an AdvancedCronJob whose jobs can not be told apart by labels,
+// rather than listing every job in the namespace.
+func emptyTemplateLabelsErr(acj *kruiseappsv1alpha1.AdvancedCronJob) error {
+	return fmt.Errorf("advancedcronjob %s has no labels in its job template to select its jobs by", acj.Name)
+}
+
+// jobRuns returns the runs of acj among jobs and broadcastJobs, newest first.
+func jobRuns(acj *kruiseappsv1alpha1.AdvancedCronJob, jobs []batchv1.Job, broadcastJobs []kruiseappsv1alpha1.BroadcastJob) []jobRun {
+	active := make(map[types.UID]bool)
+	for _, ref := range acj.Status.Active {
+		active[ref.UID] = true
+	}
+
+	var runs []jobRun
+	for i := range jobs {
+		job := &jobs[i]
+		if !metav1.IsControlledBy(job, acj) {
+			continue
+		}
+		run := jobRun{Name: job.Name, Kind: "Job", Status: "Running", Active: active[job.UID], Start: job.CreationTimestamp}
+		if job.Status.StartTime != nil {
+			run.Start = *job.Status.StartTime
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			switch condition.Type {
+			case batchv1.JobComplete:
+				run.Status = "Succeeded"
+				run.Completion = job.Status.CompletionTime
+			case batchv1.JobFailed:
+				run.Status = "Failed"
+				run.Completion = condition.LastTransitionTime.DeepCopy()
+			}
+		}
+		runs = append(runs, run)
+	}
+	for i := range broadcastJobs {
+		job := &broadcastJobs[i]
+		if !metav1.IsControlledBy(job, acj) {
+			continue
+		}
+		run := jobRun{Name: job.Name, Kind: "BroadcastJob", Status: "Running", Active: active[job.UID], Start: job.CreationTimestamp}
+		if job.Status.StartTime != nil {
+			run.Start = *job.Status.StartTime
+		}
+		// report the phases of broadcastjobs like the conditions of jobs
+		switch job.Status.Phase {
+		case kruiseappsv1alpha1.PhaseCompleted:
+			run.Status = "Succeeded"
+			run.Completion = job.Status.CompletionTime
+		case kruiseappsv1alpha1.PhaseFailed:
+			run.Status = "Failed"
+			run.Completion = job.Status.CompletionTime
+		case kruiseappsv1alpha1.PhasePaused:
+			run.Status = "Paused"
+		}
+		runs = append(runs, run)
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool { return runs[j].Start.Before(&runs[i].Start) })
+	return runs
+}
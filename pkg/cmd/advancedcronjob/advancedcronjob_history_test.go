@@ -0,0 +1,164 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package advancedcronjob
+
+import (
+	"testing"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestJobRuns(t *testing.T) {
+	acj := &kruiseappsv1alpha1.AdvancedCronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "backup", UID: "acj-uid"},
+		Status: kruiseappsv1alpha1.AdvancedCronJobStatus{
+			Active: []corev1.ObjectReference{{Name: "backup-3", UID: "job-3"}},
+		},
+	}
+	controllerRef := *metav1.NewControllerRef(acj, kruiseappsv1alpha1.SchemeGroupVersion.WithKind(kruiseappsv1alpha1.AdvancedCronJobKind))
+	start := time.Now().Add(-time.Hour)
+	job := func(name, uid string, offset time.Duration, conditions ...batchv1.JobCondition) batchv1.Job {
+		startTime := metav1.NewTime(start.Add(offset))
+		return batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name, UID: types.UID(uid), OwnerReferences: []metav1.OwnerReference{controllerRef}},
+			Status:     batchv1.JobStatus{StartTime: &startTime, Conditions: conditions},
+		}
+	}
+	jobs := []batchv1.Job{
+		job("backup-1", "job-1", 0, batchv1.JobCondition{Type: batchv1.JobComplete, Status: corev1.ConditionTrue}),
+		job("backup-3", "job-3", 20*time.Minute),
+		job("backup-2", "job-2", 10*time.Minute, batchv1.JobCondition{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}),
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "other", UID: "other"}},
+	}
+
+	runs := jobRuns(acj, jobs, nil)
+	expected := []struct {
+		name   string
+		status string
+		active bool
+	}{
+		{"backup-3", "Running", true},
+		{"backup-2", "Failed", false},
+		{"backup-1", "Succeeded", false},
+	}
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d runs, got %#v", len(expected), runs)
+	}
+	for i, e := range expected {
+		if runs[i].Name != e.name || runs[i].Status != e.status || runs[i].Active != e.active {
+			t.Errorf("expected run %d to be %v, got %#v", i, e, runs[i])
+		}
+	}
+}
+
+func TestJobRunsBroadcastJob(t *testing.T) {
+	acj := &kruiseappsv1alpha1.AdvancedCronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "clean", UID: "acj-uid"},
+	}
+	controllerRef := *metav1.NewControllerRef(acj, kruiseappsv1alpha1.SchemeGroupVersion.WithKind(kruiseappsv1alpha1.AdvancedCronJobKind))
+	tests := []struct {
+		phase    kruiseappsv1alpha1.BroadcastJobPhase
+		expected string
+	}{
+		{phase: kruiseappsv1alpha1.PhaseCompleted, expected: "Succeeded"},
+		{phase: kruiseappsv1alpha1.PhaseFailed, expected: "Failed"},
+		{phase: kruiseappsv1alpha1.PhaseRunning, expected: "Running"},
+		{phase: kruiseappsv1alpha1.PhasePaused, expected: "Paused"},
+		{phase: "", expected: "Running"},
+	}
+	for _, test := range tests {
+		broadcastJobs := []kruiseappsv1alpha1.BroadcastJob{{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "clean-1", OwnerReferences: []metav1.OwnerReference{controllerRef}},
+			Status:     kruiseappsv1alpha1.BroadcastJobStatus{Phase: test.phase},
+		}}
+
+		runs := jobRuns(acj, nil, broadcastJobs)
+		if len(runs) != 1 || runs[0].Kind != "BroadcastJob" || runs[0].Status != test.expected {
+			t.Errorf("phase %q: expected status %s, got runs %#v", test.phase, test.expected, runs)
+		}
+	}
+}
+
+func TestListJobs(t *testing.T) {
+	acj := &kruiseappsv1alpha1.AdvancedCronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "backup"},
+		Spec: kruiseappsv1alpha1.AdvancedCronJobSpec{
+			Template: kruiseappsv1alpha1.CronJobTemplate{
+				JobTemplate: &batchv1beta1.JobTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "backup"}}},
+			},
+		},
+	}
+	clientset := fake.NewSimpleClientset(
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "backup-1", Labels: map[string]string{"app": "backup"}}},
+		&batchv1.Job{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "other"}},
+	)
+	o := &HistoryOptions{
+		Clientset: clientset,
+		Reader: crfake.NewFakeClientWithScheme(internalclient.Scheme,
+			&kruiseappsv1alpha1.BroadcastJob{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "clean-1", Labels: map[string]string{"app": "clean"}}},
+			&kruiseappsv1alpha1.BroadcastJob{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "other"}},
+		),
+	}
+
+	jobs, _, err := o.listJobs(acj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "backup-1" {
+		t.Errorf("expected only the job backup-1, got %v", jobs)
+	}
+	list, ok := clientset.Actions()[0].(clienttesting.ListAction)
+	if !ok || list.GetListRestrictions().Labels.String() != "app=backup" {
+		t.Errorf("expected jobs to be listed by the labels of the template, got %v", clientset.Actions()[0])
+	}
+
+	acj.Spec.Template = kruiseappsv1alpha1.CronJobTemplate{
+		BroadcastJobTemplate: &kruiseappsv1alpha1.BroadcastJobTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "clean"}}},
+	}
+	_, broadcastJobs, err := o.listJobs(acj)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(broadcastJobs) != 1 || broadcastJobs[0].Name != "clean-1" {
+		t.Errorf("expected only the broadcastjob clean-1, got %v", broadcastJobs)
+	}
+
+	// jobs without labels can not be selected without listing every job in the namespace
+	acj.Spec.Template = kruiseappsv1alpha1.CronJobTemplate{BroadcastJobTemplate: &kruiseappsv1alpha1.BroadcastJobTemplateSpec{}}
+	if _, _, err := o.listJobs(acj); err == nil {
+		t.Errorf("expected an error for a broadcastjob template without labels")
+	}
+	acj.Spec.Template = kruiseappsv1alpha1.CronJobTemplate{JobTemplate: &batchv1beta1.JobTemplateSpec{}}
+	actions := len(clientset.Actions())
+	if _, _, err := o.listJobs(acj); err == nil {
+		t.Errorf("expected an error for a job template without labels")
+	}
+	if len(clientset.Actions()) != actions {
+		t.Errorf("expected no jobs to be listed, got %v", clientset.Actions()[actions:])
+	}
+}
@@ -5,8 +5,19 @@ import (
 	"io"
 	"os"
 
+	kadvancedcronjob "github.com/hantmac/kubectl-kruise/pkg/cmd/advancedcronjob"
+	kcreate "github.com/hantmac/kubectl-kruise/pkg/cmd/create"
+	kdrain "github.com/hantmac/kubectl-kruise/pkg/cmd/drain"
+	kimages "github.com/hantmac/kubectl-kruise/pkg/cmd/images"
+	klogs "github.com/hantmac/kubectl-kruise/pkg/cmd/logs"
+	kmigrate "github.com/hantmac/kubectl-kruise/pkg/cmd/migrate"
+	kprewarm "github.com/hantmac/kubectl-kruise/pkg/cmd/prewarm"
+	krestart "github.com/hantmac/kubectl-kruise/pkg/cmd/restart"
 	krollout "github.com/hantmac/kubectl-kruise/pkg/cmd/rollout"
+	kscale "github.com/hantmac/kubectl-kruise/pkg/cmd/scale"
 	kset "github.com/hantmac/kubectl-kruise/pkg/cmd/set"
+	ksidecarset "github.com/hantmac/kubectl-kruise/pkg/cmd/sidecarset"
+	kspread "github.com/hantmac/kubectl-kruise/pkg/cmd/spread"
 	ktop "github.com/hantmac/kubectl-kruise/pkg/cmd/top"
 	"github.com/spf13/cobra"
 
@@ -23,16 +34,13 @@ import (
 	"k8s.io/kubectl/pkg/cmd/debug"
 	"k8s.io/kubectl/pkg/cmd/describe"
 	"k8s.io/kubectl/pkg/cmd/diff"
-	"k8s.io/kubectl/pkg/cmd/drain"
 	cmdexec "k8s.io/kubectl/pkg/cmd/exec"
 	"k8s.io/kubectl/pkg/cmd/kustomize"
-	"k8s.io/kubectl/pkg/cmd/logs"
 	"k8s.io/kubectl/pkg/cmd/options"
 	"k8s.io/kubectl/pkg/cmd/patch"
 	"k8s.io/kubectl/pkg/cmd/plugin"
 	"k8s.io/kubectl/pkg/cmd/portforward"
 	"k8s.io/kubectl/pkg/cmd/replace"
-	"k8s.io/kubectl/pkg/cmd/scale"
 	"k8s.io/kubectl/pkg/cmd/taint"
 
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -364,19 +372,32 @@ func NewKubectlCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 			Commands: []*cobra.Command{
 				krollout.NewCmdRollout(f, ioStreams),
 				kset.NewCmdSet(f, ioStreams),
-				scale.NewCmdScale(f, ioStreams),
+				kscale.NewCmdScale(f, ioStreams),
 				autoscale.NewCmdAutoscale(f, ioStreams),
 			},
 		},
+		{
+			Message: "Kruise Workload Commands:",
+			Commands: []*cobra.Command{
+				kcreate.NewCmdCreate(f, ioStreams),
+				ksidecarset.NewCmdSidecarSet(f, ioStreams),
+				kadvancedcronjob.NewCmdAdvancedCronJob(f, ioStreams),
+				kprewarm.NewCmdPrewarm(f, ioStreams),
+				kimages.NewCmdImages(f, ioStreams),
+				krestart.NewCmdRestart(f, ioStreams),
+				kspread.NewCmdSpread(f, ioStreams),
+				kmigrate.NewCmdMigrate(f, ioStreams),
+			},
+		},
 		{
 			Message: "Cluster Management Commands:",
 			Commands: []*cobra.Command{
 				certificates.NewCmdCertificate(f, ioStreams),
 				clusterinfo.NewCmdClusterInfo(f, ioStreams),
 				ktop.NewCmdTop(f, ioStreams),
-				drain.NewCmdCordon(f, ioStreams),
-				drain.NewCmdUncordon(f, ioStreams),
-				drain.NewCmdDrain(f, ioStreams),
+				kdrain.NewCmdCordon(f, ioStreams),
+				kdrain.NewCmdUncordon(f, ioStreams),
+				kdrain.NewCmdDrain(f, ioStreams),
 				taint.NewCmdTaint(f, ioStreams),
 			},
 		},
@@ -384,7 +405,7 @@ func NewKubectlCommand(in io.Reader, out, err io.Writer) *cobra.Command {
 			Message: "Troubleshooting and Debugging Commands:",
 			Commands: []*cobra.Command{
 				describe.NewCmdDescribe("kubectl-kruise", f, ioStreams),
-				logs.NewCmdLogs(f, ioStreams),
+				klogs.NewCmdLogs(f, ioStreams),
 				attach.NewCmdAttach(f, ioStreams),
 				cmdexec.NewCmdExec(f, ioStreams),
 				portforward.NewCmdPortForward(f, ioStreams),
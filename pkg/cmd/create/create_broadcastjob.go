@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"context"
+	"fmt"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/create"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	broadcastJobLong = templates.LongDesc(i18n.T(`
+		Create a BroadcastJob with the specified name.
+
+		A BroadcastJob runs a pod to completion on every node of the cluster, which
+		makes it fit for node-level maintenance tasks.`))
+
+	broadcastJobExample = templates.Examples(i18n.T(`
+		# Create a broadcastjob
+		kubectl-kruise create broadcastjob my-job --image=busybox
+
+		# Create a broadcastjob with command
+		kubectl-kruise create broadcastjob my-job --image=busybox -- date
+
+		# Create a broadcastjob that runs on at most 2 nodes at a time and is deleted 10 minutes after it finished
+		kubectl-kruise create broadcastjob my-job --image=busybox --parallelism=2 --ttl-seconds-after-finished=600 -- date`))
+)
+
+// CreateBroadcastJobOptions is the command line options for 'create broadcastjob'
+type CreateBroadcastJobOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+
+	PrintObj func(obj runtime.Object) error
+
+	Name                    string
+	Image                   string
+	Command                 []string
+	Parallelism             string
+	TTLSecondsAfterFinished int32
+
+	Namespace      string
+	Client         client.Client
+	DryRunStrategy cmdutil.DryRunStrategy
+	Cmd            *cobra.Command
+
+	genericclioptions.IOStreams
+}
+
+// NewCreateBroadcastJobOptions initializes and returns new CreateBroadcastJobOptions instance
+func NewCreateBroadcastJobOptions(ioStreams genericclioptions.IOStreams) *CreateBroadcastJobOptions {
+	return &CreateBroadcastJobOptions{
+		PrintFlags:              genericclioptions.NewPrintFlags("created").WithTypeSetter(internalclient.Scheme),
+		TTLSecondsAfterFinished: -1,
+		IOStreams:               ioStreams,
+	}
+}
+
+// NewCmdCreateBroadcastJob is a command to create BroadcastJobs.
+func NewCmdCreateBroadcastJob(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := NewCreateBroadcastJobOptions(ioStreams)
+	cmd := &cobra.Command{
+		Use:                   "broadcastjob NAME --image=image -- [COMMAND] [args...]",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"bcj"},
+		Short:                 i18n.T("Create a BroadcastJob with the specified name."),
+		Long:                  broadcastJobLong,
+		Example:               broadcastJobExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVar(&o.Image, "image", o.Image, "Image name to run.")
+	cmd.Flags().StringVar(&o.Parallelism, "parallelism", o.Parallelism, "The maximum number or percentage of nodes the job runs on at the same time. Defaults to all nodes.")
+	cmd.Flags().Int32Var(&o.TTLSecondsAfterFinished, "ttl-seconds-after-finished", o.TTLSecondsAfterFinished, "The number of seconds after the job finished that it is deleted. The job is kept if negative.")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *CreateBroadcastJobOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	name, err := create.NameFromCommandArgs(cmd, args)
+	if err != nil {
+		return err
+	}
+	o.Name = name
+	if len(args) > 1 {
+		o.Command = args[1:]
+	}
+
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Cmd = cmd
+
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		o.Client = internalclient.NewManager().GetClient()
+	}
+	cmdutil.PrintFlagsWithDryRunStrategy(o.PrintFlags, o.DryRunStrategy)
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	o.PrintObj = func(obj runtime.Object) error {
+		return printer.PrintObj(obj, o.Out)
+	}
+
+	return nil
+}
+
+// Validate makes sure provided values and valid BroadcastJob options
+func (o *CreateBroadcastJobOptions) Validate() error {
+	if len(o.Image) == 0 {
+		return fmt.Errorf("--image must be specified")
+	}
+	if len(o.Parallelism) > 0 {
+		parallelism := intstr.Parse(o.Parallelism)
+		if _, err := intstr.GetValueFromIntOrPercent(&parallelism, 100, true); err != nil {
+			return fmt.Errorf("invalid --parallelism %q: %v", o.Parallelism, err)
+		}
+		if parallelism.Type == intstr.Int && parallelism.IntVal <= 0 {
+			return fmt.Errorf("--parallelism must be greater than 0")
+		}
+	}
+	return nil
+}
+
+// Run performs the execution of 'create broadcastjob' sub command
+func (o *CreateBroadcastJobOptions) Run() error {
+	job := o.createBroadcastJob()
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		var opts []client.CreateOption
+		if o.DryRunStrategy == cmdutil.DryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := o.Client.Create(context.TODO(), job, opts...); err != nil {
+			return fmt.Errorf("failed to create broadcastjob: %v", err)
+		}
+	}
+
+	return o.PrintObj(job)
+}
+
+func (o *CreateBroadcastJobOptions) createBroadcastJob() *kruiseappsv1alpha1.BroadcastJob {
+	job := &kruiseappsv1alpha1.BroadcastJob{
+		// this is ok because we know exactly how we want to be serialized
+		TypeMeta: metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.SchemeGroupVersion.String(), Kind: "BroadcastJob"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      o.Name,
+			Namespace: o.Namespace,
+		},
+		Spec: kruiseappsv1alpha1.BroadcastJobSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    o.Name,
+							Image:   o.Image,
+							Command: o.Command,
+						},
+					},
+					RestartPolicy: corev1.RestartPolicyNever,
+				},
+			},
+			CompletionPolicy: kruiseappsv1alpha1.CompletionPolicy{
+				Type: kruiseappsv1alpha1.Always,
+			},
+		},
+	}
+	if len(o.Parallelism) > 0 {
+		parallelism := intstr.Parse(o.Parallelism)
+		job.Spec.Parallelism = &parallelism
+	}
+	if o.TTLSecondsAfterFinished >= 0 {
+		ttl := o.TTLSecondsAfterFinished
+		job.Spec.CompletionPolicy.TTLSecondsAfterFinished = &ttl
+	}
+	return job
+}
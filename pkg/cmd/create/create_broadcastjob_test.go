@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCreateBroadcastJob(t *testing.T) {
+	o := &CreateBroadcastJobOptions{
+		Name:                    "my-job",
+		Namespace:               "bar",
+		Image:                   "busybox",
+		Command:                 []string{"date"},
+		Parallelism:             "50%",
+		TTLSecondsAfterFinished: 600,
+	}
+	if err := o.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job := o.createBroadcastJob()
+
+	if job.Name != "my-job" || job.Namespace != "bar" {
+		t.Errorf("unexpected object meta: %#v", job.ObjectMeta)
+	}
+	containers := job.Spec.Template.Spec.Containers
+	if len(containers) != 1 || containers[0].Name != "my-job" || containers[0].Image != "busybox" || containers[0].Command[0] != "date" {
+		t.Errorf("unexpected containers: %#v", containers)
+	}
+	if job.Spec.Template.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected restart policy Never, got %s", job.Spec.Template.Spec.RestartPolicy)
+	}
+	if job.Spec.Parallelism == nil || *job.Spec.Parallelism != intstr.FromString("50%") {
+		t.Errorf("expected parallelism 50%%, got %v", job.Spec.Parallelism)
+	}
+	policy := job.Spec.CompletionPolicy
+	if policy.Type != kruiseappsv1alpha1.Always || policy.TTLSecondsAfterFinished == nil || *policy.TTLSecondsAfterFinished != 600 {
+		t.Errorf("unexpected completion policy: %#v", policy)
+	}
+}
+
+func TestCreateBroadcastJobValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		image       string
+		parallelism string
+		expectErr   bool
+	}{
+		{name: "image only", image: "busybox"},
+		{name: "no image", expectErr: true},
+		{name: "invalid parallelism", image: "busybox", parallelism: "abc", expectErr: true},
+		{name: "zero parallelism", image: "busybox", parallelism: "0", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &CreateBroadcastJobOptions{Name: "my-job", Image: tt.image, Parallelism: tt.parallelism}
+			err := o.Validate()
+			if tt.expectErr != (err != nil) {
+				t.Errorf("expected error %t, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
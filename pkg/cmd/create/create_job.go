@@ -0,0 +1,191 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"context"
+	"fmt"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/create"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// instantiateAnnotation marks jobs that were created from a cron job by hand rather than by its schedule.
+const instantiateAnnotation = "cronjob.kubernetes.io/instantiate"
+
+var (
+	jobLong = templates.LongDesc(i18n.T(`
+		Create a job with the specified name.
+
+		With --from=advancedcronjob/NAME the Job or BroadcastJob template of an
+		AdvancedCronJob is instantiated immediately, regardless of its schedule.`))
+
+	jobExample = templates.Examples(i18n.T(`
+		# Create a job
+		kubectl-kruise create job my-job --image=busybox
+
+		# Create a job with command
+		kubectl-kruise create job my-job --image=busybox -- date
+
+		# Create a job from a CronJob named "a-cronjob"
+		kubectl-kruise create job test-job --from=cronjob/a-cronjob
+
+		# Run the job of an AdvancedCronJob named "backup" now
+		kubectl-kruise create job backup-manual --from=advancedcronjob/backup`))
+)
+
+// CreateJobOptions is the command line options for 'create job'
+type CreateJobOptions struct {
+	*create.CreateJobOptions
+
+	Client     client.Client
+	NewBuilder func() *resource.Builder
+}
+
+// NewCmdCreateJob is a command to ease creating Jobs from CronJobs and AdvancedCronJobs.
+func NewCmdCreateJob(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := &CreateJobOptions{CreateJobOptions: create.NewCreateJobOptions(ioStreams)}
+	cmd := &cobra.Command{
+		Use:                   "job NAME --image=image [--from=cronjob/name|advancedcronjob/name] -- [COMMAND] [args...]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Create a job with the specified name."),
+		Long:                  jobLong,
+		Example:               jobExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+
+	cmdutil.AddApplyAnnotationFlags(cmd)
+	cmdutil.AddValidateFlags(cmd)
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVar(&o.Image, "image", o.Image, "Image name to run.")
+	cmd.Flags().StringVar(&o.From, "from", o.From, "The name of the resource to create a Job from (cronjob and advancedcronjob are supported).")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *CreateJobOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if err := o.CreateJobOptions.Complete(f, cmd, args); err != nil {
+		return err
+	}
+	o.NewBuilder = f.NewBuilder
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		o.Client = internalclient.NewManager().GetClient()
+	}
+	return nil
+}
+
+// Run performs the execution of 'create job' sub command
+func (o *CreateJobOptions) Run() error {
+	if len(o.From) == 0 {
+		return o.CreateJobOptions.Run()
+	}
+	infos, err := o.NewBuilder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(false, o.From).
+		Flatten().
+		Latest().
+		Do().
+		Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) != 1 {
+		return fmt.Errorf("from must be an existing cronjob or advancedcronjob")
+	}
+	acj, ok := infos[0].Object.(*kruiseappsv1alpha1.AdvancedCronJob)
+	if !ok {
+		// not an AdvancedCronJob, let the upstream command create the job from a CronJob
+		o.Builder = o.NewBuilder()
+		return o.CreateJobOptions.Run()
+	}
+
+	job, err := jobFromAdvancedCronJob(acj, o.Name)
+	if err != nil {
+		return err
+	}
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		var opts []client.CreateOption
+		if o.DryRunStrategy == cmdutil.DryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := o.Client.Create(context.TODO(), job, opts...); err != nil {
+			return fmt.Errorf("failed to create job: %v", err)
+		}
+	}
+
+	return o.PrintObj(job)
+}
+
+// jobFromAdvancedCronJob returns a Job or BroadcastJob named name from the template of acj,
+// controlled by acj like the jobs it schedules itself.
+func jobFromAdvancedCronJob(acj *kruiseappsv1alpha1.AdvancedCronJob, name string) (runtime.Object, error) {
+	objectMeta := func(template metav1.ObjectMeta) metav1.ObjectMeta {
+		annotations := map[string]string{instantiateAnnotation: "manual"}
+		for k, v := range template.Annotations {
+			annotations[k] = v
+		}
+		return metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   acj.Namespace,
+			Annotations: annotations,
+			Labels:      template.Labels,
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(acj, kruiseappsv1alpha1.SchemeGroupVersion.WithKind(kruiseappsv1alpha1.AdvancedCronJobKind)),
+			},
+		}
+	}
+
+	switch template := acj.Spec.Template; {
+	case template.JobTemplate != nil:
+		return &batchv1.Job{
+			// this is ok because we know exactly how we want to be serialized
+			TypeMeta:   metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"},
+			ObjectMeta: objectMeta(template.JobTemplate.ObjectMeta),
+			Spec:       template.JobTemplate.Spec,
+		}, nil
+	case template.BroadcastJobTemplate != nil:
+		return &kruiseappsv1alpha1.BroadcastJob{
+			// this is ok because we know exactly how we want to be serialized
+			TypeMeta:   metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.SchemeGroupVersion.String(), Kind: "BroadcastJob"},
+			ObjectMeta: objectMeta(template.BroadcastJobTemplate.ObjectMeta),
+			Spec:       template.BroadcastJobTemplate.Spec,
+		}, nil
+	default:
+		return nil, fmt.Errorf("advancedcronjob %q has neither a job nor a broadcastjob template", acj.Name)
+	}
+}
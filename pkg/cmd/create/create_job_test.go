@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package create
+
+import (
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	batchv1 "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestJobFromAdvancedCronJob(t *testing.T) {
+	acj := &kruiseappsv1alpha1.AdvancedCronJob{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "backup", UID: "acj-uid"},
+		Spec: kruiseappsv1alpha1.AdvancedCronJobSpec{
+			Template: kruiseappsv1alpha1.CronJobTemplate{
+				JobTemplate: &batchv1beta1.JobTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "backup"}},
+					Spec:       batchv1.JobSpec{BackoffLimit: new(int32)},
+				},
+			},
+		},
+	}
+
+	obj, err := jobFromAdvancedCronJob(acj, "backup-manual")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	job, ok := obj.(*batchv1.Job)
+	if !ok {
+		t.Fatalf("expected a Job, got %T", obj)
+	}
+	if job.Name != "backup-manual" || job.Namespace != "bar" || job.Labels["app"] != "backup" || job.Annotations[instantiateAnnotation] != "manual" {
+		t.Errorf("unexpected object meta: %#v", job.ObjectMeta)
+	}
+	if !metav1.IsControlledBy(job, acj) {
+		t.Errorf("expected job to be controlled by the advancedcronjob, got %#v", job.OwnerReferences)
+	}
+	if job.Spec.BackoffLimit == nil {
+		t.Errorf("expected job spec to be copied from the template")
+	}
+
+	acj.Spec.Template = kruiseappsv1alpha1.CronJobTemplate{
+		BroadcastJobTemplate: &kruiseappsv1alpha1.BroadcastJobTemplateSpec{},
+	}
+	obj, err = jobFromAdvancedCronJob(acj, "backup-manual")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := obj.(*kruiseappsv1alpha1.BroadcastJob); !ok {
+		t.Errorf("expected a BroadcastJob, got %T", obj)
+	}
+
+	acj.Spec.Template = kruiseappsv1alpha1.CronJobTemplate{}
+	if _, err := jobFromAdvancedCronJob(acj, "backup-manual"); err == nil {
+		t.Errorf("expected error for advancedcronjob without template")
+	}
+}
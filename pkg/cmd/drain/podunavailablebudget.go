@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hantmac/kubectl-kruise/pkg/internal/pub"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// budgetRetryInterval is how long to wait before retrying to evict pods blocked by PodUnavailableBudgets
+var budgetRetryInterval = 5 * time.Second
+
+// deleteOrEvictPods deletes or evicts pods with the drain helper. Pods protected by
+// PodUnavailableBudgets are evicted one at a time, only while all of their budgets allow another
+// unavailable pod, and are retried until the drain times out.
+func (o *DrainCmdOptions) deleteOrEvictPods(pods []corev1.Pod) error {
+	budgets, err := o.podBudgets(pods)
+	if err != nil {
+		return err
+	}
+
+	var protected, unprotected []corev1.Pod
+	for _, pod := range pods {
+		if len(budgets[podKey(&pod)]) > 0 && o.drainer.DryRunStrategy == cmdutil.DryRunNone {
+			protected = append(protected, pod)
+		} else {
+			unprotected = append(unprotected, pod)
+		}
+	}
+	if len(unprotected) > 0 {
+		if err := o.drainer.DeleteOrEvictPods(unprotected); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	for len(protected) > 0 {
+		var blocked []corev1.Pod
+		blockedBy := make(map[string]*pub.Budget)
+		for _, pod := range protected {
+			if budget := blockingBudget(budgets[podKey(&pod)]); budget != nil {
+				blocked = append(blocked, pod)
+				blockedBy[podKey(&pod)] = budget
+				continue
+			}
+			if err := o.drainer.DeleteOrEvictPods([]corev1.Pod{pod}); err != nil {
+				return err
+			}
+			// the status of the budgets only catches up once the controller has seen the pod go
+			for _, budget := range budgets[podKey(&pod)] {
+				budget.UnavailableAllowed--
+			}
+		}
+		if len(blocked) == 0 {
+			return nil
+		}
+
+		if o.drainer.Timeout > 0 && time.Since(start) >= o.drainer.Timeout {
+			return fmt.Errorf("drain did not complete within %v, pods blocked by PodUnavailableBudgets: %s",
+				o.drainer.Timeout, blockedPods(blocked, blockedBy))
+		}
+		for _, pod := range blocked {
+			budget := blockedBy[podKey(&pod)]
+			fmt.Fprintf(o.ErrOut, "evicting pod %s/%s blocked by PodUnavailableBudget %s with %d unavailable pods allowed (will retry after %v)\n",
+				pod.Namespace, pod.Name, budget.Name, budget.UnavailableAllowed, budgetRetryInterval)
+		}
+		time.Sleep(budgetRetryInterval)
+
+		protected = blocked
+		if budgets, err = o.podBudgets(protected); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// podBudgets returns the PodUnavailableBudgets protecting each of pods, by the key of the pod.
+// Pods protected by the same budget share it.
+func (o *DrainCmdOptions) podBudgets(pods []corev1.Pod) (map[string][]*pub.Budget, error) {
+	namespaceBudgets := make(map[string][]*pub.Budget)
+	budgets := make(map[string][]*pub.Budget)
+	for i := range pods {
+		pod := &pods[i]
+		candidates, ok := namespaceBudgets[pod.Namespace]
+		if !ok {
+			var err error
+			if candidates, err = pub.List(context.TODO(), o.dynamicClient, pod.Namespace); err != nil {
+				return nil, fmt.Errorf("failed to list PodUnavailableBudgets in namespace %s: %v", pod.Namespace, err)
+			}
+			namespaceBudgets[pod.Namespace] = candidates
+		}
+		if len(candidates) == 0 {
+			continue
+		}
+
+		workload, err := o.workloadOf(pod)
+		if err != nil {
+			return nil, err
+		}
+		for _, budget := range candidates {
+			if budget.Protects(pod, workload) {
+				budgets[podKey(pod)] = append(budgets[podKey(pod)], budget)
+			}
+		}
+	}
+	return budgets, nil
+}
+
+// workloadOf returns the controller of pod, or the controller of its ReplicaSet for pods of Deployments.
+func (o *DrainCmdOptions) workloadOf(pod *corev1.Pod) (*metav1.OwnerReference, error) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil || ref.Kind != "ReplicaSet" {
+		return ref, nil
+	}
+	rs, err := o.drainer.Client.AppsV1().ReplicaSets(pod.Namespace).Get(context.TODO(), ref.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return ref, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if owner := metav1.GetControllerOf(rs); owner != nil {
+		return owner, nil
+	}
+	return ref, nil
+}
+
+// blockingBudget returns the first of budgets that allows no more unavailable pods, if any.
+func blockingBudget(budgets []*pub.Budget) *pub.Budget {
+	for _, budget := range budgets {
+		if budget.UnavailableAllowed <= 0 {
+			return budget
+		}
+	}
+	return nil
+}
+
+func blockedPods(pods []corev1.Pod, blockedBy map[string]*pub.Budget) string {
+	var names []string
+	for _, pod := range pods {
+		names = append(names, fmt.Sprintf("%s (%s)", podKey(&pod), blockedBy[podKey(&pod)].Name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+func podKey(pod *corev1.Pod) string {
+	return pod.Namespace + "/" + pod.Name
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drain
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hantmac/kubectl-kruise/pkg/internal/pub"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDeleteOrEvictPodsWithBudgets(t *testing.T) {
+	budgetRetryInterval = 10 * time.Millisecond
+
+	pod := func(name, app string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name, Labels: map[string]string{"app": app}}}
+	}
+	budget := func(unavailableAllowed int64) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+			},
+			"status": map[string]interface{}{"unavailableAllowed": unavailableAllowed},
+		}}
+		obj.SetAPIVersion(pub.GroupVersionResource.GroupVersion().String())
+		obj.SetKind("PodUnavailableBudget")
+		obj.SetNamespace("bar")
+		obj.SetName("web-pub")
+		return obj
+	}
+
+	tests := []struct {
+		name               string
+		unavailableAllowed int64
+		expectRemaining    []string
+		expectErr          string
+		expectBlocked      bool
+	}{
+		{
+			name:               "budget allows all pods",
+			unavailableAllowed: 2,
+		},
+		{
+			name:               "budget allows one pod at a time",
+			unavailableAllowed: 1,
+			expectBlocked:      true,
+		},
+		{
+			name:               "budget blocks all pods",
+			unavailableAllowed: 0,
+			expectRemaining:    []string{"web-1", "web-2"},
+			expectErr:          "bar/web-1 (web-pub), bar/web-2 (web-pub)",
+			expectBlocked:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pods := []*corev1.Pod{pod("web-1", "web"), pod("web-2", "web"), pod("db-1", "db")}
+			client := fake.NewSimpleClientset(pods[0], pods[1], pods[2])
+
+			streams, _, _, errOut := genericclioptions.NewTestIOStreams()
+			o := NewDrainCmdOptions(nil, streams)
+			o.drainer.Client = client
+			o.drainer.DisableEviction = true
+			o.drainer.Timeout = 50 * time.Millisecond
+			o.dynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), budget(tt.unavailableAllowed))
+			o.ToPrinter = func(operation string) (printers.ResourcePrinterFunc, error) {
+				return func(runtime.Object, io.Writer) error { return nil }, nil
+			}
+
+			err := o.deleteOrEvictPods([]corev1.Pod{*pods[0], *pods[1], *pods[2]})
+			if len(tt.expectErr) == 0 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(tt.expectErr) > 0 && (err == nil || !strings.Contains(err.Error(), tt.expectErr)) {
+				t.Fatalf("expected error containing %q, got %v", tt.expectErr, err)
+			}
+			if blocked := strings.Contains(errOut.String(), "blocked by PodUnavailableBudget web-pub"); blocked != tt.expectBlocked {
+				t.Errorf("expected blocked pods reported %t, got %q", tt.expectBlocked, errOut.String())
+			}
+
+			remaining, err := client.CoreV1().Pods("bar").List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, pod := range remaining.Items {
+				names = append(names, pod.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.expectRemaining, ",") {
+				t.Errorf("expected remaining pods %v, got %v", tt.expectRemaining, names)
+			}
+		})
+	}
+}
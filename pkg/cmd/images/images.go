@@ -0,0 +1,50 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	imagesLong = templates.LongDesc(`
+		Inspect the images cached on the nodes of the cluster
+
+		These commands read the NodeImages Kruise keeps for every node, which tell
+		whether an image is already pulled on a node before a pod needs it.`)
+)
+
+// NewCmdImages returns an initialized Command instance for 'images' sub command
+func NewCmdImages(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "images SUBCOMMAND",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Inspect the images cached on nodes"),
+		Long:                  imagesLong,
+		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+
+	// add subcommands
+	cmd.AddCommand(NewCmdImagesNodes(f, streams))
+
+	return cmd
+}
@@ -0,0 +1,256 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// NodesOptions holds the options for 'images nodes' sub command
+type NodesOptions struct {
+	Nodes     []string
+	Image     string
+	NoHeaders bool
+
+	Reader client.Reader
+
+	genericclioptions.IOStreams
+}
+
+var (
+	nodesLong = templates.LongDesc(`
+		List the images cached on nodes.
+
+		Every tag of an image Kruise pulls on a node is listed with the phase of its
+		pull, its size and how long ago it was pulled. Filter by --image to see which
+		nodes already have an image, or by node names to see the images of those nodes.
+		An image given with a tag only matches that tag.
+
+		The size is read from the status of the node, it is unknown for images the
+		kubelet does not report.`)
+
+	nodesExample = templates.Examples(`
+		# List the nodes the image repo/app:v2 is cached on
+		kubectl-kruise images nodes --image=repo/app:v2
+
+		# List the nodes any tag of the image repo/app is cached on
+		kubectl-kruise images nodes --image=repo/app
+
+		# List the images cached on the node node-1
+		kubectl-kruise images nodes node-1`)
+)
+
+// nodeImageTag is the state of a tag of an image on a node.
+type nodeImageTag struct {
+	Node     string
+	Image    string
+	Tag      string
+	Phase    string
+	Size     int64
+	LastPull *metav1.Time
+}
+
+// NewCmdImagesNodes returns a Command instance for 'images nodes' sub command
+func NewCmdImagesNodes(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &NodesOptions{
+		IOStreams: streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "nodes [NODE...] [--image=IMAGE]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List the images cached on nodes"),
+		Long:                  nodesLong,
+		Example:               nodesExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVar(&o.Image, "image", o.Image, "Only list the nodes this image is cached on, all tags are listed if it has none.")
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", o.NoHeaders, "If present, print output without headers")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *NodesOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	o.Nodes = args
+	o.Reader = internalclient.NewManager().GetAPIReader()
+	return nil
+}
+
+// Run performs the execution of 'images nodes' sub command
+func (o *NodesOptions) Run() error {
+	nodeImages := &kruiseappsv1alpha1.NodeImageList{}
+	if err := o.Reader.List(context.TODO(), nodeImages); err != nil {
+		return err
+	}
+	nodes := &corev1.NodeList{}
+	if err := o.Reader.List(context.TODO(), nodes); err != nil {
+		return err
+	}
+
+	tags := nodeImageTags(nodeImages.Items, nodes.Items, o.Nodes, o.Image)
+	if len(tags) == 0 {
+		fmt.Fprintln(o.ErrOut, "No cached images found")
+		return nil
+	}
+
+	now := time.Now()
+	w := printers.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NODE\tIMAGE\tTAG\tPHASE\tSIZE\tLAST PULL")
+	}
+	for _, tag := range tags {
+		size := "<unknown>"
+		if tag.Size > 0 {
+			size = formatSize(tag.Size)
+		}
+		lastPull := "<none>"
+		if tag.LastPull != nil {
+			lastPull = duration.HumanDuration(now.Sub(tag.LastPull.Time)) + " ago"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", tag.Node, tag.Image, tag.Tag, tag.Phase, size, lastPull)
+	}
+	return nil
+}
+
+// nodeImageTags returns the tags of images on the nodes, sorted by node, image and tag. Only
+// the given nodes are included if any, and only the tags of image if it is not empty.
+func nodeImageTags(nodeImages []kruiseappsv1alpha1.NodeImage, nodes []corev1.Node, nodeNames []string, image string) []nodeImageTag {
+	imageName, imageTag := splitImage(image)
+	names := sets.NewString(nodeNames...)
+	sizes := make(map[string]int64)
+	for i := range nodes {
+		for _, containerImage := range nodes[i].Status.Images {
+			for _, name := range containerImage.Names {
+				sizes[nodes[i].Name+"/"+name] = containerImage.SizeBytes
+			}
+		}
+	}
+
+	var tags []nodeImageTag
+	for i := range nodeImages {
+		nodeImage := &nodeImages[i]
+		if names.Len() > 0 && !names.Has(nodeImage.Name) {
+			continue
+		}
+
+		// tags only in the spec are still waiting to be pulled
+		statuses := make(map[string]map[string]kruiseappsv1alpha1.ImageTagStatus)
+		for name, imageStatus := range nodeImage.Status.ImageStatuses {
+			statuses[name] = make(map[string]kruiseappsv1alpha1.ImageTagStatus)
+			for _, tagStatus := range imageStatus.Tags {
+				statuses[name][tagStatus.Tag] = tagStatus
+			}
+		}
+		for name, imageSpec := range nodeImage.Spec.Images {
+			if statuses[name] == nil {
+				statuses[name] = make(map[string]kruiseappsv1alpha1.ImageTagStatus)
+			}
+			for _, tagSpec := range imageSpec.Tags {
+				if _, ok := statuses[name][tagSpec.Tag]; !ok {
+					statuses[name][tagSpec.Tag] = kruiseappsv1alpha1.ImageTagStatus{Tag: tagSpec.Tag, Phase: kruiseappsv1alpha1.ImagePhaseWaiting}
+				}
+			}
+		}
+
+		for name, tagStatuses := range statuses {
+			if len(imageName) > 0 && name != imageName {
+				continue
+			}
+			for tag, tagStatus := range tagStatuses {
+				if len(imageTag) > 0 && tag != imageTag {
+					continue
+				}
+				phase := string(tagStatus.Phase)
+				if tagStatus.Phase == kruiseappsv1alpha1.ImagePhasePulling && tagStatus.Progress > 0 {
+					phase = fmt.Sprintf("%s (%d%%)", phase, tagStatus.Progress)
+				}
+				tags = append(tags, nodeImageTag{
+					Node:     nodeImage.Name,
+					Image:    name,
+					Tag:      tag,
+					Phase:    phase,
+					Size:     sizes[nodeImage.Name+"/"+name+":"+tag],
+					LastPull: tagStatus.CompletionTime,
+				})
+			}
+		}
+	}
+
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Node != tags[j].Node {
+			return tags[i].Node < tags[j].Node
+		}
+		if tags[i].Image != tags[j].Image {
+			return tags[i].Image < tags[j].Image
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+	return tags
+}
+
+// splitImage splits image into its name and tag, a registry port is not taken for a tag. The
+// digest of an image referenced by digest is taken for its tag.
+func splitImage(image string) (string, string) {
+	if i := strings.Index(image, "@"); i >= 0 {
+		name, _ := splitImage(image[:i])
+		return name, image[i+1:]
+	}
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") {
+		return image, ""
+	}
+	return image[:i], image[i+1:]
+}
+
+// formatSize returns size in bytes in a human readable form.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit && exp < 3; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(size)/float64(div), "KMGT"[exp])
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package images
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeImageTags(t *testing.T) {
+	pulled := metav1.NewTime(time.Now().Add(-time.Hour))
+	nodeImage := func(node string, specTags map[string][]string, statusTags map[string][]kruiseappsv1alpha1.ImageTagStatus) kruiseappsv1alpha1.NodeImage {
+		ni := kruiseappsv1alpha1.NodeImage{ObjectMeta: metav1.ObjectMeta{Name: node}}
+		ni.Spec.Images = make(map[string]kruiseappsv1alpha1.ImageSpec)
+		for name, tags := range specTags {
+			imageSpec := kruiseappsv1alpha1.ImageSpec{}
+			for _, tag := range tags {
+				imageSpec.Tags = append(imageSpec.Tags, kruiseappsv1alpha1.ImageTagSpec{Tag: tag})
+			}
+			ni.Spec.Images[name] = imageSpec
+		}
+		ni.Status.ImageStatuses = make(map[string]kruiseappsv1alpha1.ImageStatus)
+		for name, tags := range statusTags {
+			ni.Status.ImageStatuses[name] = kruiseappsv1alpha1.ImageStatus{Tags: tags}
+		}
+		return ni
+	}
+	nodeImages := []kruiseappsv1alpha1.NodeImage{
+		nodeImage("node-2",
+			map[string][]string{"repo/app": {"v1", "v2"}},
+			map[string][]kruiseappsv1alpha1.ImageTagStatus{"repo/app": {{Tag: "v1", Phase: kruiseappsv1alpha1.ImagePhaseSucceeded, CompletionTime: &pulled}}}),
+		nodeImage("node-1",
+			map[string][]string{"repo/app": {"v2"}, "nginx": {"1.19"}},
+			map[string][]kruiseappsv1alpha1.ImageTagStatus{
+				"repo/app": {{Tag: "v2", Phase: kruiseappsv1alpha1.ImagePhasePulling, Progress: 40}},
+				"nginx":    {{Tag: "1.19", Phase: kruiseappsv1alpha1.ImagePhaseFailed}},
+			}),
+	}
+	nodes := []corev1.Node{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-2"},
+		Status: corev1.NodeStatus{
+			Images: []corev1.ContainerImage{{Names: []string{"repo/app:v1", "repo/app@sha256:abc"}, SizeBytes: 1024}},
+		},
+	}}
+
+	tests := []struct {
+		name      string
+		nodeNames []string
+		image     string
+		expected  []nodeImageTag
+	}{
+		{
+			name:  "image with tag",
+			image: "repo/app:v2",
+			expected: []nodeImageTag{
+				{Node: "node-1", Image: "repo/app", Tag: "v2", Phase: "Pulling (40%)"},
+				{Node: "node-2", Image: "repo/app", Tag: "v2", Phase: "Waiting"},
+			},
+		},
+		{
+			name:  "image without tag",
+			image: "repo/app",
+			expected: []nodeImageTag{
+				{Node: "node-1", Image: "repo/app", Tag: "v2", Phase: "Pulling (40%)"},
+				{Node: "node-2", Image: "repo/app", Tag: "v1", Phase: "Succeeded", Size: 1024, LastPull: &pulled},
+				{Node: "node-2", Image: "repo/app", Tag: "v2", Phase: "Waiting"},
+			},
+		},
+		{
+			name:      "images on a node",
+			nodeNames: []string{"node-1"},
+			expected: []nodeImageTag{
+				{Node: "node-1", Image: "nginx", Tag: "1.19", Phase: "Failed"},
+				{Node: "node-1", Image: "repo/app", Tag: "v2", Phase: "Pulling (40%)"},
+			},
+		},
+		{
+			name:  "unknown image",
+			image: "repo/other",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags := nodeImageTags(nodeImages, nodes, tt.nodeNames, tt.image)
+			if !reflect.DeepEqual(tags, tt.expected) {
+				t.Errorf("expected %+v, got %+v", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestSplitImage(t *testing.T) {
+	tests := []struct {
+		image string
+		name  string
+		tag   string
+	}{
+		{image: "nginx", name: "nginx"},
+		{image: "nginx:1.19", name: "nginx", tag: "1.19"},
+		{image: "registry:5000/repo/app", name: "registry:5000/repo/app"},
+		{image: "registry:5000/repo/app:v2", name: "registry:5000/repo/app", tag: "v2"},
+		{image: "repo/app@sha256:abcd", name: "repo/app", tag: "sha256:abcd"},
+		{image: "registry:5000/repo/app:v2@sha256:abcd", name: "registry:5000/repo/app", tag: "sha256:abcd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			name, tag := splitImage(tt.image)
+			if name != tt.name || tag != tt.tag {
+				t.Errorf("expected %q and %q, got %q and %q", tt.name, tt.tag, name, tag)
+			}
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:             "512B",
+		1536:            "1.5KiB",
+		150 * (1 << 20): "150.0MiB",
+		5 * (1 << 30):   "5.0GiB",
+	}
+	for size, expected := range tests {
+		if formatted := formatSize(size); formatted != expected {
+			t.Errorf("expected %d to be formatted as %q, got %q", size, expected, formatted)
+		}
+	}
+}
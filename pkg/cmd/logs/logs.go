@@ -0,0 +1,82 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package logs
+
+import (
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/kubectl/pkg/cmd/logs"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/polymorphichelpers"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	logsUsageStr = "logs [-f] [-p] (POD | TYPE/NAME) [-c CONTAINER]"
+)
+
+var (
+	logsLong = templates.LongDesc(`
+		Print the logs for a container in a pod or specified resource.
+		If the pod has only one container, the container name is optional.
+
+		For a BroadcastJob the logs of its pods on all nodes are printed, each
+		line prefixed with the name of the node the pod runs on.`)
+
+	logsExample = templates.Examples(i18n.T(`
+		# Return snapshot logs from pod nginx with only one container
+		kubectl-kruise logs nginx
+
+		# Return snapshot logs from all containers in pods defined by label app=nginx
+		kubectl-kruise logs -lapp=nginx --all-containers=true
+
+		# Begin streaming the logs of the ruby container in pod web-1
+		kubectl-kruise logs -f -c ruby web-1
+
+		# Return snapshot logs from first container of a CloneSet named nginx
+		kubectl-kruise logs cloneset/nginx
+
+		# Return snapshot logs from the pods of a BroadcastJob named hello on every node
+		kubectl-kruise logs broadcastjob/hello --all-containers=true
+
+		# Begin streaming the logs from the pods of a BroadcastJob named hello
+		kubectl-kruise logs -f broadcastjob/hello --max-log-requests=20`))
+)
+
+// NewCmdLogs creates a new pod logs command that also resolves Kruise workloads to their pods
+func NewCmdLogs(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := logs.NewLogsOptions(streams, false)
+
+	cmd := &cobra.Command{
+		Use:                   logsUsageStr,
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Print the logs for a container in a pod"),
+		Long:                  logsLong,
+		Example:               logsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			o.LogsForObject = polymorphichelpers.LogsForObjectFunc(internalpolymorphichelpers.LogsForObjectFn)
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.RunLogs())
+		},
+	}
+	o.AddFlags(cmd)
+	return cmd
+}
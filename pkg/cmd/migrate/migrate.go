@@ -0,0 +1,258 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	toCloneSet            = "cloneset"
+	toAdvancedStatefulSet = "asts"
+)
+
+var (
+	// pollInterval is the interval in which the progress of a migration is checked
+	pollInterval = 2 * time.Second
+
+	migrateLong = templates.LongDesc(i18n.T(`
+		Migrate a native workload to the equivalent Kruise workload.
+
+		A Deployment is migrated to a CloneSet with the same name, selector, pod template
+		and replicas, and its rolling update strategy mapped to maxUnavailable and maxSurge.
+		The CloneSet is created with 0 replicas, then the replicas are shifted from the
+		Deployment to the CloneSet --step replicas at a time, waiting for the new pods of
+		the CloneSet to be available before the Deployment is scaled down. With
+		--delete-source the Deployment is deleted once all replicas were moved. If the
+		migration is interrupted, for example by --timeout, running it again resumes it
+		from the replicas the CloneSet already has.
+
+		A StatefulSet is migrated to an Advanced StatefulSet with the same name, selector,
+		serviceName and volumeClaimTemplates. The StatefulSet is deleted with the orphan
+		propagation policy, so that its pods and PVCs are kept running, and the Advanced
+		StatefulSet adopts them. If the Advanced StatefulSet can not be created after all,
+		the StatefulSet is recreated to adopt its pods again. The migration fails if not
+		all pods were adopted or if not all PVCs of the pods are bound.
+
+		With --dry-run the generated workload is only printed. With --local the workload
+		equivalent to the resources of local manifest files is printed without contacting
+		the server, keeping the replicas of the source.`))
+
+	migrateExample = templates.Examples(i18n.T(`
+		# Migrate the deployment web to a cloneset, moving one replica at a time
+		kubectl-kruise migrate deployment/web --to=cloneset
+
+		# Migrate the deployment web to a cloneset, moving 3 replicas at a time, and delete the deployment afterwards
+		kubectl-kruise migrate deployment/web --to=cloneset --step=3 --delete-source
+
+		# Migrate the statefulset db to an advanced statefulset, keeping its pods
+		kubectl-kruise migrate statefulset/db --to=asts
+
+		# Print the cloneset generated for the deployment web without creating it
+		kubectl-kruise migrate deployment/web --to=cloneset --dry-run=client
+
+		# Convert the deployments of a local manifest file to clonesets
+		kubectl-kruise migrate -f web.yaml --to=cloneset --local -o yaml`))
+)
+
+// MigrateOptions is the start of the data required to perform the operation
+type MigrateOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+
+	PrintObj func(obj runtime.Object) error
+
+	FilenameOptions resource.FilenameOptions
+	Resources       []string
+	To              string
+	Step            int32
+	DeleteSource    bool
+	Timeout         time.Duration
+	Local           bool
+
+	Namespace        string
+	EnforceNamespace bool
+	Builder          func() *resource.Builder
+	Client           client.Client
+	Reader           client.Reader
+	DryRunStrategy   cmdutil.DryRunStrategy
+
+	genericclioptions.IOStreams
+}
+
+// NewMigrateOptions initializes and returns new MigrateOptions instance
+func NewMigrateOptions(streams genericclioptions.IOStreams) *MigrateOptions {
+	return &MigrateOptions{
+		PrintFlags: genericclioptions.NewPrintFlags("created").WithTypeSetter(internalclient.Scheme),
+		Step:       1,
+		Timeout:    5 * time.Minute,
+		IOStreams:  streams,
+	}
+}
+
+// NewCmdMigrate returns a Command instance for 'migrate' sub command
+func NewCmdMigrate(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewMigrateOptions(streams)
+
+	validArgs := []string{"deployment", "statefulset"}
+
+	cmd := &cobra.Command{
+		Use:                   "migrate (-f FILENAME | TYPE NAME | TYPE/NAME) --to=KIND",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Migrate a native workload to a Kruise workload"),
+		Long:                  migrateLong,
+		Example:               migrateExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+		ValidArgs: validArgs,
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+
+	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, "identifying the resource to migrate.")
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVar(&o.To, "to", o.To, "The kind of the workload to migrate to, one of: cloneset, asts.")
+	cmd.Flags().Int32Var(&o.Step, "step", o.Step, "The number of replicas moved to a cloneset at a time.")
+	cmd.Flags().BoolVar(&o.DeleteSource, "delete-source", o.DeleteSource, "If true, delete the deployment once all replicas were moved to a cloneset.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for each step of the migration. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	cmd.Flags().BoolVar(&o.Local, "local", o.Local, "If true, migrate will NOT contact api-server but print the workloads generated for local files.")
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *MigrateOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	o.Resources = args
+
+	var err error
+	o.Namespace, o.EnforceNamespace, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Builder = f.NewBuilder
+
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	if !o.Local {
+		mgr := internalclient.NewManager()
+		o.Reader = mgr.GetAPIReader()
+		o.Client = mgr.GetClient()
+	}
+
+	// the generated workload is the result of a dry run, print all of it by default
+	if (o.Local || o.DryRunStrategy == cmdutil.DryRunClient) && len(*o.PrintFlags.OutputFormat) == 0 {
+		*o.PrintFlags.OutputFormat = "yaml"
+	}
+	cmdutil.PrintFlagsWithDryRunStrategy(o.PrintFlags, o.DryRunStrategy)
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	o.PrintObj = func(obj runtime.Object) error {
+		return printer.PrintObj(obj, o.Out)
+	}
+	return nil
+}
+
+// Validate makes sure provided values in MigrateOptions are valid
+func (o *MigrateOptions) Validate() error {
+	if len(o.Resources) == 0 && cmdutil.IsFilenameSliceEmpty(o.FilenameOptions.Filenames, o.FilenameOptions.Kustomize) {
+		return fmt.Errorf("one or more resources must be specified as <resource> <name> or <resource>/<name>")
+	}
+	if o.Local && len(o.Resources) > 0 {
+		return resource.LocalResourceError
+	}
+	if o.Local && o.DryRunStrategy == cmdutil.DryRunServer {
+		return fmt.Errorf("cannot specify --local and --dry-run=server - did you mean --dry-run=client?")
+	}
+	switch o.To {
+	case toCloneSet, toAdvancedStatefulSet:
+	case "":
+		return fmt.Errorf("--to must be specified")
+	default:
+		return fmt.Errorf("invalid --to %q, must be one of: %s, %s", o.To, toCloneSet, toAdvancedStatefulSet)
+	}
+	if o.Step <= 0 {
+		return fmt.Errorf("--step must be greater than 0")
+	}
+	if o.Timeout <= 0 {
+		return fmt.Errorf("--timeout must be greater than 0")
+	}
+	return nil
+}
+
+// Run performs the execution of 'migrate' sub command
+func (o *MigrateOptions) Run() error {
+	builder := o.Builder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		LocalParam(o.Local).
+		ContinueOnError().
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		FilenameParam(o.EnforceNamespace, &o.FilenameOptions).
+		Flatten()
+	if !o.Local {
+		builder.ResourceTypeOrNameArgs(false, o.Resources...).
+			Latest()
+	}
+	infos, err := builder.Do().Infos()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, info := range infos {
+		if err := o.migrate(info); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return utilerrors.NewAggregate(errs)
+}
+
+func (o *MigrateOptions) migrate(info *resource.Info) error {
+	switch obj := info.Object.(type) {
+	case *appsv1.Deployment:
+		if o.To != toCloneSet {
+			return fmt.Errorf("deployment %q can only be migrated to a %s", info.Name, toCloneSet)
+		}
+		return o.migrateDeployment(obj)
+	case *appsv1.StatefulSet:
+		if o.To != toAdvancedStatefulSet {
+			return fmt.Errorf("statefulset %q can only be migrated to an %s", info.Name, toAdvancedStatefulSet)
+		}
+		return o.migrateStatefulSet(obj)
+	default:
+		return fmt.Errorf("migrating %s %q is not supported", info.Mapping.GroupVersionKind.Kind, info.Name)
+	}
+}
@@ -0,0 +1,269 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// migrationReplicasAnnotation records on a CloneSet the replicas of the Deployment it is migrated
+// from, so that an interrupted migration can be resumed
+const migrationReplicasAnnotation = "kruise.io/migration-replicas"
+
+// annotations of a Deployment that are not copied to the CloneSet generated for it
+var deploymentOnlyAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// migrateDeployment creates a CloneSet for the Deployment d and shifts the replicas of d to it.
+func (o *MigrateOptions) migrateDeployment(d *appsv1.Deployment) error {
+	cs := cloneSetFromDeployment(d)
+	if o.Local {
+		return o.PrintObj(cs)
+	}
+
+	total := int32(1)
+	if d.Spec.Replicas != nil {
+		total = *d.Spec.Replicas
+	}
+	if cs.Annotations == nil {
+		cs.Annotations = map[string]string{}
+	}
+	cs.Annotations[migrationReplicasAnnotation] = strconv.Itoa(int(total))
+	zero := int32(0)
+	cs.Spec.Replicas = &zero
+	if o.DryRunStrategy == cmdutil.DryRunClient {
+		return o.PrintObj(cs)
+	}
+	var opts []client.CreateOption
+	if o.DryRunStrategy == cmdutil.DryRunServer {
+		opts = append(opts, client.DryRunAll)
+	}
+	err := o.Client.Create(context.TODO(), cs, opts...)
+	if apierrors.IsAlreadyExists(err) && o.DryRunStrategy == cmdutil.DryRunNone {
+		total, err = o.resumeCloneSet(d, cs)
+	} else if err != nil {
+		err = fmt.Errorf("failed to create cloneset: %v", err)
+	}
+	if err != nil {
+		return err
+	}
+	if err := o.PrintObj(cs); err != nil {
+		return err
+	}
+	if o.DryRunStrategy == cmdutil.DryRunServer {
+		return nil
+	}
+
+	if err := o.shiftDeploymentReplicas(d, cs, total); err != nil {
+		return err
+	}
+	// a later migration of a Deployment with the same name must not resume this one
+	// the patched object is decoded into cs, which keeps annotations it already has
+	delete(cs.Annotations, migrationReplicasAnnotation)
+	patch := fmt.Sprintf(`{"metadata":{"annotations":{%q:null}}}`, migrationReplicasAnnotation)
+	if err := o.Client.Patch(context.TODO(), cs, client.RawPatch(types.MergePatchType, []byte(patch))); err != nil {
+		return fmt.Errorf("failed to complete migration of cloneset %q: %v", cs.Name, err)
+	}
+	if !o.DeleteSource {
+		fmt.Fprintf(o.Out, "deployment %q was scaled to 0 replicas, delete it with --delete-source once the migration is verified\n", d.Name)
+		return nil
+	}
+	if err := o.Client.Delete(context.TODO(), d, client.PropagationPolicy(metav1.DeletePropagationBackground)); err != nil {
+		return fmt.Errorf("failed to delete deployment %q: %v", d.Name, err)
+	}
+	fmt.Fprintf(o.Out, "deployment %q deleted\n", d.Name)
+	return nil
+}
+
+// cloneSetFromDeployment returns a CloneSet equivalent to the Deployment d, with the same name,
+// selector, pod template and replicas.
+func cloneSetFromDeployment(d *appsv1.Deployment) *kruiseappsv1alpha1.CloneSet {
+	cs := &kruiseappsv1alpha1.CloneSet{
+		// this is ok because we know exactly how we want to be serialized
+		TypeMeta: metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.SchemeGroupVersion.String(), Kind: "CloneSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        d.Name,
+			Namespace:   d.Namespace,
+			Labels:      d.Labels,
+			Annotations: withoutAnnotations(d.Annotations, deploymentOnlyAnnotations),
+		},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Replicas:             d.Spec.Replicas,
+			Selector:             d.Spec.Selector.DeepCopy(),
+			Template:             *d.Spec.Template.DeepCopy(),
+			RevisionHistoryLimit: d.Spec.RevisionHistoryLimit,
+			MinReadySeconds:      d.Spec.MinReadySeconds,
+			UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{
+				Paused: d.Spec.Paused,
+			},
+		},
+	}
+
+	// a Deployment defaults to 25% for both, a CloneSet does not
+	maxUnavailable := intstr.FromString("25%")
+	maxSurge := intstr.FromString("25%")
+	switch {
+	case d.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType:
+		maxUnavailable = intstr.FromString("100%")
+		maxSurge = intstr.FromInt(0)
+	case d.Spec.Strategy.RollingUpdate != nil:
+		if d.Spec.Strategy.RollingUpdate.MaxUnavailable != nil {
+			maxUnavailable = *d.Spec.Strategy.RollingUpdate.MaxUnavailable
+		}
+		if d.Spec.Strategy.RollingUpdate.MaxSurge != nil {
+			maxSurge = *d.Spec.Strategy.RollingUpdate.MaxSurge
+		}
+	}
+	cs.Spec.UpdateStrategy.MaxUnavailable = &maxUnavailable
+	cs.Spec.UpdateStrategy.MaxSurge = &maxSurge
+	return cs
+}
+
+// resumeCloneSet reads into cs the CloneSet left by an interrupted migration of the Deployment d,
+// and returns the replicas d had when the migration started. The CloneSet must have the selector
+// and pod template of d.
+func (o *MigrateOptions) resumeCloneSet(d *appsv1.Deployment, cs *kruiseappsv1alpha1.CloneSet) (int32, error) {
+	key := types.NamespacedName{Namespace: cs.Namespace, Name: cs.Name}
+	if err := o.Reader.Get(context.TODO(), key, cs); err != nil {
+		return 0, err
+	}
+	total, err := strconv.Atoi(cs.Annotations[migrationReplicasAnnotation])
+	if err != nil || !apiequality.Semantic.DeepEqual(cs.Spec.Selector, d.Spec.Selector) ||
+		!apiequality.Semantic.DeepEqual(cs.Spec.Template, d.Spec.Template) {
+		return 0, fmt.Errorf("cloneset %q already exists and is not a migration of deployment %q", cs.Name, d.Name)
+	}
+	if cs.Spec.Replicas != nil && *cs.Spec.Replicas > int32(total) {
+		return 0, fmt.Errorf("cloneset %q has %d replicas, more than the %d of deployment %q", cs.Name, *cs.Spec.Replicas, total, d.Name)
+	}
+	fmt.Fprintf(o.Out, "Resuming migration of deployment %q to existing cloneset %q\n", d.Name, cs.Name)
+	return int32(total), nil
+}
+
+// shiftDeploymentReplicas moves total replicas of the Deployment d to the CloneSet cs --step at a
+// time, starting from the replicas cs already has. An interrupted step is finished first.
+func (o *MigrateOptions) shiftDeploymentReplicas(d *appsv1.Deployment, cs *kruiseappsv1alpha1.CloneSet, total int32) error {
+	moved := int32(0)
+	if cs.Spec.Replicas != nil {
+		moved = *cs.Spec.Replicas
+	}
+	if moved > 0 {
+		if err := o.shiftStep(d, cs, moved, total); err != nil {
+			return err
+		}
+	}
+	for moved < total {
+		moved += o.Step
+		if moved > total {
+			moved = total
+		}
+		if err := o.shiftStep(d, cs, moved, total); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shiftStep scales the CloneSet cs up to moved replicas and waits for its pods to be available,
+// then scales the Deployment d down to the rest of total.
+func (o *MigrateOptions) shiftStep(d *appsv1.Deployment, cs *kruiseappsv1alpha1.CloneSet, moved, total int32) error {
+	if err := o.scale(cs, moved); err != nil {
+		return fmt.Errorf("failed to scale cloneset %q: %v", cs.Name, err)
+	}
+	err := o.waitFor(cs, func() bool {
+		return cs.Status.ObservedGeneration >= cs.Generation && cs.Status.AvailableReplicas >= moved
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for %d pods of cloneset %q to be available, %d are", moved, cs.Name, cs.Status.AvailableReplicas)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := o.scale(d, total-moved); err != nil {
+		return fmt.Errorf("failed to scale deployment %q: %v", d.Name, err)
+	}
+	err = o.waitFor(d, func() bool {
+		return d.Status.ObservedGeneration >= d.Generation && d.Status.Replicas <= total-moved
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for deployment %q to be scaled to %d replicas, %d are left", d.Name, total-moved, d.Status.Replicas)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(o.Out, "Moved %d of %d replicas from deployment %q to cloneset %q\n", moved, total, d.Name, cs.Name)
+	return nil
+}
+
+// object is a typed Kubernetes object, like a Deployment or a CloneSet.
+type object interface {
+	runtime.Object
+	metav1.Object
+}
+
+// scale sets the replicas of a workload, updating obj with the result.
+func (o *MigrateOptions) scale(obj object, replicas int32) error {
+	patch := fmt.Sprintf(`{"spec":{"replicas":%d}}`, replicas)
+	return o.Client.Patch(context.TODO(), obj, client.RawPatch(types.MergePatchType, []byte(patch)))
+}
+
+// waitFor reads obj until done returns true for it, or --timeout passed.
+func (o *MigrateOptions) waitFor(obj object, done func() bool) error {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	return wait.PollImmediate(pollInterval, o.Timeout, func() (bool, error) {
+		if err := o.Reader.Get(context.TODO(), key, obj); err != nil {
+			return false, err
+		}
+		return done(), nil
+	})
+}
+
+// withoutAnnotations returns a copy of annotations without the given keys.
+func withoutAnnotations(annotations map[string]string, keys []string) map[string]string {
+	if len(annotations) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(annotations))
+	for k, v := range annotations {
+		result[k] = v
+	}
+	for _, k := range keys {
+		delete(result, k)
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// migrateStatefulSet replaces the StatefulSet sts by an Advanced StatefulSet that adopts its pods and PVCs.
+func (o *MigrateOptions) migrateStatefulSet(sts *appsv1.StatefulSet) error {
+	asts := advancedStatefulSetFromStatefulSet(sts)
+	if o.Local || o.DryRunStrategy == cmdutil.DryRunClient {
+		return o.PrintObj(asts)
+	}
+	if o.DryRunStrategy == cmdutil.DryRunServer {
+		if err := o.Client.Create(context.TODO(), asts, client.DryRunAll); err != nil {
+			return fmt.Errorf("failed to create advanced statefulset: %v", err)
+		}
+		return o.PrintObj(asts)
+	}
+
+	// make sure the Advanced StatefulSet can be created before the pods lose their controller
+	key := types.NamespacedName{Namespace: asts.Namespace, Name: asts.Name}
+	err := o.Reader.Get(context.TODO(), key, &kruiseappsv1beta1.StatefulSet{})
+	if err == nil {
+		return fmt.Errorf("advanced statefulset %q already exists", asts.Name)
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := o.Client.Create(context.TODO(), asts.DeepCopy(), client.DryRunAll); err != nil {
+		return fmt.Errorf("failed to create advanced statefulset: %v", err)
+	}
+
+	// the pods and PVCs can only be adopted once they have no controller anymore
+	if err := o.Client.Delete(context.TODO(), sts, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil {
+		return fmt.Errorf("failed to delete statefulset %q: %v", sts.Name, err)
+	}
+	err = wait.PollImmediate(pollInterval, o.Timeout, func() (bool, error) {
+		err := o.Reader.Get(context.TODO(), types.NamespacedName{Namespace: sts.Namespace, Name: sts.Name}, &appsv1.StatefulSet{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for statefulset %q to be deleted", sts.Name)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "statefulset %q deleted, its pods were orphaned\n", sts.Name)
+
+	if err := o.Client.Create(context.TODO(), asts); err != nil {
+		// give the pods their controller back
+		restored := sts.DeepCopy()
+		restored.ResourceVersion = ""
+		restored.UID = ""
+		if restoreErr := o.Client.Create(context.TODO(), restored); restoreErr != nil {
+			return fmt.Errorf("failed to create advanced statefulset: %v, and to recreate statefulset %q, its pods are left without a controller: %v", err, sts.Name, restoreErr)
+		}
+		return fmt.Errorf("failed to create advanced statefulset, statefulset %q was recreated: %v", sts.Name, err)
+	}
+	if err := o.PrintObj(asts); err != nil {
+		return err
+	}
+
+	pods, err := o.waitForAdoption(asts)
+	if err != nil {
+		return err
+	}
+	if err := o.verifyClaimsBound(asts, pods); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "advanced statefulset %q adopted %d pods, their %d PVCs are bound\n",
+		asts.Name, len(pods), len(pods)*len(asts.Spec.VolumeClaimTemplates))
+	return nil
+}
+
+// advancedStatefulSetFromStatefulSet returns an Advanced StatefulSet equivalent to the StatefulSet sts,
+// with the same name, selector, serviceName, volumeClaimTemplates and replicas.
+func advancedStatefulSetFromStatefulSet(sts *appsv1.StatefulSet) *kruiseappsv1beta1.StatefulSet {
+	asts := &kruiseappsv1beta1.StatefulSet{
+		// this is ok because we know exactly how we want to be serialized
+		TypeMeta: metav1.TypeMeta{APIVersion: kruiseappsv1beta1.SchemeGroupVersion.String(), Kind: "StatefulSet"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        sts.Name,
+			Namespace:   sts.Namespace,
+			Labels:      sts.Labels,
+			Annotations: withoutAnnotations(sts.Annotations, []string{"kubectl.kubernetes.io/last-applied-configuration"}),
+		},
+		Spec: kruiseappsv1beta1.StatefulSetSpec{
+			Replicas:             sts.Spec.Replicas,
+			Selector:             sts.Spec.Selector.DeepCopy(),
+			Template:             *sts.Spec.Template.DeepCopy(),
+			ServiceName:          sts.Spec.ServiceName,
+			PodManagementPolicy:  sts.Spec.PodManagementPolicy,
+			RevisionHistoryLimit: sts.Spec.RevisionHistoryLimit,
+			UpdateStrategy: kruiseappsv1beta1.StatefulSetUpdateStrategy{
+				Type: sts.Spec.UpdateStrategy.Type,
+			},
+		},
+	}
+	for _, claim := range sts.Spec.VolumeClaimTemplates {
+		asts.Spec.VolumeClaimTemplates = append(asts.Spec.VolumeClaimTemplates, corev1.PersistentVolumeClaim{
+			ObjectMeta: *claim.ObjectMeta.DeepCopy(),
+			Spec:       *claim.Spec.DeepCopy(),
+		})
+	}
+	if rollingUpdate := sts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		partition := *rollingUpdate.Partition
+		asts.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{Partition: &partition}
+	}
+	return asts
+}
+
+// waitForAdoption waits until all pods selected by the Advanced StatefulSet asts are controlled by
+// it, and returns them.
+func (o *MigrateOptions) waitForAdoption(asts *kruiseappsv1beta1.StatefulSet) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(asts.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	pods := &corev1.PodList{}
+	var orphans []string
+	err = wait.PollImmediate(pollInterval, o.Timeout, func() (bool, error) {
+		if err := o.Reader.List(context.TODO(), pods, client.InNamespace(asts.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return false, err
+		}
+		orphans = nil
+		for i := range pods.Items {
+			if owner := metav1.GetControllerOf(&pods.Items[i]); owner == nil || owner.UID != asts.UID {
+				orphans = append(orphans, pods.Items[i].Name)
+			}
+		}
+		return len(orphans) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		sort.Strings(orphans)
+		return nil, fmt.Errorf("timed out waiting for advanced statefulset %q to adopt pods %s", asts.Name, strings.Join(orphans, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+// verifyClaimsBound makes sure the PVCs of the volumeClaimTemplates of asts are bound for every pod.
+func (o *MigrateOptions) verifyClaimsBound(asts *kruiseappsv1beta1.StatefulSet, pods []corev1.Pod) error {
+	var unbound []string
+	for _, pod := range pods {
+		for _, template := range asts.Spec.VolumeClaimTemplates {
+			name := template.Name + "-" + pod.Name
+			claim := &corev1.PersistentVolumeClaim{}
+			if err := o.Reader.Get(context.TODO(), types.NamespacedName{Namespace: asts.Namespace, Name: name}, claim); err != nil {
+				if !apierrors.IsNotFound(err) {
+					return err
+				}
+				unbound = append(unbound, name+" (not found)")
+				continue
+			}
+			switch claim.Status.Phase {
+			case corev1.ClaimBound:
+			case "":
+				unbound = append(unbound, name+" (Pending)")
+			default:
+				unbound = append(unbound, fmt.Sprintf("%s (%s)", name, claim.Status.Phase))
+			}
+		}
+	}
+	if len(unbound) > 0 {
+		sort.Strings(unbound)
+		return fmt.Errorf("PVCs of advanced statefulset %q are not bound: %s", asts.Name, strings.Join(unbound, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,518 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package migrate
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// scalingClient completes every scaling at once, like the controllers of the workloads would.
+type scalingClient struct {
+	client.Client
+}
+
+func (c scalingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.Client.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	switch t := obj.(type) {
+	case *kruiseappsv1alpha1.CloneSet:
+		t.Status.Replicas = *t.Spec.Replicas
+		t.Status.AvailableReplicas = *t.Spec.Replicas
+	case *appsv1.Deployment:
+		t.Status.Replicas = *t.Spec.Replicas
+	}
+	return c.Client.Update(ctx, obj)
+}
+
+// stallingClient scales like scalingClient, but never makes more than available pods of a CloneSet
+// available.
+type stallingClient struct {
+	scalingClient
+	available int32
+}
+
+func (c stallingClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	if err := c.scalingClient.Patch(ctx, obj, patch, opts...); err != nil {
+		return err
+	}
+	if cs, ok := obj.(*kruiseappsv1alpha1.CloneSet); ok && cs.Status.AvailableReplicas > c.available {
+		cs.Status.AvailableReplicas = c.available
+		return c.Client.Update(ctx, cs)
+	}
+	return nil
+}
+
+func newDeployment(replicas int32) *appsv1.Deployment {
+	maxUnavailable := intstr.FromInt(1)
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "web"},
+			Annotations: map[string]string{
+				"deployment.kubernetes.io/revision": "3",
+				"team":                              "shop",
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx"}}},
+			},
+			Strategy: appsv1.DeploymentStrategy{
+				Type:          appsv1.RollingUpdateDeploymentStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateDeployment{MaxUnavailable: &maxUnavailable},
+			},
+			MinReadySeconds: 10,
+		},
+	}
+}
+
+func TestCloneSetFromDeployment(t *testing.T) {
+	recreate := newDeployment(3)
+	recreate.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	defaulted := newDeployment(3)
+	defaulted.Spec.Strategy = appsv1.DeploymentStrategy{}
+
+	testCases := []struct {
+		name                  string
+		deployment            *appsv1.Deployment
+		maxUnavailable, surge intstr.IntOrString
+	}{
+		{name: "rolling update", deployment: newDeployment(3), maxUnavailable: intstr.FromInt(1), surge: intstr.FromString("25%")},
+		{name: "recreate", deployment: recreate, maxUnavailable: intstr.FromString("100%"), surge: intstr.FromInt(0)},
+		{name: "defaulted", deployment: defaulted, maxUnavailable: intstr.FromString("25%"), surge: intstr.FromString("25%")},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			d := testCase.deployment
+			cs := cloneSetFromDeployment(d)
+			if cs.Name != d.Name || cs.Namespace != d.Namespace || !reflect.DeepEqual(cs.Labels, d.Labels) {
+				t.Errorf("unexpected object meta: %#v", cs.ObjectMeta)
+			}
+			if !reflect.DeepEqual(cs.Annotations, map[string]string{"team": "shop"}) {
+				t.Errorf("unexpected annotations: %v", cs.Annotations)
+			}
+			if *cs.Spec.Replicas != 3 || cs.Spec.MinReadySeconds != 10 {
+				t.Errorf("unexpected spec: %#v", cs.Spec)
+			}
+			if !reflect.DeepEqual(cs.Spec.Selector, d.Spec.Selector) || !reflect.DeepEqual(cs.Spec.Template, d.Spec.Template) {
+				t.Errorf("expected selector and template of the deployment, got %v and %v", cs.Spec.Selector, cs.Spec.Template)
+			}
+			strategy := cs.Spec.UpdateStrategy
+			if *strategy.MaxUnavailable != testCase.maxUnavailable || *strategy.MaxSurge != testCase.surge {
+				t.Errorf("expected maxUnavailable %s and maxSurge %s, got %s and %s",
+					testCase.maxUnavailable.String(), testCase.surge.String(), strategy.MaxUnavailable.String(), strategy.MaxSurge.String())
+			}
+		})
+	}
+}
+
+func TestMigrateDeployment(t *testing.T) {
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	d := newDeployment(5)
+	c := scalingClient{fake.NewFakeClientWithScheme(internalclient.Scheme, d.DeepCopy())}
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	o := &MigrateOptions{
+		To:           toCloneSet,
+		Step:         2,
+		DeleteSource: true,
+		Timeout:      time.Second,
+		Client:       c,
+		Reader:       c,
+		PrintObj:     func(obj runtime.Object) error { return nil },
+		IOStreams:    streams,
+	}
+
+	if err := o.migrateDeployment(d); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cs := &kruiseappsv1alpha1.CloneSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, cs); err != nil {
+		t.Fatalf("failed to get cloneset: %v", err)
+	}
+	if *cs.Spec.Replicas != 5 {
+		t.Errorf("expected 5 replicas of the cloneset, got %d", *cs.Spec.Replicas)
+	}
+	if _, ok := cs.Annotations[migrationReplicasAnnotation]; ok {
+		t.Errorf("expected annotation %s to be removed once migrated, got %v", migrationReplicasAnnotation, cs.Annotations)
+	}
+	err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, &appsv1.Deployment{})
+	if !apierrors.IsNotFound(err) {
+		t.Errorf("expected the deployment to be deleted, got %v", err)
+	}
+
+	expected := []string{
+		`Moved 2 of 5 replicas from deployment "web" to cloneset "web"`,
+		`Moved 4 of 5 replicas from deployment "web" to cloneset "web"`,
+		`Moved 5 of 5 replicas from deployment "web" to cloneset "web"`,
+		`deployment "web" deleted`,
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected output %q, got %q", expected, lines)
+	}
+}
+
+func TestMigrateDeploymentResume(t *testing.T) {
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	d := newDeployment(5)
+	c := scalingClient{fake.NewFakeClientWithScheme(internalclient.Scheme, d.DeepCopy())}
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	o := &MigrateOptions{
+		To:        toCloneSet,
+		Step:      2,
+		Timeout:   10 * time.Millisecond,
+		Client:    stallingClient{scalingClient: c, available: 2},
+		Reader:    c,
+		PrintObj:  func(obj runtime.Object) error { return nil },
+		IOStreams: streams,
+	}
+
+	// the second step times out, with 4 replicas of the cloneset and 3 of the deployment
+	err := o.migrateDeployment(d.DeepCopy())
+	if expected := `timed out waiting for 4 pods of cloneset "web" to be available, 2 are`; err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, got %v", expected, err)
+	}
+
+	o.Client = c
+	o.Timeout = time.Second
+	current := &appsv1.Deployment{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, current); err != nil {
+		t.Fatalf("failed to get deployment: %v", err)
+	}
+	out.Reset()
+	if err := o.migrateDeployment(current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cs := &kruiseappsv1alpha1.CloneSet{}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, cs); err != nil {
+		t.Fatalf("failed to get cloneset: %v", err)
+	}
+	if *cs.Spec.Replicas != 5 {
+		t.Errorf("expected 5 replicas of the cloneset, got %d", *cs.Spec.Replicas)
+	}
+	if err := c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "web"}, current); err != nil || *current.Spec.Replicas != 0 {
+		t.Errorf("expected the deployment to be scaled to 0 replicas, got %v", err)
+	}
+	expected := []string{
+		`Resuming migration of deployment "web" to existing cloneset "web"`,
+		`Moved 4 of 5 replicas from deployment "web" to cloneset "web"`,
+		`Moved 5 of 5 replicas from deployment "web" to cloneset "web"`,
+		`deployment "web" was scaled to 0 replicas, delete it with --delete-source once the migration is verified`,
+	}
+	if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("expected output %q, got %q", expected, lines)
+	}
+
+	// a cloneset that was not created for the deployment is left alone
+	other := newDeployment(5)
+	other.Spec.Template.Spec.Containers[0].Image = "httpd"
+	err = o.migrateDeployment(other)
+	if expected := `cloneset "web" already exists and is not a migration of deployment "web"`; err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
+
+func TestMigrateDeploymentDryRun(t *testing.T) {
+	var printed []runtime.Object
+	o := &MigrateOptions{
+		To:             toCloneSet,
+		DryRunStrategy: cmdutil.DryRunClient,
+		PrintObj: func(obj runtime.Object) error {
+			printed = append(printed, obj)
+			return nil
+		},
+	}
+	if err := o.migrateDeployment(newDeployment(5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(printed) != 1 {
+		t.Fatalf("expected the cloneset to be printed, got %v", printed)
+	}
+	if cs, ok := printed[0].(*kruiseappsv1alpha1.CloneSet); !ok || *cs.Spec.Replicas != 0 {
+		t.Errorf("expected a cloneset with 0 replicas, got %#v", printed[0])
+	}
+
+	printed = nil
+	o.Local = true
+	if err := o.migrateDeployment(newDeployment(5)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cs, ok := printed[0].(*kruiseappsv1alpha1.CloneSet); !ok || *cs.Spec.Replicas != 5 {
+		t.Errorf("expected a cloneset with the replicas of the deployment, got %#v", printed[0])
+	}
+}
+
+// adoptingClient makes an Advanced StatefulSet adopt the orphaned pods it selects once it is created,
+// like its controller would.
+type adoptingClient struct {
+	client.Client
+}
+
+func (c adoptingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	asts, ok := obj.(*kruiseappsv1beta1.StatefulSet)
+	if !ok {
+		return c.Client.Create(ctx, obj, opts...)
+	}
+	asts.UID = "asts-uid"
+	if err := c.Client.Create(ctx, asts, opts...); err != nil {
+		return err
+	}
+	pods := &corev1.PodList{}
+	if err := c.List(ctx, pods, client.InNamespace(asts.Namespace), client.MatchingLabels(asts.Spec.Selector.MatchLabels)); err != nil {
+		return err
+	}
+	controller := true
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if metav1.GetControllerOf(pod) != nil {
+			continue
+		}
+		pod.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion: kruiseappsv1beta1.SchemeGroupVersion.String(),
+			Kind:       "StatefulSet",
+			Name:       asts.Name,
+			UID:        asts.UID,
+			Controller: &controller,
+		}}
+		if err := c.Update(ctx, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newStatefulSet() *appsv1.StatefulSet {
+	replicas := int32(2)
+	partition := int32(1)
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:    &replicas,
+			Selector:    &metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+			ServiceName: "db-headless",
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "mysql", Image: "mysql"}}},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimPending},
+			}},
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type:          appsv1.RollingUpdateStatefulSetStrategyType,
+				RollingUpdate: &appsv1.RollingUpdateStatefulSetStrategy{Partition: &partition},
+			},
+		},
+	}
+}
+
+func TestAdvancedStatefulSetFromStatefulSet(t *testing.T) {
+	sts := newStatefulSet()
+	asts := advancedStatefulSetFromStatefulSet(sts)
+
+	if asts.APIVersion != "apps.kruise.io/v1beta1" || asts.Name != "db" || asts.Namespace != "default" {
+		t.Errorf("unexpected object: %#v", asts.ObjectMeta)
+	}
+	if *asts.Spec.Replicas != 2 || asts.Spec.ServiceName != "db-headless" {
+		t.Errorf("unexpected spec: %#v", asts.Spec)
+	}
+	if !reflect.DeepEqual(asts.Spec.Selector, sts.Spec.Selector) || !reflect.DeepEqual(asts.Spec.Template, sts.Spec.Template) {
+		t.Errorf("expected selector and template of the statefulset, got %v and %v", asts.Spec.Selector, asts.Spec.Template)
+	}
+	if len(asts.Spec.VolumeClaimTemplates) != 1 || asts.Spec.VolumeClaimTemplates[0].Name != "data" || asts.Spec.VolumeClaimTemplates[0].Status.Phase != "" {
+		t.Errorf("unexpected volume claim templates: %v", asts.Spec.VolumeClaimTemplates)
+	}
+	strategy := asts.Spec.UpdateStrategy
+	if strategy.Type != appsv1.RollingUpdateStatefulSetStrategyType || strategy.RollingUpdate == nil || *strategy.RollingUpdate.Partition != 1 {
+		t.Errorf("unexpected update strategy: %#v", strategy)
+	}
+}
+
+func TestMigrateStatefulSet(t *testing.T) {
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	orphan := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"app": "db"}}}
+	}
+	claim := func(name string, phase corev1.PersistentVolumeClaimPhase) *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status:     corev1.PersistentVolumeClaimStatus{Phase: phase},
+		}
+	}
+
+	testCases := []struct {
+		name      string
+		objects   []runtime.Object
+		expectErr string
+	}{
+		{
+			name: "adopted and bound",
+			objects: []runtime.Object{
+				orphan("db-0"), orphan("db-1"),
+				claim("data-db-0", corev1.ClaimBound), claim("data-db-1", corev1.ClaimBound),
+			},
+		},
+		{
+			name: "unbound claims",
+			objects: []runtime.Object{
+				orphan("db-0"), orphan("db-1"),
+				claim("data-db-0", corev1.ClaimLost),
+			},
+			expectErr: `PVCs of advanced statefulset "db" are not bound: data-db-0 (Lost), data-db-1 (not found)`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sts := newStatefulSet()
+			c := adoptingClient{fake.NewFakeClientWithScheme(internalclient.Scheme, append(testCase.objects, sts.DeepCopy())...)}
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			o := &MigrateOptions{
+				To:        toAdvancedStatefulSet,
+				Timeout:   time.Second,
+				Client:    c,
+				Reader:    c,
+				PrintObj:  func(obj runtime.Object) error { return nil },
+				IOStreams: streams,
+			}
+
+			err := o.migrateStatefulSet(sts)
+			if len(testCase.expectErr) > 0 {
+				if err == nil || err.Error() != testCase.expectErr {
+					t.Errorf("expected error %q, got %v", testCase.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			key := types.NamespacedName{Namespace: "default", Name: "db"}
+			if err := c.Get(context.TODO(), key, &appsv1.StatefulSet{}); !apierrors.IsNotFound(err) {
+				t.Errorf("expected the statefulset to be deleted, got %v", err)
+			}
+			if err := c.Get(context.TODO(), key, &kruiseappsv1beta1.StatefulSet{}); err != nil {
+				t.Errorf("expected the advanced statefulset to be created, got %v", err)
+			}
+			expected := `advanced statefulset "db" adopted 2 pods, their 2 PVCs are bound`
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("expected output to contain %q, got %q", expected, out.String())
+			}
+		})
+	}
+}
+
+// rejectingClient fails to create Advanced StatefulSets, except in a dry run, like a webhook
+// rejecting them only once admission completes would.
+type rejectingClient struct {
+	client.Client
+}
+
+func (c rejectingClient) Create(ctx context.Context, obj runtime.Object, opts ...client.CreateOption) error {
+	createOptions := &client.CreateOptions{}
+	createOptions.ApplyOptions(opts)
+	if _, ok := obj.(*kruiseappsv1beta1.StatefulSet); ok && len(createOptions.DryRun) == 0 {
+		return apierrors.NewBadRequest("rejected")
+	}
+	return c.Client.Create(ctx, obj, opts...)
+}
+
+func TestMigrateStatefulSetFailure(t *testing.T) {
+	pollInterval = time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	key := types.NamespacedName{Namespace: "default", Name: "db"}
+	existing := &kruiseappsv1beta1.StatefulSet{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}}
+	testCases := []struct {
+		name      string
+		objects   []runtime.Object
+		client    func(client.Client) client.Client
+		expectErr string
+	}{
+		{
+			name:      "advanced statefulset exists",
+			objects:   []runtime.Object{existing},
+			client:    func(c client.Client) client.Client { return c },
+			expectErr: `advanced statefulset "db" already exists`,
+		},
+		{
+			name:      "create rejected",
+			client:    func(c client.Client) client.Client { return rejectingClient{c} },
+			expectErr: `failed to create advanced statefulset, statefulset "db" was recreated: rejected`,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			sts := newStatefulSet()
+			c := fake.NewFakeClientWithScheme(internalclient.Scheme, append(testCase.objects, sts.DeepCopy())...)
+			if err := c.Get(context.TODO(), key, sts); err != nil {
+				t.Fatalf("failed to get statefulset: %v", err)
+			}
+			o := &MigrateOptions{
+				To:        toAdvancedStatefulSet,
+				Timeout:   time.Second,
+				Client:    testCase.client(c),
+				Reader:    c,
+				PrintObj:  func(obj runtime.Object) error { return nil },
+				IOStreams: genericclioptions.NewTestIOStreamsDiscard(),
+			}
+
+			err := o.migrateStatefulSet(sts)
+			if err == nil || err.Error() != testCase.expectErr {
+				t.Errorf("expected error %q, got %v", testCase.expectErr, err)
+			}
+			current := &appsv1.StatefulSet{}
+			if err := c.Get(context.TODO(), key, current); err != nil {
+				t.Fatalf("expected the statefulset to exist, got %v", err)
+			}
+			if !reflect.DeepEqual(current.Spec, sts.Spec) {
+				t.Errorf("expected the spec of the statefulset to be kept, got %#v", current.Spec)
+			}
+		})
+	}
+}
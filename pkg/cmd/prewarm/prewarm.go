@@ -0,0 +1,339 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kset "github.com/hantmac/kubectl-kruise/pkg/cmd/set"
+	"github.com/hantmac/kubectl-kruise/pkg/fetcher"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// ImagePullJobs created by prewarm are deleted this long after they finished
+const prewarmTTLSecondsAfterFinished int32 = 600
+
+var (
+	// pollInterval is the interval in which the progress of an ImagePullJob is checked
+	pollInterval = 2 * time.Second
+
+	prewarmLong = templates.LongDesc(i18n.T(`
+		Pre-warm an image on the nodes of a workload before rolling it out.
+
+		An ImagePullJob is created to pull the image on every node the pods of the
+		CloneSet are currently running on, and its progress is watched until the image
+		is pulled on all of them. With --then-set-image the image of the given container
+		is updated once the pull completed, so that the rollout starts with the image
+		already in place.`))
+
+	prewarmExample = templates.Examples(i18n.T(`
+		# Pull the image repo/app:v2 on the nodes running the pods of the cloneset abc
+		kubectl-kruise prewarm cloneset/abc --image=repo/app:v2
+
+		# Pull the image on at most 2 nodes at a time, then update the image of the container app
+		kubectl-kruise prewarm cloneset/abc --image=repo/app:v2 --parallelism=2 --then-set-image=app`))
+)
+
+// PrewarmOptions is the start of the data required to perform the operation
+type PrewarmOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+
+	PrintObj func(obj runtime.Object) error
+	Output   string
+
+	Resources    []string
+	Image        string
+	ThenSetImage string
+	Parallelism  string
+	Timeout      time.Duration
+
+	Namespace      string
+	Builder        func() *resource.Builder
+	Client         client.Client
+	Reader         client.Reader
+	DryRunStrategy cmdutil.DryRunStrategy
+	// SetImage updates the image of a container of a workload, args are passed as to 'set image'
+	SetImage func(args []string) error
+
+	genericclioptions.IOStreams
+}
+
+// NewPrewarmOptions initializes and returns new PrewarmOptions instance
+func NewPrewarmOptions(streams genericclioptions.IOStreams) *PrewarmOptions {
+	return &PrewarmOptions{
+		PrintFlags: genericclioptions.NewPrintFlags("created").WithTypeSetter(internalclient.Scheme),
+		Timeout:    10 * time.Minute,
+		IOStreams:  streams,
+	}
+}
+
+// NewCmdPrewarm returns a Command instance for 'prewarm' sub command
+func NewCmdPrewarm(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewPrewarmOptions(streams)
+
+	validArgs := []string{"cloneset"}
+
+	cmd := &cobra.Command{
+		Use:                   "prewarm (TYPE NAME | TYPE/NAME) --image=IMAGE [--then-set-image=CONTAINER]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Pull an image on the nodes of a workload before rolling it out"),
+		Long:                  prewarmLong,
+		Example:               prewarmExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+		ValidArgs: validArgs,
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+
+	cmdutil.AddDryRunFlag(cmd)
+	cmd.Flags().StringVar(&o.Image, "image", o.Image, "The image to pull on the nodes.")
+	cmd.Flags().StringVar(&o.ThenSetImage, "then-set-image", o.ThenSetImage, "The container whose image is updated once the image was pulled on all nodes, '*' updates all containers.")
+	cmd.Flags().StringVar(&o.Parallelism, "parallelism", o.Parallelism, "The maximum number or percentage of nodes the image is pulled on at the same time. Defaults to 1.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for the image to be pulled, zero means never. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *PrewarmOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	o.Resources = args
+
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.Builder = f.NewBuilder
+
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	mgr := internalclient.NewManager()
+	o.Reader = mgr.GetAPIReader()
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		o.Client = mgr.GetClient()
+	}
+	cmdutil.PrintFlagsWithDryRunStrategy(o.PrintFlags, o.DryRunStrategy)
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	o.PrintObj = func(obj runtime.Object) error {
+		return printer.PrintObj(obj, o.Out)
+	}
+	o.Output = cmdutil.GetFlagString(cmd, "output")
+
+	o.SetImage = func(args []string) error {
+		setImage := kset.NewImageOptions(o.IOStreams)
+		if err := setImage.Complete(f, kset.NewCmdImage(f, o.IOStreams), args); err != nil {
+			return err
+		}
+		if err := setImage.Validate(); err != nil {
+			return err
+		}
+		return setImage.Run()
+	}
+
+	return nil
+}
+
+// Validate makes sure provided values in PrewarmOptions are valid
+func (o *PrewarmOptions) Validate() error {
+	if len(o.Resources) == 0 {
+		return fmt.Errorf("required resource not specified")
+	}
+	if len(o.Image) == 0 {
+		return fmt.Errorf("--image must be specified")
+	}
+	if len(o.Parallelism) > 0 {
+		parallelism := intstr.Parse(o.Parallelism)
+		if _, err := intstr.GetValueFromIntOrPercent(&parallelism, 100, true); err != nil {
+			return fmt.Errorf("invalid --parallelism %q: %v", o.Parallelism, err)
+		}
+		if parallelism.Type == intstr.Int && parallelism.IntVal <= 0 {
+			return fmt.Errorf("--parallelism must be greater than 0")
+		}
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	return nil
+}
+
+// Run performs the execution of 'prewarm' sub command
+func (o *PrewarmOptions) Run() error {
+	infos, err := o.Builder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(false, o.Resources...).
+		SingleResourceType().
+		Flatten().
+		Latest().
+		Do().
+		Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) != 1 {
+		return fmt.Errorf("prewarm is only supported on a single resource")
+	}
+	info := infos[0]
+	cs, ok := info.Object.(*kruiseappsv1alpha1.CloneSet)
+	if !ok {
+		return fmt.Errorf("prewarm is not supported on %s %q", info.Mapping.GroupVersionKind.Kind, info.Name)
+	}
+
+	nodes, err := fetcher.GetNodeNamesOfCloneSetPods(cs.Namespace, cs.Name, o.Reader)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return fmt.Errorf("no pods of cloneset %q are scheduled to a node", cs.Name)
+	}
+
+	job := o.imagePullJob(cs, nodes)
+	if o.DryRunStrategy != cmdutil.DryRunClient {
+		var opts []client.CreateOption
+		if o.DryRunStrategy == cmdutil.DryRunServer {
+			opts = append(opts, client.DryRunAll)
+		}
+		if err := o.Client.Create(context.TODO(), job, opts...); err != nil {
+			return fmt.Errorf("failed to create imagepulljob: %v", err)
+		}
+	}
+	if err := o.PrintObj(job); err != nil {
+		return err
+	}
+	if o.DryRunStrategy != cmdutil.DryRunNone {
+		return nil
+	}
+
+	if err := o.waitForImagePullJob(job); err != nil {
+		return err
+	}
+
+	if len(o.ThenSetImage) == 0 {
+		return nil
+	}
+	return o.SetImage([]string{"cloneset/" + cs.Name, fmt.Sprintf("%s=%s", o.ThenSetImage, o.Image)})
+}
+
+// imagePullJob returns an ImagePullJob pulling the image on the given nodes, with the pull
+// secrets of the pod template of the CloneSet
+func (o *PrewarmOptions) imagePullJob(cs *kruiseappsv1alpha1.CloneSet, nodes []string) *kruiseappsv1alpha1.ImagePullJob {
+	ttl := prewarmTTLSecondsAfterFinished
+	job := &kruiseappsv1alpha1.ImagePullJob{
+		// this is ok because we know exactly how we want to be serialized
+		TypeMeta: metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.SchemeGroupVersion.String(), Kind: "ImagePullJob"},
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: cs.Name + "-prewarm-",
+			Namespace:    cs.Namespace,
+		},
+		Spec: kruiseappsv1alpha1.ImagePullJobSpec{
+			Image: o.Image,
+			Selector: &kruiseappsv1alpha1.NodeSelector{
+				Names: nodes,
+			},
+			CompletionPolicy: kruiseappsv1alpha1.CompletionPolicy{
+				Type:                    kruiseappsv1alpha1.Always,
+				TTLSecondsAfterFinished: &ttl,
+			},
+		},
+	}
+	for _, secret := range cs.Spec.Template.Spec.ImagePullSecrets {
+		job.Spec.PullSecrets = append(job.Spec.PullSecrets, secret.Name)
+	}
+	if len(o.Parallelism) > 0 {
+		parallelism := intstr.Parse(o.Parallelism)
+		job.Spec.Parallelism = &parallelism
+	}
+	if o.Timeout > 0 {
+		deadline := int64(o.Timeout / time.Second)
+		job.Spec.CompletionPolicy.ActiveDeadlineSeconds = &deadline
+	}
+	return job
+}
+
+// waitForImagePullJob prints the progress of the ImagePullJob until it completed, and fails
+// if the image could not be pulled on all nodes
+func (o *PrewarmOptions) waitForImagePullJob(job *kruiseappsv1alpha1.ImagePullJob) error {
+	// keep the printed object parseable when an output format is requested
+	out := o.Out
+	if len(o.Output) > 0 {
+		out = o.ErrOut
+	}
+
+	key := types.NamespacedName{Namespace: job.Namespace, Name: job.Name}
+	var lastProgress string
+	condition := func() (bool, error) {
+		if err := o.Reader.Get(context.TODO(), key, job); err != nil {
+			return false, err
+		}
+		if job.Status.CompletionTime != nil {
+			return true, nil
+		}
+		progress := fmt.Sprintf("Waiting for image %q to be pulled: %d of %d nodes succeeded, %d failed...\n",
+			job.Spec.Image, job.Status.Succeeded, job.Status.Desired, job.Status.Failed)
+		if progress != lastProgress {
+			fmt.Fprint(out, progress)
+			lastProgress = progress
+		}
+		return false, nil
+	}
+
+	var err error
+	if o.Timeout > 0 {
+		err = wait.PollImmediate(pollInterval, o.Timeout, condition)
+	} else {
+		err = wait.PollImmediateInfinite(pollInterval, condition)
+	}
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for imagepulljob %q to complete", job.Name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if job.Status.Failed > 0 {
+		return fmt.Errorf("failed to pull image %q on nodes %s: %s",
+			job.Spec.Image, strings.Join(job.Status.FailedNodes, ", "), job.Status.Message)
+	}
+	fmt.Fprintf(out, "image %q pulled on %d nodes\n", job.Spec.Image, job.Status.Succeeded)
+	return nil
+}
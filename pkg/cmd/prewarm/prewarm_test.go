@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package prewarm
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	"github.com/hantmac/kubectl-kruise/pkg/fetcher"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+func TestImagePullJob(t *testing.T) {
+	cs := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry"}},
+				},
+			},
+		},
+	}
+	o := &PrewarmOptions{Image: "repo/app:v2", Parallelism: "2", Timeout: 5 * time.Minute}
+	job := o.imagePullJob(cs, []string{"node-1", "node-2"})
+
+	if job.Namespace != "bar" || job.GenerateName != "abc-prewarm-" {
+		t.Errorf("unexpected object meta: %#v", job.ObjectMeta)
+	}
+	if job.Spec.Image != "repo/app:v2" {
+		t.Errorf("expected image repo/app:v2, got %s", job.Spec.Image)
+	}
+	if job.Spec.Selector == nil || !reflect.DeepEqual(job.Spec.Selector.Names, []string{"node-1", "node-2"}) {
+		t.Errorf("unexpected selector: %#v", job.Spec.Selector)
+	}
+	if !reflect.DeepEqual(job.Spec.PullSecrets, []string{"registry"}) {
+		t.Errorf("unexpected pull secrets: %v", job.Spec.PullSecrets)
+	}
+	if job.Spec.Parallelism == nil || *job.Spec.Parallelism != intstr.FromInt(2) {
+		t.Errorf("expected parallelism 2, got %v", job.Spec.Parallelism)
+	}
+	policy := job.Spec.CompletionPolicy
+	if policy.Type != kruiseappsv1alpha1.Always || policy.ActiveDeadlineSeconds == nil || *policy.ActiveDeadlineSeconds != 300 {
+		t.Errorf("unexpected completion policy: %#v", policy)
+	}
+}
+
+func TestGetNodeNamesOfCloneSetPods(t *testing.T) {
+	labels := map[string]string{"app": "abc"}
+	cs := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+		},
+	}
+	pod := func(name, node string, labels map[string]string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name, Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+	}
+	c := fake.NewFakeClientWithScheme(internalclient.Scheme, cs,
+		pod("abc-1", "node-2", labels),
+		pod("abc-2", "node-1", labels),
+		pod("abc-3", "node-2", labels),
+		pod("abc-pending", "", labels),
+		pod("other", "node-3", map[string]string{"app": "other"}),
+	)
+
+	nodes, err := fetcher.GetNodeNamesOfCloneSetPods("bar", "abc", c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"node-1", "node-2"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("expected nodes %v, got %v", expected, nodes)
+	}
+}
+
+func TestWaitForImagePullJob(t *testing.T) {
+	completed := metav1.Now()
+	tests := []struct {
+		name         string
+		status       kruiseappsv1alpha1.ImagePullJobStatus
+		output       string
+		expectErr    bool
+		expectOut    string
+		expectErrOut string
+	}{
+		{
+			name:      "succeeded",
+			status:    kruiseappsv1alpha1.ImagePullJobStatus{CompletionTime: &completed, Desired: 2, Succeeded: 2},
+			expectOut: `image "repo/app:v2" pulled on 2 nodes`,
+		},
+		{
+			name:         "succeeded with output format",
+			status:       kruiseappsv1alpha1.ImagePullJobStatus{CompletionTime: &completed, Desired: 2, Succeeded: 2},
+			output:       "json",
+			expectErrOut: `image "repo/app:v2" pulled on 2 nodes`,
+		},
+		{
+			name:      "failed nodes",
+			status:    kruiseappsv1alpha1.ImagePullJobStatus{CompletionTime: &completed, Desired: 2, Succeeded: 1, Failed: 1, FailedNodes: []string{"node-2"}},
+			expectErr: true,
+		},
+		{
+			name:      "timed out",
+			status:    kruiseappsv1alpha1.ImagePullJobStatus{Desired: 2, Active: 2},
+			expectErr: true,
+			expectOut: `Waiting for image "repo/app:v2" to be pulled: 0 of 2 nodes succeeded, 0 failed...`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &kruiseappsv1alpha1.ImagePullJob{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc-prewarm-x"},
+				Spec:       kruiseappsv1alpha1.ImagePullJobSpec{Image: "repo/app:v2"},
+				Status:     tt.status,
+			}
+			streams, _, _, _ := genericclioptions.NewTestIOStreams()
+			o := &PrewarmOptions{
+				Output:    tt.output,
+				Timeout:   time.Millisecond,
+				Reader:    fake.NewFakeClientWithScheme(internalclient.Scheme, job.DeepCopy()),
+				IOStreams: streams,
+			}
+
+			err := o.waitForImagePullJob(job)
+			if tt.expectErr != (err != nil) {
+				t.Errorf("expected error %t, got %v", tt.expectErr, err)
+			}
+			if out := streams.Out.(*bytes.Buffer).String(); !strings.Contains(out, tt.expectOut) {
+				t.Errorf("expected output to contain %q, got %q", tt.expectOut, out)
+			}
+			if errOut := streams.ErrOut.(*bytes.Buffer).String(); !strings.Contains(errOut, tt.expectErrOut) {
+				t.Errorf("expected error output to contain %q, got %q", tt.expectErrOut, errOut)
+			}
+			if out := streams.Out.(*bytes.Buffer).String(); len(tt.output) > 0 && len(out) > 0 {
+				t.Errorf("expected no output besides the printed object, got %q", out)
+			}
+		})
+	}
+}
+
+func TestPrewarmValidate(t *testing.T) {
+	tests := []struct {
+		name        string
+		resources   []string
+		image       string
+		parallelism string
+		expectErr   bool
+	}{
+		{name: "valid", resources: []string{"cloneset/abc"}, image: "repo/app:v2"},
+		{name: "no resource", image: "repo/app:v2", expectErr: true},
+		{name: "no image", resources: []string{"cloneset/abc"}, expectErr: true},
+		{name: "invalid parallelism", resources: []string{"cloneset/abc"}, image: "repo/app:v2", parallelism: "abc", expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &PrewarmOptions{Resources: tt.resources, Image: tt.image, Parallelism: tt.parallelism}
+			err := o.Validate()
+			if tt.expectErr != (err != nil) {
+				t.Errorf("expected error %t, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
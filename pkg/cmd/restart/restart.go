@@ -0,0 +1,51 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	restartLong = templates.LongDesc(`
+		Restart parts of running pods in place
+
+		These commands restart containers without deleting their pods, so the pods
+		keep their node, IP and volumes. Use "kubectl-kruise rollout restart" to
+		restart all pods of a workload.`)
+)
+
+// NewCmdRestart returns an initialized Command instance for 'restart' sub command
+func NewCmdRestart(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "restart SUBCOMMAND",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Restart containers of pods in place"),
+		Long:                  restartLong,
+		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+
+	// add subcommands
+	cmd.AddCommand(NewCmdRestartContainer(f, streams))
+
+	return cmd
+}
@@ -0,0 +1,338 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	"github.com/hantmac/kubectl-kruise/pkg/internal/crr"
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	watchtools "k8s.io/client-go/tools/watch"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// ContainerOptions holds the options for 'restart container' sub command
+type ContainerOptions struct {
+	Resources       []string
+	Selector        string
+	Containers      []string
+	OrderByPriority bool
+	ForceKillAfter  time.Duration
+	Timeout         time.Duration
+
+	Namespace     string
+	Builder       func() *resource.Builder
+	Clientset     kubernetes.Interface
+	DynamicClient dynamic.Interface
+
+	genericclioptions.IOStreams
+}
+
+var (
+	containerLong = templates.LongDesc(`
+		Restart containers of pods in place.
+
+		A Kruise ContainerRecreateRequest is created for every pod, which stops the
+		given containers and starts them again without deleting the pod. Pods are
+		given by name, or as a workload whose running pods are restarted, optionally
+		narrowed down by --selector. All containers of a pod are restarted if no
+		container is given.
+
+		The requests are watched until they finished, and the result of every pod is
+		printed at the end.`)
+
+	containerExample = templates.Examples(`
+		# Restart the container sidecar of the pod foo
+		kubectl-kruise restart container foo -c sidecar
+
+		# Restart the container sidecar of the pods of the cloneset abc in zone-a
+		kubectl-kruise restart container cloneset/abc -c sidecar --selector=zone=zone-a
+
+		# Restart the containers app and sidecar of the pods labeled app=abc in the order of their priorities
+		kubectl-kruise restart container -l app=abc -c app -c sidecar --order-by-priority
+
+		# Restart all containers of the pod foo, killing them if they did not stop within 30 seconds
+		kubectl-kruise restart container foo --force-kill-after=30s`)
+)
+
+// NewCmdRestartContainer returns a Command instance for 'restart container' sub command
+func NewCmdRestartContainer(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &ContainerOptions{
+		Timeout:   5 * time.Minute,
+		IOStreams: streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "container (POD | TYPE/NAME)... [-c CONTAINER] [--selector=SELECTOR]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Restart containers of pods in place"),
+		Long:                  containerLong,
+		Example:               containerExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringSliceVarP(&o.Containers, "container", "c", o.Containers, "The containers to restart, all containers of a pod are restarted if not given.")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter the pods on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&o.OrderByPriority, "order-by-priority", o.OrderByPriority, "Restart the containers one after another, in the order of their priorities.")
+	cmd.Flags().DurationVar(&o.ForceKillAfter, "force-kill-after", o.ForceKillAfter, "The length of time the containers may take to stop before they are killed, in whole seconds. Defaults to the termination grace period of the pod.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for the containers to be restarted, zero means never. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *ContainerOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	// bare names are pods, workloads are given as TYPE/NAME
+	for _, arg := range args {
+		if !strings.Contains(arg, "/") {
+			arg = "pods/" + arg
+		}
+		o.Resources = append(o.Resources, arg)
+	}
+	o.Builder = f.NewBuilder
+
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	if o.Clientset, err = f.KubernetesClientSet(); err != nil {
+		return err
+	}
+	if o.DynamicClient, err = f.DynamicClient(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Validate makes sure all the provided values for command-line options are valid
+func (o *ContainerOptions) Validate() error {
+	if len(o.Resources) == 0 && len(o.Selector) == 0 {
+		return fmt.Errorf("pods must be specified by name, workload or --selector")
+	}
+	if len(o.Selector) > 0 {
+		if _, err := labels.Parse(o.Selector); err != nil {
+			return fmt.Errorf("invalid --selector %q: %v", o.Selector, err)
+		}
+	}
+	if o.ForceKillAfter < 0 {
+		return fmt.Errorf("--force-kill-after must not be negative")
+	}
+	// the grace period of a ContainerRecreateRequest is in seconds, truncating would kill sooner than asked
+	if o.ForceKillAfter%time.Second != 0 {
+		return fmt.Errorf("--force-kill-after must be a whole number of seconds, got %v", o.ForceKillAfter)
+	}
+	if o.Timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	return nil
+}
+
+// Run performs the execution of 'restart container' sub command
+func (o *ContainerOptions) Run() error {
+	pods, err := o.pods()
+	if err != nil {
+		return err
+	}
+	if len(pods) == 0 {
+		return fmt.Errorf("no running pods found to restart containers of")
+	}
+
+	strategy := crr.Strategy{OrderedRecreate: o.OrderByPriority}
+	if o.ForceKillAfter > 0 {
+		seconds := int64(o.ForceKillAfter / time.Second)
+		strategy.TerminationGracePeriodSeconds = &seconds
+	}
+
+	// requests by namespace, and the result of every pod once known
+	requests := make(map[string][]string)
+	podOfRequest := make(map[string]*corev1.Pod)
+	results := make(map[*corev1.Pod]error)
+	for _, pod := range pods {
+		if err := hasContainers(pod, o.Containers); err != nil {
+			results[pod] = err
+			continue
+		}
+		req, err := o.DynamicClient.Resource(crr.GroupVersionResource).Namespace(pod.Namespace).
+			Create(context.TODO(), crr.NewRequest(pod, o.Containers, strategy), metav1.CreateOptions{})
+		if err != nil {
+			results[pod] = fmt.Errorf("failed to create ContainerRecreateRequest: %v", err)
+			continue
+		}
+		requests[pod.Namespace] = append(requests[pod.Namespace], req.GetName())
+		podOfRequest[pod.Namespace+"/"+req.GetName()] = pod
+	}
+	if len(podOfRequest) > 0 {
+		fmt.Fprintf(o.Out, "Waiting for the containers of %d pods to be restarted...\n", len(podOfRequest))
+	}
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), o.Timeout)
+	defer cancel()
+	for namespace, names := range requests {
+		requestResults, err := crr.WaitForResults(ctx, o.DynamicClient, namespace, names, 2*time.Second)
+		if err != nil {
+			return err
+		}
+		for name, result := range requestResults {
+			results[podOfRequest[namespace+"/"+name]] = result
+		}
+	}
+
+	return o.printResults(pods, results)
+}
+
+// pods returns the pods to restart the containers of, sorted by namespace and name.
+func (o *ContainerOptions) pods() ([]*corev1.Pod, error) {
+	builder := o.Builder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		Flatten().
+		Latest()
+	if len(o.Resources) > 0 {
+		builder = builder.ResourceTypeOrNameArgs(false, o.Resources...)
+	} else {
+		builder = builder.LabelSelectorParam(o.Selector).ResourceTypes("pods")
+	}
+	infos, err := builder.Do().Infos()
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []*corev1.Pod
+	for _, info := range infos {
+		if pod, ok := info.Object.(*corev1.Pod); ok {
+			pods = append(pods, pod)
+			continue
+		}
+		workloadPods, err := o.podsOfWorkload(info.Object)
+		if err != nil {
+			return nil, fmt.Errorf("%s %q: %v", info.Mapping.Resource.Resource, info.Name, err)
+		}
+		pods = append(pods, workloadPods...)
+	}
+	return filterPods(pods, o.Selector)
+}
+
+// podsOfWorkload returns the running pods controlled by the workload obj.
+func (o *ContainerOptions) podsOfWorkload(obj runtime.Object) ([]*corev1.Pod, error) {
+	namespace, selector, err := internalpolymorphichelpers.SelectorsForObject(obj)
+	if err != nil {
+		return nil, err
+	}
+	controlled, err := internalpolymorphichelpers.ControlledPods(o.Clientset, obj, namespace, selector)
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for _, pod := range controlled {
+		if pod.Status.Phase == corev1.PodRunning {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// filterPods returns the distinct pods matching selector, sorted by namespace and name.
+func filterPods(pods []*corev1.Pod, selector string) ([]*corev1.Pod, error) {
+	sel, err := labels.Parse(selector)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var filtered []*corev1.Pod
+	for _, pod := range pods {
+		key := pod.Namespace + "/" + pod.Name
+		if seen[key] || !sel.Matches(labels.Set(pod.Labels)) {
+			continue
+		}
+		seen[key] = true
+		filtered = append(filtered, pod)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		if filtered[i].Namespace != filtered[j].Namespace {
+			return filtered[i].Namespace < filtered[j].Namespace
+		}
+		return filtered[i].Name < filtered[j].Name
+	})
+	return filtered, nil
+}
+
+// hasContainers returns an error if any of containers is not a container of pod.
+func hasContainers(pod *corev1.Pod, containers []string) error {
+	for _, name := range containers {
+		found := false
+		for _, c := range pod.Spec.Containers {
+			if c.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("container %q not found", name)
+		}
+	}
+	return nil
+}
+
+// printResults prints the result of every pod and returns an error if the containers of any pod
+// could not be restarted.
+func (o *ContainerOptions) printResults(pods []*corev1.Pod, results map[*corev1.Pod]error) error {
+	containers := "<all>"
+	if len(o.Containers) > 0 {
+		containers = strings.Join(o.Containers, ",")
+	}
+
+	failed := 0
+	w := printers.GetNewTabWriter(o.Out)
+	fmt.Fprintln(w, "NAMESPACE\tPOD\tCONTAINERS\tRESULT")
+	for _, pod := range pods {
+		result := "Restarted"
+		if err := results[pod]; err != nil {
+			result = "Failed: " + err.Error()
+			failed++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pod.Namespace, pod.Name, containers, result)
+	}
+	w.Flush()
+
+	if failed > 0 {
+		return fmt.Errorf("failed to restart the containers of %d of %d pods", failed, len(pods))
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package restart
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestPodsOfWorkload(t *testing.T) {
+	cs := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc", UID: "cs-uid"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "abc"}},
+		},
+	}
+	controllerRef := *metav1.NewControllerRef(cs, kruiseappsv1alpha1.SchemeGroupVersion.WithKind("CloneSet"))
+	pod := func(name string, phase corev1.PodPhase, owned bool) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name, Labels: map[string]string{"app": "abc"}},
+			Status:     corev1.PodStatus{Phase: phase},
+		}
+		if owned {
+			pod.OwnerReferences = []metav1.OwnerReference{controllerRef}
+		}
+		return pod
+	}
+	o := &ContainerOptions{
+		Clientset: fake.NewSimpleClientset(
+			pod("abc-1", corev1.PodRunning, true),
+			pod("abc-2", corev1.PodPending, true),
+			pod("orphan", corev1.PodRunning, false),
+		),
+	}
+
+	pods, err := o.podsOfWorkload(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "abc-1" {
+		t.Errorf("expected only the running pod abc-1, got %v", pods)
+	}
+
+	// pods of a Deployment are controlled by its ReplicaSets
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "web", UID: "d-uid"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "bar",
+		Name:            "web-1",
+		UID:             "rs-uid",
+		Labels:          map[string]string{"app": "web"},
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(d, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+	}}
+	rsPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "bar",
+			Name:            "web-1-x",
+			Labels:          map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	o.Clientset = fake.NewSimpleClientset(rs, rsPod)
+	pods, err = o.podsOfWorkload(d)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "web-1-x" {
+		t.Errorf("expected the pod web-1-x of the replicaset, got %v", pods)
+	}
+}
+
+func TestFilterPods(t *testing.T) {
+	pod := func(namespace, name, zone string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"zone": zone}}}
+	}
+	pods := []*corev1.Pod{
+		pod("bar", "b", "zone-a"),
+		pod("bar", "a", "zone-b"),
+		pod("bar", "c", "zone-a"),
+		pod("bar", "b", "zone-a"),
+		pod("alpha", "z", "zone-a"),
+	}
+
+	tests := []struct {
+		selector string
+		expected []string
+	}{
+		{selector: "", expected: []string{"alpha/z", "bar/a", "bar/b", "bar/c"}},
+		{selector: "zone=zone-a", expected: []string{"alpha/z", "bar/b", "bar/c"}},
+		{selector: "zone=zone-c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			filtered, err := filterPods(pods, tt.selector)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, pod := range filtered {
+				names = append(names, pod.Namespace+"/"+pod.Name)
+			}
+			if strings.Join(names, " ") != strings.Join(tt.expected, " ") {
+				t.Errorf("expected %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestValidateForceKillAfter(t *testing.T) {
+	tests := []struct {
+		forceKillAfter time.Duration
+		expectErr      bool
+	}{
+		{forceKillAfter: 0},
+		{forceKillAfter: 30 * time.Second},
+		{forceKillAfter: 500 * time.Millisecond, expectErr: true},
+		{forceKillAfter: 1500 * time.Millisecond, expectErr: true},
+		{forceKillAfter: -time.Second, expectErr: true},
+	}
+	for _, tt := range tests {
+		o := &ContainerOptions{Resources: []string{"pod/foo"}, ForceKillAfter: tt.forceKillAfter}
+		if err := o.Validate(); tt.expectErr != (err != nil) {
+			t.Errorf("--force-kill-after=%v: expected error %t, got %v", tt.forceKillAfter, tt.expectErr, err)
+		}
+	}
+}
+
+func TestHasContainers(t *testing.T) {
+	pod := &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}}}}
+	if err := hasContainers(pod, nil); err != nil {
+		t.Errorf("unexpected error for all containers: %v", err)
+	}
+	if err := hasContainers(pod, []string{"sidecar"}); err != nil {
+		t.Errorf("unexpected error for sidecar: %v", err)
+	}
+	if err := hasContainers(pod, []string{"sidecar", "unknown"}); err == nil {
+		t.Errorf("expected error for unknown container")
+	}
+}
+
+func TestPrintResults(t *testing.T) {
+	pods := []*corev1.Pod{
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc-1"}},
+		{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc-2"}},
+	}
+	streams, _, _, _ := genericclioptions.NewTestIOStreams()
+	o := &ContainerOptions{Containers: []string{"sidecar"}, IOStreams: streams}
+
+	err := o.printResults(pods, map[*corev1.Pod]error{pods[0]: nil, pods[1]: fmt.Errorf("boom")})
+	if err == nil {
+		t.Errorf("expected error for the failed pod")
+	}
+	out := streams.Out.(*bytes.Buffer).String()
+	for _, expected := range []string{"abc-1   sidecar      Restarted", "abc-2   sidecar      Failed: boom"} {
+		if !strings.Contains(out, expected) {
+			t.Errorf("expected output to contain %q, got:\n%s", expected, out)
+		}
+	}
+}
@@ -18,10 +18,14 @@ package rollout
 
 import (
 	"fmt"
+	"strconv"
+
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
 	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
 	"github.com/spf13/cobra"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -34,11 +38,15 @@ import (
 // UndoOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
 // referencing the cmd.Flags()
 type UndoOptions struct {
-	PrintFlags *genericclioptions.PrintFlags
-	ToPrinter  func(string) (printers.ResourcePrinter, error)
+	PrintFlags  *genericclioptions.PrintFlags
+	RecordFlags *genericclioptions.RecordFlags
+	ChangeCause string
+	ToPrinter   func(string) (printers.ResourcePrinter, error)
+	Recorder    genericclioptions.Recorder
 
 	Builder          func() *resource.Builder
 	ToRevision       int64
+	ToRevisionName   string
 	DryRunStrategy   cmdutil.DryRunStrategy
 	DryRunVerifier   *resource.DryRunVerifier
 	Resources        []string
@@ -64,6 +72,18 @@ var (
 		# Rollback to daemonset revision 3
 		kubectl rollout undo daemonset/abc --to-revision=3
 
+		# Rollback to the previous advanced daemonset
+		kubectl rollout undo daemonset.apps.kruise.io/abc
+
+		# Rollback a cloneset to the ControllerRevision a known-good pod is running
+		kubectl rollout undo cloneset/abc --to-revision=abc-5d8f9c
+
+		# Rollback an Advanced StatefulSet to the revision with the given hash
+		kubectl rollout undo asts/abc --to-revision=5d8f9c
+
+		# Rollback a cloneset and record why in its revision history
+		kubectl rollout undo cloneset/abc --change-cause="roll back broken nginx:1.20 release"
+
 		# Rollback to the previous deployment with dry-run
 		kubectl rollout undo --dry-run=server deployment/abc`)
 )
@@ -71,9 +91,11 @@ var (
 // NewRolloutUndoOptions returns an initialized UndoOptions instance
 func NewRolloutUndoOptions(streams genericclioptions.IOStreams) *UndoOptions {
 	return &UndoOptions{
-		PrintFlags: genericclioptions.NewPrintFlags("rolled back").WithTypeSetter(internalclient.Scheme),
-		IOStreams:  streams,
-		ToRevision: int64(0),
+		PrintFlags:  genericclioptions.NewPrintFlags("rolled back").WithTypeSetter(internalclient.Scheme),
+		RecordFlags: genericclioptions.NewRecordFlags(),
+		Recorder:    genericclioptions.NoopRecorder{},
+		IOStreams:   streams,
+		ToRevision:  int64(0),
 	}
 }
 
@@ -81,7 +103,7 @@ func NewRolloutUndoOptions(streams genericclioptions.IOStreams) *UndoOptions {
 func NewCmdRolloutUndo(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
 	o := NewRolloutUndoOptions(streams)
 
-	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advenced statefulset"}
+	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advenced statefulset", "advanced daemonset"}
 
 	cmd := &cobra.Command{
 		Use:                   "undo (TYPE NAME | TYPE/NAME) [flags]",
@@ -97,11 +119,13 @@ func NewCmdRolloutUndo(f cmdutil.Factory, streams genericclioptions.IOStreams) *
 		ValidArgs: validArgs,
 	}
 
-	cmd.Flags().Int64Var(&o.ToRevision, "to-revision", o.ToRevision, "The revision to rollback to. Default to 0 (last revision).")
+	cmd.Flags().StringVar(&o.ToRevisionName, "to-revision", "0", "The revision to rollback to, either a revision number or, for CloneSets, Advanced StatefulSets and Advanced DaemonSets, a ControllerRevision name or hash. Default to 0 (last revision).")
 	usage := "identifying the resource to get from a server."
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
 	cmdutil.AddDryRunFlag(cmd)
 	o.PrintFlags.AddFlags(cmd)
+	o.RecordFlags.AddFlags(cmd)
+	cmd.Flags().StringVar(&o.ChangeCause, "change-cause", o.ChangeCause, "The message to record in the kubernetes.io/change-cause annotation of the resource. Implies --record.")
 	return cmd
 }
 
@@ -109,10 +133,26 @@ func NewCmdRolloutUndo(f cmdutil.Factory, streams genericclioptions.IOStreams) *
 func (o *UndoOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	o.Resources = args
 	var err error
+	// hashes made of digits only are parsed as numbers too, the rollbackers of Kruise workloads
+	// look up a number that matches no revision as a hash
+	if revision, err := strconv.ParseInt(o.ToRevisionName, 10, 64); err == nil {
+		o.ToRevision = revision
+		o.ToRevisionName = ""
+	}
 	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
 	if err != nil {
 		return err
 	}
+	if len(o.ChangeCause) > 0 {
+		record := true
+		o.RecordFlags.Record = &record
+		o.RecordFlags.CompleteWithChangeCause(o.ChangeCause)
+	} else {
+		o.RecordFlags.Complete(cmd)
+	}
+	if o.Recorder, err = o.RecordFlags.ToRecorder(); err != nil {
+		return err
+	}
 	dynamicClient, err := f.DynamicClient()
 	if err != nil {
 		return err
@@ -175,7 +215,21 @@ func (o *UndoOptions) RunUndo() error {
 				return err
 			}
 		}
-		result, err := rollbacker.Rollback(info.Object, nil, o.ToRevision, o.DryRunStrategy)
+		updatedAnnotations, err := o.changeCauseAnnotations(info.Object)
+		if err != nil {
+			return err
+		}
+
+		var result string
+		if len(o.ToRevisionName) > 0 {
+			nameRollbacker, ok := rollbacker.(internalpolymorphichelpers.RevisionNameRollbacker)
+			if !ok {
+				return fmt.Errorf("--to-revision must be a revision number for %s", info.Mapping.GroupVersionKind.Kind)
+			}
+			result, err = nameRollbacker.RollbackToRevisionName(info.Object, updatedAnnotations, o.ToRevisionName, o.DryRunStrategy)
+		} else {
+			result, err = rollbacker.Rollback(info.Object, updatedAnnotations, o.ToRevision, o.DryRunStrategy)
+		}
 		if err != nil {
 			return err
 		}
@@ -190,3 +244,22 @@ func (o *UndoOptions) RunUndo() error {
 
 	return err
 }
+
+// changeCauseAnnotations returns the change-cause annotation to stamp on the rolled back object, it is
+// empty unless the rollback is recorded.
+func (o *UndoOptions) changeCauseAnnotations(obj runtime.Object) (map[string]string, error) {
+	if _, ok := o.Recorder.(genericclioptions.NoopRecorder); ok {
+		return nil, nil
+	}
+	objCopy := obj.DeepCopyObject()
+	if err := o.Recorder.Record(objCopy); err != nil {
+		return nil, err
+	}
+	accessor, err := meta.Accessor(objCopy)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		genericclioptions.ChangeCauseAnnotation: accessor.GetAnnotations()[genericclioptions.ChangeCauseAnnotation],
+	}, nil
+}
@@ -36,7 +36,10 @@ var (
 		kubectl-kruise rollout undo cloneset/abc
 
 		# Check the rollout status of a daemonset
-		kubectl-kruise rollout status daemonset/foo`)
+		kubectl-kruise rollout status daemonset/foo
+
+		# Update the next 20% of the pods of a partitioned cloneset
+		kubectl-kruise rollout approve cloneset/abc --batch=20%`)
 
 	rolloutValidResources = dedent.Dedent(`
 
@@ -46,6 +49,8 @@ var (
 			* daemonsets
 			* statefulsets
 			* clonesets
+			* advanced statefulsets
+			* advanced daemonsets
 		`)
 )
 
@@ -66,6 +71,7 @@ func NewCmdRollout(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobr
 	cmd.AddCommand(NewCmdRolloutUndo(f, streams))
 	cmd.AddCommand(NewCmdRolloutStatus(f, streams))
 	cmd.AddCommand(NewCmdRolloutRestart(f, streams))
+	cmd.AddCommand(NewCmdRolloutApprove(f, streams))
 
 	return cmd
 }
@@ -0,0 +1,388 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"github.com/spf13/cobra"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/kubectl/pkg/cmd/set"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/interrupt"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// ApproveOptions is the start of the data required to perform the operation.  As new fields are added, add them here instead of
+// referencing the cmd.Flags()
+type ApproveOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+	ToPrinter  func(string) (printers.ResourcePrinter, error)
+
+	Batch   string
+	To      string
+	Wait    bool
+	Timeout time.Duration
+
+	Approver         internalpolymorphichelpers.ObjectApproverFunc
+	Builder          func() *resource.Builder
+	DynamicClient    dynamic.Interface
+	Namespace        string
+	EnforceNamespace bool
+	Resources        []string
+
+	resource.FilenameOptions
+	genericclioptions.IOStreams
+}
+
+var (
+	approveLong = templates.LongDesc(`
+		Approve the next batch of a partitioned rollout.
+
+		The update partition of the resource is lowered either by --batch or down
+		to --to, after which the command waits for the newly updated pods to become
+		ready. Both flags accept an absolute number of pods or a percentage of the
+		replicas. Currently clonesets and advanced statefulsets support being approved.`)
+
+	approveExample = templates.Examples(`
+		# Update another 20% of the pods of the cloneset
+		kubectl-kruise rollout approve cloneset/abc --batch=20%
+
+		# Lower the partition of the cloneset to 2, so that all but 2 pods are updated
+		kubectl-kruise rollout continue cloneset/abc --to=2
+
+		# Update two more pods of the advanced statefulset without waiting for them
+		kubectl-kruise rollout approve asts/abc --batch=2 --wait=false`)
+)
+
+// NewRolloutApproveOptions returns an initialized ApproveOptions instance
+func NewRolloutApproveOptions(streams genericclioptions.IOStreams) *ApproveOptions {
+	return &ApproveOptions{
+		PrintFlags: genericclioptions.NewPrintFlags("approved").WithTypeSetter(internalclient.Scheme),
+		IOStreams:  streams,
+		Wait:       true,
+	}
+}
+
+// NewCmdRolloutApprove returns a Command instance for 'rollout approve' sub command
+func NewCmdRolloutApprove(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewRolloutApproveOptions(streams)
+
+	validArgs := []string{"cloneset", "advancedstatefulset"}
+
+	cmd := &cobra.Command{
+		Use:                   "approve RESOURCE (--batch=N | --to=N)",
+		DisableFlagsInUseLine: true,
+		Aliases:               []string{"continue"},
+		Short:                 i18n.T("Approve the next batch of a partitioned rollout"),
+		Long:                  approveLong,
+		Example:               approveExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.RunApprove())
+		},
+		ValidArgs: validArgs,
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+
+	usage := "identifying the resource to get from a server."
+	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
+	cmd.Flags().StringVar(&o.Batch, "batch", o.Batch, "Number or percentage of pods by which the partition is lowered (e.g. 2 or 20%).")
+	cmd.Flags().StringVar(&o.To, "to", o.To, "Number or percentage of pods the partition is lowered to (e.g. 0 or 50%).")
+	cmd.Flags().BoolVar(&o.Wait, "wait", o.Wait, "Wait for the updated pods of the approved batch to be ready.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for the approved batch, zero means never. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *ApproveOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	o.Approver = internalpolymorphichelpers.ObjectApproverFn
+
+	var err error
+	o.Namespace, o.EnforceNamespace, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+
+	o.Resources = args
+	o.Builder = f.NewBuilder
+
+	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
+		o.PrintFlags.NamePrintFlags.Operation = operation
+		return o.PrintFlags.ToPrinter()
+	}
+
+	o.DynamicClient, err = f.DynamicClient()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (o *ApproveOptions) Validate() error {
+	if len(o.Resources) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+		return fmt.Errorf("required resource not specified")
+	}
+	if (len(o.Batch) == 0) == (len(o.To) == 0) {
+		return fmt.Errorf("exactly one of --batch and --to must be specified")
+	}
+	return nil
+}
+
+// RunApprove performs the execution of 'rollout approve' sub command
+func (o *ApproveOptions) RunApprove() error {
+	var batch, to *intstr.IntOrString
+	if len(o.Batch) > 0 {
+		v := intstr.Parse(o.Batch)
+		batch = &v
+	}
+	if len(o.To) > 0 {
+		v := intstr.Parse(o.To)
+		to = &v
+	}
+
+	r := o.Builder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		FilenameParam(o.EnforceNamespace, &o.FilenameOptions).
+		ResourceTypeOrNameArgs(true, o.Resources...).
+		ContinueOnError().
+		Latest().
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return err
+	}
+
+	var allErrs []error
+	infos, err := r.Infos()
+	if err != nil {
+		// restore previous command behavior where
+		// an error caused by retrieving infos due to
+		// at least a single broken object did not result
+		// in an immediate return, but rather an overall
+		// aggregation of errors.
+		allErrs = append(allErrs, err)
+	}
+
+	approveFn := func(obj runtime.Object) ([]byte, error) {
+		return o.Approver(obj, batch, to)
+	}
+
+	for _, patch := range set.CalculatePatches(infos, scheme.DefaultJSONEncoder(), approveFn) {
+		info := patch.Info
+
+		if patch.Err != nil {
+			resourceString := info.Mapping.Resource.Resource
+			if len(info.Mapping.Resource.Group) > 0 {
+				resourceString = resourceString + "." + info.Mapping.Resource.Group
+			}
+			allErrs = append(allErrs, fmt.Errorf("error: %s %q %v", resourceString, info.Name, patch.Err))
+			continue
+		}
+
+		if string(patch.Patch) == "{}" || len(patch.Patch) == 0 {
+			printer, err := o.ToPrinter("already approved")
+			if err != nil {
+				allErrs = append(allErrs, err)
+				continue
+			}
+			if err = printer.PrintObj(info.Object, o.Out); err != nil {
+				allErrs = append(allErrs, err)
+			}
+			continue
+		}
+
+		obj, err := resource.NewHelper(info.Client, info.Mapping).Patch(info.Namespace, info.Name, types.MergePatchType, patch.Patch, nil)
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to patch: %v", err))
+			continue
+		}
+
+		info.Refresh(obj, true)
+		printer, err := o.ToPrinter(fmt.Sprintf("approved (%s)", describePartition(info.Object)))
+		if err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		if err = printer.PrintObj(info.Object, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+
+		if o.Wait {
+			if err := o.waitForBatch(info); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+	}
+
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// waitForBatch watches the object in info until all pods outside of its partition are updated and ready.
+func (o *ApproveOptions) waitForBatch(info *resource.Info) error {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", info.Name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = fieldSelector
+			return o.DynamicClient.Resource(info.Mapping.Resource).Namespace(info.Namespace).List(context.TODO(), options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = fieldSelector
+			return o.DynamicClient.Resource(info.Mapping.Resource).Namespace(info.Namespace).Watch(context.TODO(), options)
+		},
+	}
+
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), o.Timeout)
+	intr := interrupt.New(nil, cancel)
+	return intr.Run(func() error {
+		_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, nil, func(e watch.Event) (bool, error) {
+			switch t := e.Type; t {
+			case watch.Added, watch.Modified:
+				status, done, err := partitionProgress(e.Object.(runtime.Unstructured))
+				if err != nil {
+					return false, err
+				}
+				fmt.Fprintf(o.Out, "%s", status)
+				return done, nil
+
+			case watch.Deleted:
+				return true, fmt.Errorf("object has been deleted")
+
+			default:
+				return true, fmt.Errorf("internal error: unexpected event %#v", e)
+			}
+		})
+		return err
+	})
+}
+
+// partitionProgress returns a message describing how far the pods outside of the partition of obj
+// have been updated, and a bool value indicating if the approved batch is considered done.
+func partitionProgress(obj runtime.Unstructured) (string, bool, error) {
+	var (
+		generation, observedGeneration int64
+		replicas                       int32
+		partition                      *intstr.IntOrString
+		updated, ready                 int32
+		// updatedReady is only reported by CloneSet, for Advanced StatefulSet all pods have to be ready.
+		updatedReady *int32
+	)
+
+	switch kind := obj.GetObjectKind().GroupVersionKind().GroupKind(); kind {
+	case kruiseappsv1alpha1.SchemeGroupVersion.WithKind("CloneSet").GroupKind():
+		cs := &kruiseappsv1alpha1.CloneSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), cs); err != nil {
+			return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, cs, err)
+		}
+		generation, observedGeneration = cs.Generation, cs.Status.ObservedGeneration
+		replicas = cs.Status.Replicas
+		if cs.Spec.Replicas != nil {
+			replicas = *cs.Spec.Replicas
+		}
+		partition = cs.Spec.UpdateStrategy.Partition
+		updated, ready = cs.Status.UpdatedReplicas, cs.Status.ReadyReplicas
+		updatedReady = &cs.Status.UpdatedReadyReplicas
+
+	case kruiseappsv1beta1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
+		asts := &kruiseappsv1beta1.StatefulSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), asts); err != nil {
+			return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, asts, err)
+		}
+		generation, observedGeneration = asts.Generation, asts.Status.ObservedGeneration
+		replicas = asts.Status.Replicas
+		if asts.Spec.Replicas != nil {
+			replicas = *asts.Spec.Replicas
+		}
+		if asts.Spec.UpdateStrategy.RollingUpdate != nil && asts.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+			p := intstr.FromInt(int(*asts.Spec.UpdateStrategy.RollingUpdate.Partition))
+			partition = &p
+		}
+		updated, ready = asts.Status.UpdatedReplicas, asts.Status.ReadyReplicas
+
+	default:
+		return "", false, fmt.Errorf("approving is not supported for %v", kind)
+	}
+
+	if observedGeneration == 0 || generation > observedGeneration {
+		return "Waiting for partition update to be observed...\n", false, nil
+	}
+	partitionValue, err := internalpolymorphichelpers.PartitionValue(partition, replicas)
+	if err != nil {
+		return "", false, err
+	}
+	expected := replicas - partitionValue
+	if updated < expected {
+		return fmt.Sprintf("Waiting for approved batch to finish: %d out of %d new pods have been updated...\n", updated, expected), false, nil
+	}
+	if updatedReady != nil && *updatedReady < expected {
+		return fmt.Sprintf("Waiting for approved batch to finish: %d of %d updated pods are ready...\n", *updatedReady, expected), false, nil
+	}
+	if updatedReady == nil && ready < replicas {
+		return fmt.Sprintf("Waiting for approved batch to finish: %d pods are not ready yet...\n", replicas-ready), false, nil
+	}
+	return fmt.Sprintf("approved batch complete: %d new pods have been updated, %d of %d pods are ready\n", updated, ready, replicas), true, nil
+}
+
+// describePartition returns a short description of the partition of obj for printing.
+func describePartition(obj runtime.Object) string {
+	var content map[string]interface{}
+	if u, ok := obj.(runtime.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return "partition unknown"
+		}
+	}
+
+	for _, path := range [][]string{
+		{"spec", "updateStrategy", "partition"},
+		{"spec", "updateStrategy", "rollingUpdate", "partition"},
+	} {
+		if partition, found, _ := unstructured.NestedFieldNoCopy(content, path...); found {
+			return fmt.Sprintf("partition %v", partition)
+		}
+	}
+	return "partition 0"
+}
@@ -17,12 +17,18 @@ limitations under the License.
 package rollout
 
 import (
+	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
 
 	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
 	"github.com/spf13/cobra"
 
+	"k8s.io/apimachinery/pkg/util/duration"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
@@ -44,7 +50,19 @@ var (
 		kubectl-kruise rollout history asts/abc
 
 		# View the details of daemonset revision 3
-		kubectl-kruise rollout history daemonset/abc --revision=3`)
+		kubectl-kruise rollout history daemonset/abc --revision=3
+
+		# View the rollout history of an advanced daemonset
+		kubectl-kruise rollout history daemonset.apps.kruise.io/abc
+
+		# Compare the pod templates of cloneset revisions 2 and 3
+		kubectl-kruise rollout history cloneset/abc --diff 2 3
+
+		# View the revisions of a cloneset with their hashes, pod counts and current/update markers
+		kubectl-kruise rollout history cloneset/abc -o wide
+
+		# View the revision history of an advanced statefulset in JSON
+		kubectl-kruise rollout history asts/abc -o json`)
 )
 
 // RolloutHistoryOptions holds the options for 'rollout history' sub command
@@ -53,6 +71,9 @@ type RolloutHistoryOptions struct {
 	ToPrinter  func(string) (printers.ResourcePrinter, error)
 
 	Revision int64
+	Diff     bool
+	// DiffRevisions are the two revisions that follow the resource when --diff is set.
+	DiffRevisions []int64
 
 	Builder          func() *resource.Builder
 	Resources        []string
@@ -78,10 +99,10 @@ func NewRolloutHistoryOptions(streams genericclioptions.IOStreams) *RolloutHisto
 func NewCmdRolloutHistory(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
 	o := NewRolloutHistoryOptions(streams)
 
-	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advancedstatefulset"}
+	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advancedstatefulset", "advanceddaemonset"}
 
 	cmd := &cobra.Command{
-		Use:                   "history (TYPE NAME | TYPE/NAME) [flags]",
+		Use:                   "history (TYPE NAME | TYPE/NAME) [--diff REV1 REV2] [flags]",
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("View rollout history"),
 		Long:                  historyLong,
@@ -95,6 +116,7 @@ func NewCmdRolloutHistory(f cmdutil.Factory, streams genericclioptions.IOStreams
 	}
 
 	cmd.Flags().Int64Var(&o.Revision, "revision", o.Revision, "See the details, including podTemplate of the revision specified")
+	cmd.Flags().BoolVar(&o.Diff, "diff", o.Diff, "Show a unified diff between the podTemplates of the two revisions given after the resource, e.g. --diff 2 3")
 
 	usage := "identifying the resource to get from a server."
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
@@ -109,12 +131,21 @@ func (o *RolloutHistoryOptions) Complete(f cmdutil.Factory, cmd *cobra.Command,
 	o.Resources = args
 
 	var err error
+	if o.Diff {
+		if o.Resources, o.DiffRevisions, err = splitDiffRevisions(args); err != nil {
+			return err
+		}
+	}
 	if o.Namespace, o.EnforceNamespace, err = f.ToRawKubeConfigLoader().Namespace(); err != nil {
 		return err
 	}
 
 	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
 		o.PrintFlags.NamePrintFlags.Operation = operation
+		if o.outputFormat() == "wide" {
+			// the wide table is passed in as the operation of the name printer
+			return o.PrintFlags.TypeSetterPrinter.WrapToPrinter(o.PrintFlags.NamePrintFlags.ToPrinter(""))
+		}
 		return o.PrintFlags.ToPrinter()
 	}
 
@@ -125,6 +156,22 @@ func (o *RolloutHistoryOptions) Complete(f cmdutil.Factory, cmd *cobra.Command,
 	return nil
 }
 
+// splitDiffRevisions splits the two revisions given to --diff off the end of args.
+func splitDiffRevisions(args []string) ([]string, []int64, error) {
+	if len(args) < 2 {
+		return nil, nil, fmt.Errorf("--diff requires two revisions, e.g. --diff 2 3")
+	}
+	var revisions []int64
+	for _, arg := range args[len(args)-2:] {
+		revision, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return nil, nil, fmt.Errorf("--diff requires two revisions, got %q", arg)
+		}
+		revisions = append(revisions, revision)
+	}
+	return args[:len(args)-2], revisions, nil
+}
+
 // Validate makes sure all the provided values for command-line options are valid
 func (o *RolloutHistoryOptions) Validate() error {
 	if len(o.Resources) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
@@ -133,6 +180,22 @@ func (o *RolloutHistoryOptions) Validate() error {
 	if o.Revision < 0 {
 		return fmt.Errorf("revision must be a positive integer: %v", o.Revision)
 	}
+	if o.Diff {
+		if len(o.DiffRevisions) != 2 {
+			return fmt.Errorf("--diff requires exactly two revisions, got %d", len(o.DiffRevisions))
+		}
+		if o.Revision > 0 {
+			return fmt.Errorf("--diff and --revision cannot be used together")
+		}
+		for _, revision := range o.DiffRevisions {
+			if revision <= 0 {
+				return fmt.Errorf("revision must be a positive integer: %v", revision)
+			}
+		}
+	}
+	if o.revisionHistoryOutput() && (o.Revision > 0 || o.Diff) {
+		return fmt.Errorf("--revision and --diff cannot be used with -o %s", o.outputFormat())
+	}
 
 	return nil
 }
@@ -163,14 +226,26 @@ func (o *RolloutHistoryOptions) Run() error {
 		if err != nil {
 			return err
 		}
-		historyInfo, err := historyViewer.ViewHistory(info.Namespace, info.Name, o.Revision)
-		if err != nil {
-			return err
+
+		if o.revisionHistoryOutput() {
+			if viewer, ok := historyViewer.(internalpolymorphichelpers.RevisionHistoryViewer); ok {
+				return o.printRevisionHistory(viewer, info)
+			}
+			return fmt.Errorf("-o %s is not supported for %s", o.outputFormat(), mapping.GroupVersionKind.Kind)
 		}
 
-		withRevision := ""
-		if o.Revision > 0 {
-			withRevision = fmt.Sprintf("with revision #%d", o.Revision)
+		var historyInfo, withRevision string
+		if o.Diff {
+			historyInfo, err = historyViewer.DiffHistory(info.Namespace, info.Name, o.DiffRevisions[0], o.DiffRevisions[1])
+			withRevision = fmt.Sprintf("diff between revision #%d and #%d", o.DiffRevisions[0], o.DiffRevisions[1])
+		} else {
+			historyInfo, err = historyViewer.ViewHistory(info.Namespace, info.Name, o.Revision)
+			if o.Revision > 0 {
+				withRevision = fmt.Sprintf("with revision #%d", o.Revision)
+			}
+		}
+		if err != nil {
+			return err
 		}
 
 		printer, err := o.ToPrinter(fmt.Sprintf("%s\n%s", withRevision, historyInfo))
@@ -181,3 +256,66 @@ func (o *RolloutHistoryOptions) Run() error {
 		return printer.PrintObj(info.Object, o.Out)
 	})
 }
+
+func (o *RolloutHistoryOptions) outputFormat() string {
+	if o.PrintFlags.OutputFormat == nil {
+		return ""
+	}
+	return *o.PrintFlags.OutputFormat
+}
+
+// revisionHistoryOutput returns true if the structured revision history should be printed
+func (o *RolloutHistoryOptions) revisionHistoryOutput() bool {
+	output := o.outputFormat()
+	return len(output) > 0 && output != "name"
+}
+
+// printRevisionHistory prints the revision history as a wide table, or through the printer of the output format
+func (o *RolloutHistoryOptions) printRevisionHistory(viewer internalpolymorphichelpers.RevisionHistoryViewer, info *resource.Info) error {
+	history, err := viewer.RevisionHistory(info.Namespace, info.Name)
+	if err != nil {
+		return err
+	}
+	if o.outputFormat() != "wide" {
+		printer, err := o.PrintFlags.ToPrinter()
+		if err != nil {
+			return err
+		}
+		return printer.PrintObj(history, o.Out)
+	}
+
+	buf := &bytes.Buffer{}
+	w := printers.GetNewTabWriter(buf)
+	fmt.Fprintf(w, "REVISION\tNAME\tHASH\tPODS\tAGE\tMARK\tCHANGE-CAUSE\n")
+	for _, revision := range history.Revisions {
+		var marks []string
+		if revision.Current {
+			marks = append(marks, "current")
+		}
+		if revision.Update {
+			marks = append(marks, "update")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\t%s\n",
+			revision.Revision,
+			revision.Name,
+			valueOrNone(revision.Hash),
+			revision.Pods,
+			duration.HumanDuration(time.Since(revision.CreationTimestamp.Time)),
+			valueOrNone(strings.Join(marks, ",")),
+			valueOrNone(revision.ChangeCause))
+	}
+	w.Flush()
+
+	printer, err := o.ToPrinter(fmt.Sprintf("\n%s", buf.String()))
+	if err != nil {
+		return err
+	}
+	return printer.PrintObj(info.Object, o.Out)
+}
+
+func valueOrNone(value string) string {
+	if len(value) == 0 {
+		return "<none>"
+	}
+	return value
+}
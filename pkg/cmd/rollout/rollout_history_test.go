@@ -0,0 +1,78 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitDiffRevisions(t *testing.T) {
+	tests := []struct {
+		name              string
+		args              []string
+		expectedResources []string
+		expectedRevisions []int64
+		expectErr         bool
+	}{
+		{
+			name:              "type/name",
+			args:              []string{"cloneset/abc", "2", "3"},
+			expectedResources: []string{"cloneset/abc"},
+			expectedRevisions: []int64{2, 3},
+		},
+		{
+			name:              "type name",
+			args:              []string{"cloneset", "abc", "3", "2"},
+			expectedResources: []string{"cloneset", "abc"},
+			expectedRevisions: []int64{3, 2},
+		},
+		{
+			name:              "resource from a file",
+			args:              []string{"2", "3"},
+			expectedResources: []string{},
+			expectedRevisions: []int64{2, 3},
+		},
+		{
+			name:      "one revision",
+			args:      []string{"cloneset/abc", "2"},
+			expectErr: true,
+		},
+		{
+			name:      "no revisions",
+			args:      []string{"cloneset/abc"},
+			expectErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resources, revisions, err := splitDiffRevisions(tt.args)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if !reflect.DeepEqual(resources, tt.expectedResources) {
+				t.Errorf("expected resources %v, got %v", tt.expectedResources, resources)
+			}
+			if !reflect.DeepEqual(revisions, tt.expectedRevisions) {
+				t.Errorf("expected revisions %v, got %v", tt.expectedRevisions, revisions)
+			}
+		})
+	}
+}
@@ -40,7 +40,9 @@ var (
 
 		Paused resources will not be reconciled by a controller.
 		Use "kubectl rollout resume" to resume a paused resource.
-		Currently  deployments, clonesets, advanced statefulsets support being paused.`)
+		Currently  deployments, clonesets, advanced statefulsets, advanced daemonsets
+		and advanced cronjobs support being paused. A paused advanced cronjob does not
+		schedule new jobs.`)
 
 	pauseExample = templates.Examples(`
 		# Mark the nginx deployment as paused. Any current state of
@@ -49,7 +51,13 @@ var (
 
 		kubectl-kruise rollout pause cloneset/nginx
 
-		kubectl-kruise rollout pause deployment/nginx`)
+		kubectl-kruise rollout pause deployment/nginx
+
+		# Pause the rolling update of an advanced daemonset
+		kubectl-kruise rollout pause daemonset.apps.kruise.io/nginx
+
+		# Stop an advanced cronjob from scheduling new jobs
+		kubectl-kruise rollout pause advancedcronjob/backup`)
 )
 
 // NewCmdRolloutPause returns a Command instance for 'rollout pause' sub command
@@ -59,7 +67,7 @@ func NewCmdRolloutPause(f cmdutil.Factory, streams genericclioptions.IOStreams)
 		IOStreams:  streams,
 	}
 
-	validArgs := []string{"deployment", "cloneset", "advancedstatefulset"}
+	validArgs := []string{"deployment", "cloneset", "advancedstatefulset", "advanceddaemonset", "advancedcronjob"}
 
 	cmd := &cobra.Command{
 		Use:                   "pause RESOURCE",
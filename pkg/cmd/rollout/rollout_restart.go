@@ -1,20 +1,34 @@
 package rollout
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"time"
+
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	"github.com/hantmac/kubectl-kruise/pkg/internal/crr"
 	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	"github.com/spf13/cobra"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	watchtools "k8s.io/client-go/tools/watch"
 	"k8s.io/kubectl/pkg/cmd/set"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util/i18n"
+	podutil "k8s.io/kubectl/pkg/util/podutils"
 	"k8s.io/kubectl/pkg/util/templates"
 )
 
@@ -26,10 +40,16 @@ type RestartOptions struct {
 
 	Resources []string
 
+	InPlace        bool
+	MaxUnavailable string
+	Timeout        time.Duration
+
 	Builder          func() *resource.Builder
 	Restarter        internalpolymorphichelpers.ObjectRestarterFunc
 	Namespace        string
 	EnforceNamespace bool
+	DynamicClient    dynamic.Interface
+	Clientset        kubernetes.Interface
 
 	resource.FilenameOptions
 	genericclioptions.IOStreams
@@ -39,7 +59,12 @@ var (
 	restartLong = templates.LongDesc(`
 		Restart a resource.
 
-	        Resource will be rollout restarted.`)
+	        Resource will be rollout restarted.
+
+		With --in-place, the containers of the pods of a CloneSet are restarted in place
+		by Kruise ContainerRecreateRequests instead, so pods are neither rescheduled nor
+		lose their IPs. Pods are restarted in batches of --max-unavailable, waiting for
+		each batch to be ready again before starting the next.`)
 
 	restartExample = templates.Examples(`
 		# Restart a deployment
@@ -52,7 +77,13 @@ var (
 		kubectl-kruise rollout restart asts/abc
 
 		# Restart a daemonset
-		kubectl-kruise rollout restart daemonset/abc`)
+		kubectl-kruise rollout restart daemonset/abc
+
+		# Restart an advanced daemonset
+		kubectl-kruise rollout restart daemonset.apps.kruise.io/abc
+
+		# Restart the containers of a cloneset in place, two pods at a time
+		kubectl-kruise rollout restart cloneset/abc --in-place --max-unavailable=2`)
 )
 
 // NewRolloutRestartOptions returns an initialized RestartOptions instance
@@ -85,6 +116,9 @@ func NewCmdRolloutRestart(f cmdutil.Factory, streams genericclioptions.IOStreams
 
 	usage := "identifying the resource to get from a server."
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
+	cmd.Flags().BoolVar(&o.InPlace, "in-place", o.InPlace, "Restart the containers of CloneSet pods in place with ContainerRecreateRequests instead of recreating the pods.")
+	cmd.Flags().StringVar(&o.MaxUnavailable, "max-unavailable", o.MaxUnavailable, "Number or percentage of pods restarted in place at a time (e.g. 2 or 20%). Defaults to the maxUnavailable of the CloneSet update strategy.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait for each batch of in-place restarts, zero means never. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
 	o.PrintFlags.AddFlags(cmd)
 	return cmd
 }
@@ -108,6 +142,15 @@ func (o *RestartOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []
 
 	o.Builder = f.NewBuilder
 
+	if o.InPlace {
+		if o.DynamicClient, err = f.DynamicClient(); err != nil {
+			return err
+		}
+		if o.Clientset, err = f.KubernetesClientSet(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -115,6 +158,15 @@ func (o *RestartOptions) Validate() error {
 	if len(o.Resources) == 0 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
 		return fmt.Errorf("required resource not specified")
 	}
+	if !o.InPlace && len(o.MaxUnavailable) > 0 {
+		return fmt.Errorf("--max-unavailable can only be used with --in-place")
+	}
+	if len(o.MaxUnavailable) > 0 {
+		maxUnavailable := intstr.Parse(o.MaxUnavailable)
+		if _, err := intstr.GetValueFromIntOrPercent(&maxUnavailable, 100, false); err != nil {
+			return fmt.Errorf("invalid --max-unavailable %q: %v", o.MaxUnavailable, err)
+		}
+	}
 	return nil
 }
 
@@ -144,6 +196,15 @@ func (o RestartOptions) RunRestart() error {
 		allErrs = append(allErrs, err)
 	}
 
+	if o.InPlace {
+		for _, info := range infos {
+			if err := o.restartInPlace(info); err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
+		return utilerrors.NewAggregate(allErrs)
+	}
+
 	for _, patch := range set.CalculatePatches(infos, scheme.DefaultJSONEncoder(), set.PatchFn(o.Restarter)) {
 		info := patch.Info
 
@@ -179,3 +240,144 @@ func (o RestartOptions) RunRestart() error {
 
 	return utilerrors.NewAggregate(allErrs)
 }
+
+// restartInPlace restarts the containers of all running pods of a CloneSet in place, in batches that
+// keep at most maxUnavailable pods unavailable, waiting for every batch to be ready again before
+// starting the next. Pods that are not running are reported and left out.
+func (o RestartOptions) restartInPlace(info *resource.Info) error {
+	cs, ok := info.Object.(*kruiseappsv1alpha1.CloneSet)
+	if !ok {
+		return fmt.Errorf("%s %q: --in-place is only supported for CloneSets", info.Mapping.Resource.Resource, info.Name)
+	}
+
+	pods, err := o.podsOfCloneSet(cs)
+	if err != nil {
+		return err
+	}
+	var running []*corev1.Pod
+	unavailable := 0
+	for _, pod := range pods {
+		if pod.Status.Phase != corev1.PodRunning {
+			fmt.Fprintf(o.ErrOut, "Warning: pod %s is %s, its containers are not restarted\n", pod.Name, pod.Status.Phase)
+			unavailable++
+			continue
+		}
+		if !podutil.IsPodReady(pod) {
+			unavailable++
+		}
+		running = append(running, pod)
+	}
+	if len(running) == 0 {
+		return fmt.Errorf("cloneset %q has no running pods to restart", cs.Name)
+	}
+	batchSize, err := o.inPlaceBatchSize(cs, unavailable)
+	if err != nil {
+		return err
+	}
+
+	for start := 0; start < len(running); start += batchSize {
+		end := start + batchSize
+		if end > len(running) {
+			end = len(running)
+		}
+		batch := running[start:end]
+
+		var names []string
+		for _, pod := range batch {
+			req, err := o.DynamicClient.Resource(crr.GroupVersionResource).Namespace(pod.Namespace).
+				Create(context.TODO(), crr.NewRequest(pod, nil, crr.Strategy{}), metav1.CreateOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to restart pod %s in place: %v", pod.Name, err)
+			}
+			names = append(names, req.GetName())
+		}
+
+		if err := o.waitForInPlaceBatch(batch, names); err != nil {
+			return err
+		}
+		printer, err := o.ToPrinter(fmt.Sprintf("restarted in place (%d/%d pods)", end, len(running)))
+		if err != nil {
+			return err
+		}
+		if err := printer.PrintObj(info.Object, o.Out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// podsOfCloneSet returns the pods controlled by cs that are not terminating, sorted by name.
+func (o RestartOptions) podsOfCloneSet(cs *kruiseappsv1alpha1.CloneSet) ([]*corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(cs.Spec.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create selector for CloneSet %s: %v", cs.Name, err)
+	}
+	podList, err := o.Clientset.CoreV1().Pods(cs.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || !metav1.IsControlledBy(pod, cs) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	sort.Slice(pods, func(i, j int) bool { return pods[i].Name < pods[j].Name })
+	return pods, nil
+}
+
+// inPlaceBatchSize resolves --max-unavailable, or the maxUnavailable of the CloneSet, to a number of
+// pods, less the pods that are unavailable already.
+func (o RestartOptions) inPlaceBatchSize(cs *kruiseappsv1alpha1.CloneSet, unavailable int) (int, error) {
+	maxUnavailable := intstr.FromString("20%")
+	if len(o.MaxUnavailable) > 0 {
+		maxUnavailable = intstr.Parse(o.MaxUnavailable)
+	} else if cs.Spec.UpdateStrategy.MaxUnavailable != nil {
+		maxUnavailable = *cs.Spec.UpdateStrategy.MaxUnavailable
+	}
+	replicas := 1
+	if cs.Spec.Replicas != nil {
+		replicas = int(*cs.Spec.Replicas)
+	}
+	allowed, err := intstr.GetValueFromIntOrPercent(&maxUnavailable, replicas, false)
+	if err != nil {
+		return 0, err
+	}
+	if allowed < 1 {
+		allowed = 1
+	}
+	if allowed <= unavailable {
+		return 0, fmt.Errorf("cloneset %q has %d unavailable pods already, no more may be restarted with a maxUnavailable of %d",
+			cs.Name, unavailable, allowed)
+	}
+	return allowed - unavailable, nil
+}
+
+// waitForInPlaceBatch waits for the ContainerRecreateRequests of a batch to complete and for its pods to be ready.
+func (o RestartOptions) waitForInPlaceBatch(pods []*corev1.Pod, names []string) error {
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), o.Timeout)
+	defer cancel()
+
+	namespace := pods[0].Namespace
+	if err := crr.WaitForCompletion(ctx, o.DynamicClient, namespace, names, 2*time.Second); err != nil {
+		return fmt.Errorf("failed waiting for in-place restart of %d pods: %v", len(pods), err)
+	}
+	err := wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+		for _, pod := range pods {
+			current, err := o.Clientset.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if err != nil {
+				return false, err
+			}
+			if !podutil.IsPodReady(current) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, ctx.Done())
+	if err != nil {
+		return fmt.Errorf("failed waiting for restarted pods to be ready: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestInPlaceBatchSize(t *testing.T) {
+	percent := intstr.FromString("50%")
+	tests := []struct {
+		name           string
+		maxUnavailable string
+		strategy       *intstr.IntOrString
+		unavailable    int
+		expected       int
+		expectErr      bool
+	}{
+		{name: "default of 20%", expected: 2},
+		{name: "maxUnavailable of the cloneset", strategy: &percent, expected: 5},
+		{name: "--max-unavailable", maxUnavailable: "3", strategy: &percent, expected: 3},
+		{name: "at least one pod", maxUnavailable: "1%", expected: 1},
+		{name: "unavailable pods are left out", maxUnavailable: "3", unavailable: 2, expected: 1},
+		{name: "no pods left", maxUnavailable: "3", unavailable: 3, expectErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			replicas := int32(10)
+			cs := &kruiseappsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Name: "abc"},
+				Spec: kruiseappsv1alpha1.CloneSetSpec{
+					Replicas:       &replicas,
+					UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{MaxUnavailable: tt.strategy},
+				},
+			}
+			batchSize, err := RestartOptions{MaxUnavailable: tt.maxUnavailable}.inPlaceBatchSize(cs, tt.unavailable)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectErr, err)
+			}
+			if batchSize != tt.expected {
+				t.Errorf("expected batch size %d, got %d", tt.expected, batchSize)
+			}
+		})
+	}
+}
+
+func TestRestartInPlace(t *testing.T) {
+	replicas := int32(4)
+	maxUnavailable := intstr.FromInt(2)
+	cs := &kruiseappsv1alpha1.CloneSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.GroupVersion.String(), Kind: "CloneSet"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "abc", UID: "abc-uid"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Replicas:       &replicas,
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "abc"}},
+			UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{MaxUnavailable: &maxUnavailable},
+		},
+	}
+	newPod := func(name string, phase corev1.PodPhase, ready bool) *corev1.Pod {
+		condition := corev1.ConditionFalse
+		if ready {
+			condition = corev1.ConditionTrue
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "bar",
+				Name:            name,
+				Labels:          map[string]string{"app": "abc"},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(cs, cs.GroupVersionKind())},
+			},
+			Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main"}}},
+			Status: corev1.PodStatus{
+				Phase:      phase,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: condition}},
+			},
+		}
+	}
+
+	tests := []struct {
+		name            string
+		pods            []runtime.Object
+		completed       bool
+		expectErr       bool
+		expectRequests  int
+		expectOut       []string
+		expectErrOutput string
+	}{
+		{
+			name: "batches of maxUnavailable",
+			pods: []runtime.Object{
+				newPod("abc-a", corev1.PodRunning, true),
+				newPod("abc-b", corev1.PodRunning, true),
+				newPod("abc-c", corev1.PodRunning, true),
+			},
+			completed:      true,
+			expectRequests: 3,
+			expectOut: []string{
+				"cloneset.apps.kruise.io/abc restarted in place (2/3 pods)",
+				"cloneset.apps.kruise.io/abc restarted in place (3/3 pods)",
+			},
+		},
+		{
+			name: "unavailable pods shrink the batches and pods not running are skipped",
+			pods: []runtime.Object{
+				newPod("abc-a", corev1.PodRunning, true),
+				newPod("abc-b", corev1.PodRunning, true),
+				newPod("abc-c", corev1.PodPending, false),
+			},
+			completed:      true,
+			expectRequests: 2,
+			expectOut: []string{
+				"cloneset.apps.kruise.io/abc restarted in place (1/2 pods)",
+				"cloneset.apps.kruise.io/abc restarted in place (2/2 pods)",
+			},
+			expectErrOutput: "Warning: pod abc-c is Pending, its containers are not restarted",
+		},
+		{
+			name: "no room left by unavailable pods",
+			pods: []runtime.Object{
+				newPod("abc-a", corev1.PodRunning, true),
+				newPod("abc-b", corev1.PodRunning, false),
+				newPod("abc-c", corev1.PodFailed, false),
+			},
+			expectErr: true,
+		},
+		{
+			name: "batch not restarted in time",
+			pods: []runtime.Object{
+				newPod("abc-a", corev1.PodRunning, true),
+			},
+			expectErr:      true,
+			expectRequests: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+			requests := 0
+			dynamicClient.PrependReactor("create", "containerrecreaterequests", func(action clienttesting.Action) (bool, runtime.Object, error) {
+				req := action.(clienttesting.CreateAction).GetObject().(*unstructured.Unstructured)
+				requests++
+				req.SetName(fmt.Sprintf("%s%d", req.GetGenerateName(), requests))
+				if tt.completed {
+					req.Object["status"] = map[string]interface{}{"phase": "Completed"}
+				}
+				return false, nil, nil
+			})
+
+			streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+			printFlags := genericclioptions.NewPrintFlags("").WithTypeSetter(internalclient.Scheme)
+			o := RestartOptions{
+				Timeout:       50 * time.Millisecond,
+				DynamicClient: dynamicClient,
+				Clientset:     fake.NewSimpleClientset(tt.pods...),
+				ToPrinter: func(operation string) (printers.ResourcePrinter, error) {
+					printFlags.NamePrintFlags.Operation = operation
+					return printFlags.ToPrinter()
+				},
+				IOStreams: streams,
+			}
+			info := &resource.Info{
+				Mapping: &meta.RESTMapping{Resource: kruiseappsv1alpha1.GroupVersion.WithResource("clonesets")},
+				Name:    cs.Name,
+				Object:  cs.DeepCopy(),
+			}
+
+			err := o.restartInPlace(info)
+			if tt.expectErr != (err != nil) {
+				t.Fatalf("expected error %t, got %v", tt.expectErr, err)
+			}
+			if requests != tt.expectRequests {
+				t.Errorf("expected %d ContainerRecreateRequests, got %d", tt.expectRequests, requests)
+			}
+			if lines := strings.Split(strings.TrimSpace(out.String()), "\n"); len(tt.expectOut) > 0 && strings.Join(lines, "\n") != strings.Join(tt.expectOut, "\n") {
+				t.Errorf("expected output %q, got %q", tt.expectOut, lines)
+			}
+			if !strings.Contains(errOut.String(), tt.expectErrOutput) {
+				t.Errorf("expected error output to contain %q, got %q", tt.expectErrOutput, errOut.String())
+			}
+		})
+	}
+}
@@ -41,13 +41,17 @@ var (
 
 		Paused resources will not be reconciled by a controller. By resuming a
 		resource, we allow it to be reconciled again.
-		Currently deployments, cloneset, advancedstatefulset support being resumed.`)
+		Currently deployments, cloneset, advancedstatefulset, advanceddaemonset, advancedcronjob support being resumed.`)
 
 	resumeExample = templates.Examples(`
 		# Resume an already paused deployment
 		
 		kubectl-kruise rollout resume cloneset/nginx
-		kubectl-kruise rollout resume deployment/nginx`)
+		kubectl-kruise rollout resume deployment/nginx
+		kubectl-kruise rollout resume daemonset.apps.kruise.io/nginx
+
+		# Let an advanced cronjob schedule jobs again
+		kubectl-kruise rollout resume advancedcronjob/backup`)
 )
 
 // NewRolloutResumeOptions returns an initialized ResumeOptions instance
@@ -62,7 +66,7 @@ func NewRolloutResumeOptions(streams genericclioptions.IOStreams) *ResumeOptions
 func NewCmdRolloutResume(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
 	o := NewRolloutResumeOptions(streams)
 
-	validArgs := []string{"deployment", "cloneset"}
+	validArgs := []string{"deployment", "cloneset", "advancedstatefulset", "advanceddaemonset", "advancedcronjob"}
 
 	cmd := &cobra.Command{
 		Use:                   "resume RESOURCE",
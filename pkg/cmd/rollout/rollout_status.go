@@ -3,9 +3,13 @@ package rollout
 import (
 	"context"
 	"fmt"
-	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	"io"
+	"strings"
+	"sync"
 	"time"
 
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
 	"github.com/spf13/cobra"
 
@@ -15,12 +19,15 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/tools/cache"
 	watchtools "k8s.io/client-go/tools/watch"
+	"k8s.io/klog"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util/i18n"
@@ -37,7 +44,24 @@ var (
 		you can use --watch=false. Note that if a new rollout starts in-between, then
 		'rollout status' will continue watching the latest revision. If you want to
 		pin to a specific revision and abort if it is rolled over by another revision,
-		use --revision=N where N is the revision you need to watch for.`)
+		use --revision=N where N is the revision you need to watch for.
+
+		When several resources are selected, e.g. with --selector, their rollouts are
+		watched concurrently and a summary table is printed once all of them are done.
+		--timeout then applies to the whole group, and the command fails if any of the
+		rollouts fails or times out.
+
+		While a rollout is waiting for pods, their conditions and events are inspected
+		to report why they are not ready, e.g. ImagePullBackOff, CrashLoopBackOff, an
+		unschedulable pod or a failing readiness probe. Use --fail-fast to abort as
+		soon as a cause is found that the rollout won't recover from by itself.
+
+		The status of a BroadcastJob can be watched as well: it is done once the job
+		has completed on all of its nodes, and fails if the job fails.
+
+		The rollout of a UnitedDeployment is done once the CloneSets, StatefulSets or
+		Deployments of all of its subsets are rolled out, and the progress of each
+		subset is shown.`)
 
 	statusExample = templates.Examples(`
 		# Watch the rollout status of a deployment
@@ -47,24 +71,50 @@ var (
 		kubectl-kruise rollout status cloneset/nginx
 
 		# Watch the rollout status of a advanced statefulset
-		kubectl-kruise rollout status asts/nginx`)
+		kubectl-kruise rollout status asts/nginx
+
+		# Watch the rollout status of an advanced daemonset
+		kubectl-kruise rollout status daemonset.apps.kruise.io/nginx
+
+		# Watch the rollout status of the subsets of a uniteddeployment
+		kubectl-kruise rollout status uniteddeployment/nginx
+
+		# Wait for a broadcastjob to finish on all nodes
+		kubectl-kruise rollout status broadcastjob/hello
+
+		# Print the rollout status of a cloneset as a single JSON document
+		kubectl-kruise rollout status cloneset/nginx --watch=false -o json
+
+		# Watch the rollout status of a cloneset, printing one JSON document per change
+		kubectl-kruise rollout status cloneset/nginx -o json
+
+		# Watch the rollout status of a cloneset, aborting as soon as its pods can't start, e.g. on ImagePullBackOff
+		kubectl-kruise rollout status cloneset/nginx --fail-fast
+
+		# Watch the rollouts of all clonesets of an application, giving up after 10 minutes
+		kubectl-kruise rollout status clonesets -l app.kubernetes.io/part-of=shop --timeout=10m`)
 )
 
 // RolloutStatusOptions holds the command-line options for 'rollout status' sub command
 type RolloutStatusOptions struct {
 	PrintFlags *genericclioptions.PrintFlags
+	// PrintObj prints the structured status, it is nil if no output format was requested.
+	PrintObj printers.ResourcePrinterFunc
 
 	Namespace        string
 	EnforceNamespace bool
 	BuilderArgs      []string
+	LabelSelector    string
 
 	Watch    bool
 	Revision int64
 	Timeout  time.Duration
+	FailFast bool
 
 	StatusViewerFn func(*meta.RESTMapping) (internalpolymorphichelpers.StatusViewer, error)
 	Builder        func() *resource.Builder
 	DynamicClient  dynamic.Interface
+	Diagnoser      *internalpolymorphichelpers.RolloutDiagnoser
 
 	FilenameOptions *resource.FilenameOptions
 	genericclioptions.IOStreams
@@ -85,7 +135,7 @@ func NewRolloutStatusOptions(streams genericclioptions.IOStreams) *RolloutStatus
 func NewCmdRolloutStatus(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
 	o := NewRolloutStatusOptions(streams)
 
-	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advanced statefulset"}
+	validArgs := []string{"deployment", "daemonset", "statefulset", "cloneset", "advanced statefulset", "advanced daemonset", "broadcastjob", "uniteddeployment"}
 
 	cmd := &cobra.Command{
 		Use:                   "status (TYPE NAME | TYPE/NAME) [flags]",
@@ -103,9 +153,12 @@ func NewCmdRolloutStatus(f cmdutil.Factory, streams genericclioptions.IOStreams)
 
 	usage := "identifying the resource to get from a server."
 	cmdutil.AddFilenameOptionFlags(cmd, o.FilenameOptions, usage)
+	cmd.Flags().StringVarP(&o.LabelSelector, "selector", "l", o.LabelSelector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
 	cmd.Flags().BoolVarP(&o.Watch, "watch", "w", o.Watch, "Watch the status of the rollout until it's done.")
 	cmd.Flags().Int64Var(&o.Revision, "revision", o.Revision, "Pin to a specific revision for showing its status. Defaults to 0 (last revision).")
-	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait before ending watch, zero means never. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", o.Timeout, "The length of time to wait before ending watch, zero means never. If several resources are selected, the timeout applies to all of them together. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	cmd.Flags().BoolVar(&o.FailFast, "fail-fast", o.FailFast, "Abort as soon as pods of the rollout hit a failure they won't recover from by themselves, e.g. ImagePullBackOff or CrashLoopBackOff, instead of waiting for --timeout.")
+	o.PrintFlags.AddFlags(cmd)
 
 	return cmd
 }
@@ -133,6 +186,20 @@ func (o *RolloutStatusOptions) Complete(f cmdutil.Factory, args []string) error
 		return err
 	}
 
+	clientset, err := f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	o.Diagnoser = &internalpolymorphichelpers.RolloutDiagnoser{Client: clientset}
+
+	if o.PrintFlags.OutputFormat != nil && len(*o.PrintFlags.OutputFormat) > 0 {
+		printer, err := o.PrintFlags.ToPrinter()
+		if err != nil {
+			return err
+		}
+		o.PrintObj = printer.PrintObj
+	}
+
 	return nil
 }
 
@@ -146,18 +213,27 @@ func (o *RolloutStatusOptions) Validate() error {
 		return fmt.Errorf("revision must be a positive integer: %v", o.Revision)
 	}
 
+	if o.PrintFlags.OutputFormat != nil && *o.PrintFlags.OutputFormat == "name" {
+		return fmt.Errorf("output format %q is not supported by rollout status", "name")
+	}
+
 	return nil
 }
 
+// diagnosisInterval is how often a rollout that is not done is diagnosed while no events arrive.
+const diagnosisInterval = 10 * time.Second
+
 // Run performs the execution of 'rollout status' sub command
 func (o *RolloutStatusOptions) Run() error {
 	r := o.Builder().
 		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
 		NamespaceParam(o.Namespace).DefaultNamespace().
 		FilenameParam(o.EnforceNamespace, o.FilenameOptions).
+		LabelSelectorParam(o.LabelSelector).
 		ResourceTypeOrNameArgs(true, o.BuilderArgs...).
 		SingleResourceType().
 		Latest().
+		Flatten().
 		Do()
 	err := r.Err()
 	if err != nil {
@@ -168,26 +244,42 @@ func (o *RolloutStatusOptions) Run() error {
 	if err != nil {
 		return err
 	}
-	if len(infos) != 1 {
-		return fmt.Errorf("rollout status is only supported on individual resources and resource collections - %d resources were found", len(infos))
+	if len(infos) == 0 {
+		return fmt.Errorf("no resources found")
+	}
+
+	// if the rollout isn't done yet, keep watching deployment status
+	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), o.Timeout)
+	intr := interrupt.New(nil, cancel)
+	if len(infos) > 1 {
+		return intr.Run(func() error {
+			return o.watchGroup(ctx, infos)
+		})
 	}
-	info := infos[0]
-	mapping := info.ResourceMapping()
 
-	statusViewer, err := o.StatusViewerFn(mapping)
+	info := infos[0]
+	statusViewer, err := o.StatusViewerFn(info.ResourceMapping())
 	if err != nil {
 		return err
 	}
+	return intr.Run(func() error {
+		return o.watchStatus(ctx, info, statusViewer, o.printStatus)
+	})
+}
 
+// watchStatus watches the object of info and hands every status to handleStatus, until the
+// rollout is done, the object is deleted or ctx is done.
+func (o *RolloutStatusOptions) watchStatus(ctx context.Context, info *resource.Info, statusViewer internalpolymorphichelpers.StatusViewer, handleStatus func(*internalpolymorphichelpers.RolloutStatus) error) error {
+	mapping := info.ResourceMapping()
 	fieldSelector := fields.OneTermEqualSelector("metadata.name", info.Name).String()
 	lw := &cache.ListWatch{
 		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
 			options.FieldSelector = fieldSelector
-			return o.DynamicClient.Resource(info.Mapping.Resource).Namespace(info.Namespace).List(context.TODO(), options)
+			return o.DynamicClient.Resource(mapping.Resource).Namespace(info.Namespace).List(context.TODO(), options)
 		},
 		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 			options.FieldSelector = fieldSelector
-			return o.DynamicClient.Resource(info.Mapping.Resource).Namespace(info.Namespace).Watch(context.TODO(), options)
+			return o.DynamicClient.Resource(mapping.Resource).Namespace(info.Namespace).Watch(context.TODO(), options)
 		},
 	}
 
@@ -205,38 +297,225 @@ func (o *RolloutStatusOptions) Run() error {
 		return false, nil
 	}
 
-	// if the rollout isn't done yet, keep watching deployment status
-	ctx, cancel := watchtools.ContextWithOptionalTimeout(context.Background(), o.Timeout)
-	intr := interrupt.New(nil, cancel)
-	return intr.Run(func() error {
-		_, err = watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, preconditionFunc, func(e watch.Event) (bool, error) {
-			switch t := e.Type; t {
-			case watch.Added, watch.Modified:
-				status, done, err := statusViewer.Status(e.Object.(runtime.Unstructured), o.Revision)
-				if err != nil {
-					return false, err
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu sync.Mutex
+		// latest is the last seen object while its rollout is not done
+		latest     runtime.Unstructured
+		lastIssues string
+		failure    error
+	)
+	// diagnose adds the causes that keep pods of the rollout from becoming ready to status. It returns
+	// whether they changed since the last diagnosis, and an error if --fail-fast hit a terminal one.
+	diagnose := func(obj runtime.Unstructured, status *internalpolymorphichelpers.RolloutStatus) (bool, error) {
+		issues, err := o.Diagnoser.Diagnose(obj)
+		if err != nil {
+			klog.V(4).Infof("failed to diagnose rollout of %s %q: %v", mapping.Resource.Resource, info.Name, err)
+			return false, nil
+		}
+		status.Issues = issues
+		key := fmt.Sprint(issues)
+		changed := key != lastIssues
+		lastIssues = key
+		if o.FailFast {
+			for _, issue := range issues {
+				if issue.Terminal {
+					return changed, fmt.Errorf("rollout failed: %s", issue)
 				}
-				fmt.Fprintf(o.Out, "%s", status)
-				// Quit waiting if the rollout is done
-				if done {
-					return true, nil
+			}
+		}
+		return changed, nil
+	}
+
+	// Pods of a stuck rollout don't change the object, so diagnose it periodically as well.
+	if o.Diagnoser != nil {
+		go func() {
+			ticker := time.NewTicker(diagnosisInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
 				}
 
-				shouldWatch := o.Watch
-				if !shouldWatch {
-					return true, nil
+				mu.Lock()
+				if latest != nil {
+					status, done, err := statusViewer.Status(latest, o.Revision)
+					if err == nil && !done {
+						changed, err := diagnose(latest, status)
+						if changed {
+							if printErr := handleStatus(status); printErr != nil {
+								klog.V(4).Infof("failed to print rollout status: %v", printErr)
+							}
+						}
+						if err != nil {
+							failure = err
+							cancel()
+						}
+					}
 				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	_, err := watchtools.UntilWithSync(ctx, lw, &unstructured.Unstructured{}, preconditionFunc, func(e watch.Event) (bool, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch t := e.Type; t {
+		case watch.Added, watch.Modified:
+			obj := e.Object.(runtime.Unstructured)
+			status, done, err := statusViewer.Status(obj, o.Revision)
+			if err != nil {
+				return false, err
+			}
+			var failErr error
+			latest = nil
+			if !done && o.Diagnoser != nil {
+				latest = obj
+				_, failErr = diagnose(obj, status)
+			}
+			if err := handleStatus(status); err != nil {
+				return false, err
+			}
+			if failErr != nil {
+				return true, failErr
+			}
+			// Quit waiting if the rollout is done
+			if done {
+				return true, nil
+			}
+
+			shouldWatch := o.Watch
+			if !shouldWatch {
+				return true, nil
+			}
 
-				return false, nil
+			return false, nil
 
-			case watch.Deleted:
-				// We need to abort to avoid cases of recreation and not to silently watch the wrong (new) object
-				return true, fmt.Errorf("object has been deleted")
+		case watch.Deleted:
+			// We need to abort to avoid cases of recreation and not to silently watch the wrong (new) object
+			return true, fmt.Errorf("object has been deleted")
+
+		default:
+			return true, fmt.Errorf("internal error: unexpected event %#v", e)
+		}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if failure != nil {
+		return failure
+	}
+	return err
+}
+
+// groupStatus is the last known rollout status of one object of a group.
+type groupStatus struct {
+	name   string
+	status *internalpolymorphichelpers.RolloutStatus
+	err    error
+}
+
+// watchGroup watches the rollouts of all infos concurrently and prints a summary table once
+// all of them are done. It fails if any of the rollouts fails or does not finish before ctx is done.
+func (o *RolloutStatusOptions) watchGroup(ctx context.Context, infos []*resource.Info) error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make([]groupStatus, len(infos))
+	)
+	for i, info := range infos {
+		results[i].name = fmt.Sprintf("%s/%s", strings.ToLower(info.Mapping.GroupVersionKind.Kind), info.Name)
+		statusViewer, err := o.StatusViewerFn(info.ResourceMapping())
+		if err != nil {
+			results[i].err = err
+			continue
+		}
 
-			default:
-				return true, fmt.Errorf("internal error: unexpected event %#v", e)
+		wg.Add(1)
+		go func(i int, info *resource.Info, statusViewer internalpolymorphichelpers.StatusViewer) {
+			defer wg.Done()
+			err := o.watchStatus(ctx, info, statusViewer, func(status *internalpolymorphichelpers.RolloutStatus) error {
+				mu.Lock()
+				defer mu.Unlock()
+				results[i].status = status
+				if o.PrintObj != nil {
+					return o.PrintObj(status, o.Out)
+				}
+				_, err := fmt.Fprintf(o.Out, "%s: %s", results[i].name, statusText(status))
+				return err
+			})
+			if err == wait.ErrWaitTimeout {
+				err = fmt.Errorf("timed out waiting for the rollout to finish")
 			}
-		})
+			mu.Lock()
+			results[i].err = err
+			mu.Unlock()
+		}(i, info, statusViewer)
+	}
+	wg.Wait()
+
+	var failed int
+	for _, result := range results {
+		if result.err != nil {
+			failed++
+		}
+	}
+	if o.PrintObj == nil {
+		if err := printGroupStatus(o.Out, results); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d rollouts did not finish successfully", failed, len(results))
+	}
+	return nil
+}
+
+// printGroupStatus prints a summary table of the rollout status of a group of objects.
+func printGroupStatus(out io.Writer, results []groupStatus) error {
+	w := printers.GetNewTabWriter(out)
+	fmt.Fprintf(w, "\nNAME\tPHASE\tDESIRED\tUPDATED\tAVAILABLE\tMESSAGE\n")
+	for _, result := range results {
+		phase, desired, updated, available, message := "Unknown", "-", "-", "-", ""
+		if result.status != nil {
+			phase = string(result.status.Phase)
+			desired = fmt.Sprint(result.status.Desired)
+			updated = fmt.Sprint(result.status.Updated)
+			available = fmt.Sprint(result.status.Available)
+			message = result.status.Message
+		}
+		if result.err != nil {
+			phase = "Failed"
+			message = result.err.Error()
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", result.name, phase, desired, updated, available, message)
+	}
+	return w.Flush()
+}
+
+// printStatus prints the structured status if an output format was requested, and its message otherwise.
+func (o *RolloutStatusOptions) printStatus(status *internalpolymorphichelpers.RolloutStatus) error {
+	if o.PrintObj == nil {
+		_, err := fmt.Fprint(o.Out, statusText(status))
 		return err
-	})
+	}
+	return o.PrintObj(status, o.Out)
+}
+
+// statusText returns the message of status, followed by one indented line per diagnosed issue.
+func statusText(status *internalpolymorphichelpers.RolloutStatus) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", status.Message)
+	for _, subset := range status.Subsets {
+		fmt.Fprintf(&b, "  %s\n", subset)
+	}
+	for _, issue := range status.Issues {
+		fmt.Fprintf(&b, "  %s\n", issue)
+	}
+	return b.String()
 }
@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// fakeStatusViewer fails the rollout of the object named failing and never finishes the others.
+type fakeStatusViewer struct {
+	failing string
+}
+
+func (v fakeStatusViewer) Status(obj runtime.Unstructured, revision int64) (*internalpolymorphichelpers.RolloutStatus, bool, error) {
+	name := obj.(*unstructured.Unstructured).GetName()
+	if name == v.failing {
+		return nil, false, fmt.Errorf("cloneset %q exceeded its progress deadline", name)
+	}
+	return &internalpolymorphichelpers.RolloutStatus{
+		Phase:     internalpolymorphichelpers.RolloutProgressing,
+		Desired:   3,
+		Updated:   1,
+		Available: 2,
+		Message:   fmt.Sprintf("Waiting for cloneset %q rollout to finish: 1 out of 3 new replicas have been updated...", name),
+	}, false, nil
+}
+
+func TestWatchGroup(t *testing.T) {
+	gvr := kruiseappsv1alpha1.GroupVersion.WithResource("clonesets")
+	gvk := kruiseappsv1alpha1.GroupVersion.WithKind("CloneSet")
+	newCloneSet := func(namespace, name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace(namespace)
+		obj.SetName(name)
+		return obj
+	}
+	// The objects are put into namespaces of their own, since the fake client ignores field selectors.
+	objs := []*unstructured.Unstructured{newCloneSet("foo", "failing"), newCloneSet("bar", "stuck")}
+	var infos []*resource.Info
+	for _, obj := range objs {
+		infos = append(infos, &resource.Info{
+			Mapping:   &meta.RESTMapping{Resource: gvr, GroupVersionKind: gvk, Scope: meta.RESTScopeNamespace},
+			Namespace: obj.GetNamespace(),
+			Name:      obj.GetName(),
+			Object:    obj,
+		})
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	o := NewRolloutStatusOptions(streams)
+	o.DynamicClient = dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objs[0], objs[1])
+	o.StatusViewerFn = func(*meta.RESTMapping) (internalpolymorphichelpers.StatusViewer, error) {
+		return fakeStatusViewer{failing: "failing"}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	err := o.watchGroup(ctx, infos)
+	if err == nil || err.Error() != "2 of 2 rollouts did not finish successfully" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, `cloneset/stuck: Waiting for cloneset "stuck" rollout to finish`) {
+		t.Errorf("expected the progress of cloneset/stuck, got %q", output)
+	}
+	rows := []*regexp.Regexp{
+		regexp.MustCompile(`(?m)^NAME\s+PHASE\s+DESIRED\s+UPDATED\s+AVAILABLE\s+MESSAGE$`),
+		regexp.MustCompile(`(?m)^cloneset/failing\s+Failed\s+-\s+-\s+-\s+cloneset "failing" exceeded its progress deadline$`),
+		regexp.MustCompile(`(?m)^cloneset/stuck\s+Failed\s+3\s+1\s+2\s+timed out waiting for the rollout to finish$`),
+	}
+	for _, row := range rows {
+		if !row.MatchString(output) {
+			t.Errorf("expected the summary to match %q, got %q", row, output)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hantmac/kubectl-kruise/pkg/fetcher"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+func (o *ScaleOptions) changesReserveOrdinals() bool {
+	return len(o.ReserveOrdinals) > 0 || len(o.UnreserveOrdinals) > 0
+}
+
+// validateOrdinals makes sure ordinals are not negative and not both reserved and unreserved.
+func validateOrdinals(reserve, unreserve []int) error {
+	for _, ordinal := range append(append([]int{}, reserve...), unreserve...) {
+		if ordinal < 0 {
+			return fmt.Errorf("invalid ordinal %d, ordinals must be greater than or equal to 0", ordinal)
+		}
+	}
+	both := sets.NewInt(reserve...).Intersection(sets.NewInt(unreserve...))
+	if both.Len() > 0 {
+		return fmt.Errorf("ordinals %v can not be both reserved and unreserved", both.List())
+	}
+	return nil
+}
+
+// scaleReserveOrdinals edits the reserved ordinals of the Advanced StatefulSet held by info together
+// with its replicas if --replicas is given, and prints the ordinals of the pods that exist afterwards.
+func (o *ScaleOptions) scaleReserveOrdinals(info *resource.Info) error {
+	if info.Mapping.GroupVersionKind.GroupKind() != kruiseappsv1beta1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind() {
+		return fmt.Errorf("%s %q has no reserved ordinals, --reserve-ordinal and --unreserve-ordinal are only supported for advanced statefulsets", info.Mapping.Resource.Resource, info.Name)
+	}
+	asts, found, err := fetcher.GetAdvancedStsInCache(info.Namespace, info.Name, o.statefulSetReader)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return errors.NewNotFound(info.Mapping.Resource.GroupResource(), info.Name)
+	}
+
+	current := 1
+	if asts.Spec.Replicas != nil {
+		current = int(*asts.Spec.Replicas)
+	}
+	if o.CurrentReplicas >= 0 && o.CurrentReplicas != current {
+		return fmt.Errorf("expected replicas to be %d, was %d", o.CurrentReplicas, current)
+	}
+	replicas := current
+	if o.Replicas >= 0 {
+		replicas = o.Replicas
+	}
+	reserved := updateReserveOrdinals(asts.Spec.ReserveOrdinals, o.ReserveOrdinals, o.UnreserveOrdinals)
+
+	if len(o.ResourceVersion) > 0 && o.ResourceVersion != asts.ResourceVersion {
+		return fmt.Errorf("expected resourceVersion to be %s, was %s", o.ResourceVersion, asts.ResourceVersion)
+	}
+	// the patch replaces the whole list of reserved ordinals, so it only applies to the version
+	// they were read from
+	var reserveOrdinals interface{}
+	if len(reserved) > 0 {
+		reserveOrdinals = reserved
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": replicas, "reserveOrdinals": reserveOrdinals},
+	})
+	if err != nil {
+		return err
+	}
+	if err := o.patch(info, patch, asts.ResourceVersion); err != nil {
+		return fmt.Errorf("failed to scale %s: %v", info.Name, err)
+	}
+
+	// keep the printed object parseable when an output format is requested
+	out := o.Out
+	if len(o.output) > 0 {
+		out = o.ErrOut
+	}
+	fmt.Fprintf(out, "%s/%s ordinals: %s (reserved: %s)\n", info.Mapping.Resource.Resource, info.Name,
+		formatOrdinals(podOrdinals(replicas, reserved)), formatOrdinals(reserved))
+	return nil
+}
+
+// updateReserveOrdinals returns the sorted reserved ordinals after adding reserve to and removing
+// unreserve from current.
+func updateReserveOrdinals(current, reserve, unreserve []int) []int {
+	reserved := sets.NewInt(current...).Insert(reserve...).Delete(unreserve...)
+	return reserved.List()
+}
+
+// podOrdinals returns the ordinals of the pods of an Advanced StatefulSet, which are the
+// lowest replicas ordinals that are not reserved.
+func podOrdinals(replicas int, reserved []int) []int {
+	skip := sets.NewInt(reserved...)
+	ordinals := make([]int, 0, replicas)
+	for ordinal := 0; len(ordinals) < replicas; ordinal++ {
+		if !skip.Has(ordinal) {
+			ordinals = append(ordinals, ordinal)
+		}
+	}
+	return ordinals
+}
+
+func formatOrdinals(ordinals []int) string {
+	if len(ordinals) == 0 {
+		return "<none>"
+	}
+	sort.Ints(ordinals)
+	s := make([]string, 0, len(ordinals))
+	for _, ordinal := range ordinals {
+		s = append(s, strconv.Itoa(ordinal))
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	restfake "k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestValidateOrdinals(t *testing.T) {
+	if err := validateOrdinals([]int{1, 3}, []int{2}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateOrdinals([]int{-1}, nil); err == nil {
+		t.Errorf("expected error for a negative ordinal")
+	}
+	if err := validateOrdinals([]int{1, 3}, []int{3}); err == nil || err.Error() != "ordinals [3] can not be both reserved and unreserved" {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestPodOrdinals(t *testing.T) {
+	testCases := []struct {
+		current, reserve, unreserve []int
+		replicas                    int
+		expectedReserved            []int
+		expectedOrdinals            []int
+	}{
+		{
+			reserve:          []int{1},
+			replicas:         3,
+			expectedReserved: []int{1},
+			expectedOrdinals: []int{0, 2, 3},
+		},
+		{
+			current:          []int{1},
+			reserve:          []int{3},
+			replicas:         2,
+			expectedReserved: []int{1, 3},
+			expectedOrdinals: []int{0, 2},
+		},
+		{
+			current:          []int{1, 3},
+			unreserve:        []int{1, 5},
+			replicas:         4,
+			expectedReserved: []int{3},
+			expectedOrdinals: []int{0, 1, 2, 4},
+		},
+	}
+	for i, testCase := range testCases {
+		reserved := updateReserveOrdinals(testCase.current, testCase.reserve, testCase.unreserve)
+		if !reflect.DeepEqual(reserved, testCase.expectedReserved) {
+			t.Errorf("%d: expected reserved ordinals %v, got %v", i, testCase.expectedReserved, reserved)
+		}
+		if ordinals := podOrdinals(testCase.replicas, reserved); !reflect.DeepEqual(ordinals, testCase.expectedOrdinals) {
+			t.Errorf("%d: expected ordinals %v, got %v", i, testCase.expectedOrdinals, ordinals)
+		}
+	}
+}
+
+func TestScaleReserveOrdinals(t *testing.T) {
+	replicas := int32(5)
+	asts := &kruiseappsv1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", ResourceVersion: "42"},
+		Spec:       kruiseappsv1beta1.StatefulSetSpec{Replicas: &replicas, ReserveOrdinals: []int{1}},
+	}
+	// ordinal 1 was reserved on the server after the file was written
+	u := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1beta1",
+		"kind":       "StatefulSet",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "default"},
+		"spec":       map[string]interface{}{"replicas": int64(5)},
+	}}
+
+	var patch []byte
+	restClient := &restfake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client: restfake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+			if req.Method != http.MethodPatch || req.URL.Path != "/namespaces/default/statefulsets/db" {
+				t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+			}
+			patch, _ = ioutil.ReadAll(req.Body)
+			body, _ := u.MarshalJSON()
+			return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+		}),
+	}
+
+	streams, _, out, _ := genericclioptions.NewTestIOStreams()
+	o := NewScaleOptions(streams)
+	o.Replicas = 4
+	o.ReserveOrdinals = []int{3}
+	o.statefulSetReader = fake.NewFakeClientWithScheme(internalclient.Scheme, asts)
+	o.unstructuredClientForMapping = func(*meta.RESTMapping) (resource.RESTClient, error) {
+		return restClient, nil
+	}
+	info := &resource.Info{
+		Name:      "db",
+		Namespace: "default",
+		Object:    u,
+		Mapping: &meta.RESTMapping{
+			Resource:         kruiseappsv1beta1.SchemeGroupVersion.WithResource("statefulsets"),
+			GroupVersionKind: kruiseappsv1beta1.SchemeGroupVersion.WithKind("StatefulSet"),
+			Scope:            meta.RESTScopeNamespace,
+		},
+	}
+
+	if err := o.scaleReserveOrdinals(info); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := `{"metadata":{"resourceVersion":"42"},"spec":{"replicas":4,"reserveOrdinals":[1,3]}}`; string(patch) != expected {
+		t.Errorf("expected patch %s, got %s", expected, patch)
+	}
+	if expected := "statefulsets/db ordinals: 0, 2, 4, 5 (reserved: 1, 3)\n"; out.String() != expected {
+		t.Errorf("expected output %q, got %q", expected, out.String())
+	}
+}
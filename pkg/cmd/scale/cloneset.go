@@ -0,0 +1,132 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/cli-runtime/pkg/resource"
+)
+
+// podDeletionPollInterval is how often pods are checked while waiting for them to be deleted.
+var podDeletionPollInterval = time.Second
+
+// scaleInPods sets the replicas of the CloneSet held by info together with the pods to delete
+// first, and waits for these pods to be deleted if --timeout is given.
+func (o *ScaleOptions) scaleInPods(info *resource.Info) error {
+	if info.Mapping.GroupVersionKind.GroupKind() != kruiseappsv1alpha1.SchemeGroupVersion.WithKind("CloneSet").GroupKind() {
+		return fmt.Errorf("%s %q can not delete specific pods, --delete-pods is only supported for clonesets", info.Mapping.Resource.Resource, info.Name)
+	}
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", info.Object)
+	}
+	pods, err := o.podsOfCloneSet(u, o.PodsToDelete)
+	if err != nil {
+		return err
+	}
+
+	err = o.patchUnstructured(info, func(cs *unstructured.Unstructured) error {
+		return setPodsToDelete(cs, o.Replicas, o.CurrentReplicas, o.PodsToDelete)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale %s: %v", info.Name, err)
+	}
+
+	if o.Timeout == 0 {
+		return nil
+	}
+	return o.waitForPodsDeleted(pods)
+}
+
+// podsOfCloneSet gets the named pods and makes sure they are controlled by the CloneSet cs.
+func (o *ScaleOptions) podsOfCloneSet(cs *unstructured.Unstructured, names []string) ([]*corev1.Pod, error) {
+	if len(cs.GetUID()) == 0 {
+		return nil, fmt.Errorf("CloneSet %s has no uid, it must be read from the server", cs.GetName())
+	}
+	var pods []*corev1.Pod
+	var errs []error
+	for _, name := range names {
+		pod, err := o.clientSet.CoreV1().Pods(cs.GetNamespace()).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if owner := metav1.GetControllerOf(pod); owner == nil || owner.UID != cs.GetUID() {
+			errs = append(errs, fmt.Errorf("pod %q does not belong to CloneSet %s", name, cs.GetName()))
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, utilerrors.NewAggregate(errs)
+}
+
+// setPodsToDelete sets the replicas of the unstructured CloneSet cs like setReplicas, and adds pods
+// to its scaleStrategy.podsToDelete.
+func setPodsToDelete(cs *unstructured.Unstructured, replicas, currentReplicas int, pods []string) error {
+	if err := setReplicas(cs, replicas, currentReplicas); err != nil {
+		return err
+	}
+	podsToDelete, _, err := unstructured.NestedStringSlice(cs.Object, "spec", "scaleStrategy", "podsToDelete")
+	if err != nil {
+		return err
+	}
+	existing := sets.NewString(podsToDelete...)
+	for _, pod := range pods {
+		if !existing.Has(pod) {
+			existing.Insert(pod)
+			podsToDelete = append(podsToDelete, pod)
+		}
+	}
+	return unstructured.SetNestedStringSlice(cs.Object, podsToDelete, "spec", "scaleStrategy", "podsToDelete")
+}
+
+// waitForPodsDeleted waits until the pods are gone. Pods recreated with the same name are
+// told apart by their UID.
+func (o *ScaleOptions) waitForPodsDeleted(pods []*corev1.Pod) error {
+	var remaining []string
+	err := wait.PollImmediate(podDeletionPollInterval, o.Timeout, func() (bool, error) {
+		remaining = nil
+		for _, pod := range pods {
+			current, err := o.clientSet.CoreV1().Pods(pod.Namespace).Get(context.TODO(), pod.Name, metav1.GetOptions{})
+			if errors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+				continue
+			}
+			if err != nil {
+				return false, err
+			}
+			remaining = append(remaining, pod.Name)
+		}
+		return len(remaining) == 0, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("timed out waiting for pods %s to be deleted", strings.Join(remaining, ", "))
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newCloneSet() *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "CloneSet",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "default", "uid": "cs-uid"},
+		"spec": map[string]interface{}{
+			"replicas": int64(6),
+			"scaleStrategy": map[string]interface{}{
+				"podsToDelete": []interface{}{"web-a"},
+			},
+		},
+	}}
+}
+
+func newClonedPod(name string, owner types.UID) *corev1.Pod {
+	controller := true
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      name,
+		Namespace: "default",
+		UID:       types.UID(name + "-uid"),
+		OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: "apps.kruise.io/v1alpha1",
+			Kind:       "CloneSet",
+			Name:       "web",
+			UID:        owner,
+			Controller: &controller,
+		}},
+	}}
+}
+
+func TestSetPodsToDelete(t *testing.T) {
+	cs := newCloneSet()
+	if err := setPodsToDelete(cs, 4, 6, []string{"web-a", "web-x", "web-y"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(cs.Object, "spec", "replicas"); replicas != 4 {
+		t.Errorf("expected 4 replicas, got %d", replicas)
+	}
+	podsToDelete, _, _ := unstructured.NestedStringSlice(cs.Object, "spec", "scaleStrategy", "podsToDelete")
+	if expected := []string{"web-a", "web-x", "web-y"}; !reflect.DeepEqual(podsToDelete, expected) {
+		t.Errorf("expected podsToDelete %v, got %v", expected, podsToDelete)
+	}
+
+	if err := setPodsToDelete(newCloneSet(), 4, 5, []string{"web-x"}); err == nil {
+		t.Errorf("expected error for mismatched current replicas")
+	}
+}
+
+func TestPodsOfCloneSet(t *testing.T) {
+	o := &ScaleOptions{clientSet: fake.NewSimpleClientset(
+		newClonedPod("web-x", "cs-uid"),
+		newClonedPod("other-x", "other-uid"),
+	)}
+
+	pods, err := o.podsOfCloneSet(newCloneSet(), []string{"web-x"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "web-x" {
+		t.Errorf("unexpected pods: %v", pods)
+	}
+
+	_, err = o.podsOfCloneSet(newCloneSet(), []string{"web-x", "other-x", "missing"})
+	expected := `[pod "other-x" does not belong to CloneSet web, pods "missing" not found]`
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+
+	local := newCloneSet()
+	local.SetUID("")
+	if _, err := o.podsOfCloneSet(local, []string{"web-x"}); err == nil {
+		t.Errorf("expected error for a CloneSet without uid")
+	}
+}
+
+func TestScaleInPodsOfFile(t *testing.T) {
+	manifest := `apiVersion: apps.kruise.io/v1alpha1
+kind: CloneSet
+metadata:
+  name: web
+  namespace: test
+spec:
+  replicas: 6
+`
+	// web-b was added to podsToDelete on the server after the file was written
+	live := newCloneSet()
+	live.SetNamespace("test")
+	live.SetResourceVersion("42")
+	unstructured.SetNestedStringSlice(live.Object, []string{"web-a", "web-b"}, "spec", "scaleStrategy", "podsToDelete")
+
+	var patch []byte
+	o, cleanup := newFileScaleOptions(t, manifest, func(req *http.Request) (*http.Response, error) {
+		switch p, m := req.URL.Path, req.Method; {
+		case p == "/namespaces/test/clonesets/web" && m == http.MethodGet:
+			return jsonResponse(live)
+		case p == "/namespaces/test/clonesets/web" && m == http.MethodPatch:
+			patch, _ = ioutil.ReadAll(req.Body)
+			return jsonResponse(live)
+		default:
+			t.Fatalf("unexpected request: %s %s", m, p)
+			return nil, nil
+		}
+	})
+	defer cleanup()
+	pod := newClonedPod("web-x", "cs-uid")
+	pod.Namespace = "test"
+	o.clientSet = fake.NewSimpleClientset(pod)
+	o.Replicas = 5
+	o.PodsToDelete = []string{"web-x"}
+
+	if err := o.RunScale(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"metadata":{"resourceVersion":"42"},"spec":{"replicas":5,"scaleStrategy":{"podsToDelete":["web-a","web-b","web-x"]}}}`
+	if string(patch) != expected {
+		t.Errorf("expected patch %s, got %s", expected, patch)
+	}
+}
+
+func TestWaitForPodsDeleted(t *testing.T) {
+	podDeletionPollInterval = 10 * time.Millisecond
+	defer func() { podDeletionPollInterval = time.Second }()
+
+	deleted := newClonedPod("web-x", "cs-uid")
+	recreated := newClonedPod("web-y", "cs-uid")
+	remaining := newClonedPod("web-z", "cs-uid")
+	current := recreated.DeepCopy()
+	current.UID = "web-y-new-uid"
+
+	o := &ScaleOptions{
+		clientSet: fake.NewSimpleClientset(current, remaining),
+		Timeout:   50 * time.Millisecond,
+	}
+	if err := o.waitForPodsDeleted([]*corev1.Pod{deleted, recreated}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	err := o.waitForPodsDeleted([]*corev1.Pod{deleted, remaining})
+	expected := "timed out waiting for pods web-z to be deleted"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,522 @@
+/*
+Copyright 2014 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scale"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	scaleLong = templates.LongDesc(i18n.T(`
+		Set a new size for a Deployment, ReplicaSet, Replication Controller, StatefulSet,
+		CloneSet, Advanced StatefulSet or UnitedDeployment.
+
+		Scale also allows users to specify one or more preconditions for the scale action.
+
+		If --current-replicas or --resource-version is specified, it is validated before the
+		scale is attempted, and it is guaranteed that the precondition holds true when the
+		scale is sent to the server.
+
+		The replicas of single subsets of a UnitedDeployment are set with --subset,
+		either as a number of pods or as a percentage of the replicas of the
+		UnitedDeployment. --replicas is optional then.
+
+		The pods removed when scaling in a CloneSet are picked with --delete-pods, which
+		sets the replicas and the scaleStrategy.podsToDelete of the CloneSet in one update.
+		The pods must belong to the CloneSet. With --timeout the command waits until the
+		pods are deleted.
+
+		Ordinals of an Advanced StatefulSet are taken out with --reserve-ordinal and brought
+		back with --unreserve-ordinal, which edit its spec.reserveOrdinals together with the
+		replicas given by --replicas, if any. The ordinals of the pods that exist afterwards
+		are printed.`))
+
+	scaleExample = templates.Examples(i18n.T(`
+		# Scale a replicaset named 'foo' to 3.
+		kubectl-kruise scale --replicas=3 rs/foo
+
+		# Scale a resource identified by type and name specified in "foo.yaml" to 3.
+		kubectl-kruise scale --replicas=3 -f foo.yaml
+
+		# If the deployment named mysql's current size is 2, scale mysql to 3.
+		kubectl-kruise scale --current-replicas=2 --replicas=3 deployment/mysql
+
+		# Scale multiple replication controllers.
+		kubectl-kruise scale --replicas=5 rc/foo rc/bar rc/baz
+
+		# Scale statefulset named 'web' to 3.
+		kubectl-kruise scale --replicas=3 statefulset/web
+
+		# Scale cloneset named 'web' to 3.
+		kubectl-kruise scale --replicas=3 cloneset/web
+
+		# Scale cloneset named 'web' to 4, deleting the pods 'web-x' and 'web-y', and wait until they are gone.
+		kubectl-kruise scale --replicas=4 cloneset/web --delete-pods=web-x,web-y --timeout=5m
+
+		# Run 5 pods of the uniteddeployment named 'web' in the subset 'zone-a'.
+		kubectl-kruise scale uniteddeployment/web --subset=zone-a=5
+
+		# Scale the uniteddeployment named 'web' to 10, running 30% of its pods in 'zone-a' and 2 in 'zone-b'.
+		kubectl-kruise scale uniteddeployment/web --replicas=10 --subset=zone-a=30% --subset=zone-b=2
+
+		# Take the pod with ordinal 3 out of the advanced statefulset 'db' of 5 replicas without replacing it.
+		kubectl-kruise scale asts/db --reserve-ordinal=3 --replicas=4
+
+		# Bring ordinal 3 of the advanced statefulset 'db' back.
+		kubectl-kruise scale asts/db --unreserve-ordinal=3 --replicas=5`))
+)
+
+type ScaleOptions struct {
+	FilenameOptions resource.FilenameOptions
+	RecordFlags     *genericclioptions.RecordFlags
+	PrintFlags      *genericclioptions.PrintFlags
+	PrintObj        printers.ResourcePrinterFunc
+
+	Selector        string
+	All             bool
+	Replicas        int
+	ResourceVersion string
+	CurrentReplicas int
+	Timeout         time.Duration
+	Subsets         []string
+	PodsToDelete    []string
+
+	ReserveOrdinals   []int
+	UnreserveOrdinals []int
+
+	Recorder                     genericclioptions.Recorder
+	builder                      *resource.Builder
+	namespace                    string
+	enforceNamespace             bool
+	args                         []string
+	shortOutput                  bool
+	output                       string
+	clientSet                    kubernetes.Interface
+	scaler                       scale.Scaler
+	unstructuredClientForMapping func(mapping *meta.RESTMapping) (resource.RESTClient, error)
+	parent                       string
+	subsetReplicas               []subsetReplicas
+	statefulSetReader            client.Reader
+
+	genericclioptions.IOStreams
+}
+
+func NewScaleOptions(ioStreams genericclioptions.IOStreams) *ScaleOptions {
+	return &ScaleOptions{
+		PrintFlags:      genericclioptions.NewPrintFlags("scaled"),
+		RecordFlags:     genericclioptions.NewRecordFlags(),
+		Replicas:        -1,
+		CurrentReplicas: -1,
+		Recorder:        genericclioptions.NoopRecorder{},
+		IOStreams:       ioStreams,
+	}
+}
+
+// NewCmdScale returns a cobra command with the appropriate configuration and flags to run scale
+func NewCmdScale(f cmdutil.Factory, ioStreams genericclioptions.IOStreams) *cobra.Command {
+	o := NewScaleOptions(ioStreams)
+
+	validArgs := []string{"deployment", "replicaset", "replicationcontroller", "statefulset", "cloneset", "advancedstatefulset", "uniteddeployment"}
+
+	cmd := &cobra.Command{
+		Use:                   "scale [--resource-version=version] [--current-replicas=count] --replicas=COUNT [--subset=SUBSET=COUNT] [--delete-pods=POD,...] [--reserve-ordinal=ORDINAL,...] [--unreserve-ordinal=ORDINAL,...] (-f FILENAME | TYPE NAME)",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Set a new size for a Deployment, ReplicaSet, Replication Controller, CloneSet or UnitedDeployment"),
+		Long:                  scaleLong,
+		Example:               scaleExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate(cmd))
+			cmdutil.CheckErr(o.RunScale())
+		},
+		ValidArgs: validArgs,
+	}
+
+	o.RecordFlags.AddFlags(cmd)
+	o.PrintFlags.AddFlags(cmd)
+
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&o.All, "all", o.All, "Select all resources in the namespace of the specified resource types")
+	cmd.Flags().StringVar(&o.ResourceVersion, "resource-version", o.ResourceVersion, i18n.T("Precondition for resource version. Requires that the current resource version match this value in order to scale."))
+	cmd.Flags().IntVar(&o.CurrentReplicas, "current-replicas", o.CurrentReplicas, "Precondition for current size. Requires that the current size of the resource match this value in order to scale.")
+	cmd.Flags().IntVar(&o.Replicas, "replicas", o.Replicas, "The new desired number of replicas. Required unless --subset is given.")
+	cmd.Flags().StringArrayVar(&o.Subsets, "subset", o.Subsets, "The new desired replicas of a subset of a UnitedDeployment as SUBSET=COUNT, where COUNT is a number or a percentage. May be repeated.")
+	cmd.Flags().StringSliceVar(&o.PodsToDelete, "delete-pods", o.PodsToDelete, "The pods of a CloneSet to delete when scaling it to --replicas, through its scaleStrategy.podsToDelete.")
+	cmd.Flags().IntSliceVar(&o.ReserveOrdinals, "reserve-ordinal", o.ReserveOrdinals, "The ordinals of an Advanced StatefulSet to reserve, so that no pods run with them.")
+	cmd.Flags().IntSliceVar(&o.UnreserveOrdinals, "unreserve-ordinal", o.UnreserveOrdinals, "The reserved ordinals of an Advanced StatefulSet to use for pods again.")
+	cmd.Flags().DurationVar(&o.Timeout, "timeout", 0, "The length of time to wait before giving up on a scale operation, zero means don't wait. Any other values should contain a corresponding time unit (e.g. 1s, 2m, 3h).")
+	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, "identifying the resource to set a new size")
+	return cmd
+}
+
+func (o *ScaleOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+	o.RecordFlags.Complete(cmd)
+	o.Recorder, err = o.RecordFlags.ToRecorder()
+	if err != nil {
+		return err
+	}
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	o.PrintObj = printer.PrintObj
+
+	o.namespace, o.enforceNamespace, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	o.builder = f.NewBuilder()
+	o.args = args
+	o.output = cmdutil.GetFlagString(cmd, "output")
+	o.shortOutput = o.output == "name"
+	o.clientSet, err = f.KubernetesClientSet()
+	if err != nil {
+		return err
+	}
+	o.scaler, err = scaler(f)
+	if err != nil {
+		return err
+	}
+	o.unstructuredClientForMapping = f.UnstructuredClientForMapping
+	o.parent = cmd.Parent().Name()
+	if o.changesReserveOrdinals() {
+		o.statefulSetReader = internalclient.NewManager().GetAPIReader()
+	}
+
+	return nil
+}
+
+func (o *ScaleOptions) Validate(cmd *cobra.Command) error {
+	if len(o.PodsToDelete) > 0 && len(o.Subsets) > 0 {
+		return fmt.Errorf("--delete-pods can not be used with --subset")
+	}
+	if o.changesReserveOrdinals() {
+		if len(o.Subsets) > 0 || len(o.PodsToDelete) > 0 {
+			return fmt.Errorf("--reserve-ordinal and --unreserve-ordinal can not be used with --subset or --delete-pods")
+		}
+		if cmd.Flags().Changed("replicas") && o.Replicas < 0 {
+			return fmt.Errorf("--replicas=COUNT must be greater than or equal to 0")
+		}
+		return validateOrdinals(o.ReserveOrdinals, o.UnreserveOrdinals)
+	}
+	if len(o.Subsets) == 0 {
+		if o.Replicas < 0 {
+			return fmt.Errorf("The --replicas=COUNT flag is required, and COUNT must be greater than or equal to 0")
+		}
+		return nil
+	}
+
+	if cmd.Flags().Changed("replicas") && o.Replicas < 0 {
+		return fmt.Errorf("--replicas=COUNT must be greater than or equal to 0")
+	}
+	if o.Timeout != 0 {
+		return fmt.Errorf("--timeout can not be used with --subset")
+	}
+	var err error
+	o.subsetReplicas, err = parseSubsetReplicas(o.Subsets)
+	return err
+}
+
+// RunScale executes the scaling
+func (o *ScaleOptions) RunScale() error {
+	builder := o.builder.
+		Unstructured().
+		ContinueOnError().
+		NamespaceParam(o.namespace).DefaultNamespace().
+		FilenameParam(o.enforceNamespace, &o.FilenameOptions).
+		ResourceTypeOrNameArgs(o.All, o.args...).
+		Flatten().
+		LabelSelectorParam(o.Selector)
+	if o.patchesObjects() {
+		// patches are computed from the object, which must not be the content of a file
+		builder = builder.Latest()
+	}
+	r := builder.Do()
+	err := r.Err()
+	if err != nil {
+		return err
+	}
+
+	infos := []*resource.Info{}
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err == nil {
+			infos = append(infos, info)
+		}
+		return nil
+	})
+
+	if len(o.ResourceVersion) != 0 && len(infos) > 1 {
+		return fmt.Errorf("cannot use --resource-version with multiple resources")
+	}
+	if len(o.PodsToDelete) != 0 && len(infos) > 1 {
+		return fmt.Errorf("cannot use --delete-pods with multiple resources")
+	}
+	if o.changesReserveOrdinals() && len(infos) > 1 {
+		return fmt.Errorf("cannot use --reserve-ordinal or --unreserve-ordinal with multiple resources")
+	}
+
+	// only set a precondition if the user has requested one.  A nil precondition means we can do a blind update, so
+	// we avoid a Scale GET that may or may not succeed
+	var precondition *scale.ScalePrecondition
+	if o.CurrentReplicas != -1 || len(o.ResourceVersion) > 0 {
+		precondition = &scale.ScalePrecondition{Size: o.CurrentReplicas, ResourceVersion: o.ResourceVersion}
+	}
+	retry := scale.NewRetryParams(1*time.Second, 5*time.Minute)
+
+	var waitForReplicas *scale.RetryParams
+	if o.Timeout != 0 {
+		waitForReplicas = scale.NewRetryParams(1*time.Second, o.Timeout)
+	}
+
+	counter := 0
+	err = r.Visit(func(info *resource.Info, err error) error {
+		if err != nil {
+			return err
+		}
+
+		mapping := info.ResourceMapping()
+		if len(o.subsetReplicas) > 0 {
+			if err := o.scaleSubsets(info); err != nil {
+				return err
+			}
+		} else if len(o.PodsToDelete) > 0 {
+			if err := o.scaleInPods(info); err != nil {
+				return err
+			}
+		} else if o.changesReserveOrdinals() {
+			if err := o.scaleReserveOrdinals(info); err != nil {
+				return err
+			}
+		} else if err := o.scaler.Scale(info.Namespace, info.Name, uint(o.Replicas), precondition, retry, waitForReplicas, mapping.Resource); err != nil {
+			return err
+		}
+
+		// if the recorder makes a change, compute and create another patch
+		if mergePatch, err := o.Recorder.MakeRecordMergePatch(info.Object); err != nil {
+			klog.V(4).Infof("error recording current command: %v", err)
+		} else if len(mergePatch) > 0 {
+			client, err := o.unstructuredClientForMapping(mapping)
+			if err != nil {
+				return err
+			}
+			helper := resource.NewHelper(client, mapping)
+			if _, err := helper.Patch(info.Namespace, info.Name, types.MergePatchType, mergePatch, nil); err != nil {
+				klog.V(4).Infof("error recording reason: %v", err)
+			}
+		}
+
+		counter++
+		return o.PrintObj(info.Object, o.Out)
+	})
+	if err != nil {
+		return err
+	}
+	if counter == 0 {
+		return fmt.Errorf("no objects passed to scale")
+	}
+	return nil
+}
+
+func scaler(f cmdutil.Factory) (scale.Scaler, error) {
+	scalesGetter, err := cmdutil.ScaleClientFn(f)
+	if err != nil {
+		return nil, err
+	}
+
+	return scale.NewScaler(scalesGetter), nil
+}
+
+// subsetReplicas are the desired replicas of a subset of a UnitedDeployment.
+type subsetReplicas struct {
+	name     string
+	replicas intstr.IntOrString
+}
+
+// parseSubsetReplicas parses SUBSET=COUNT arguments.
+func parseSubsetReplicas(args []string) ([]subsetReplicas, error) {
+	var result []subsetReplicas
+	seen := make(map[string]bool)
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+			return nil, fmt.Errorf("invalid --subset %q, expected SUBSET=COUNT", arg)
+		}
+		if seen[parts[0]] {
+			return nil, fmt.Errorf("subset %q is given more than once", parts[0])
+		}
+		seen[parts[0]] = true
+
+		replicas := intstr.Parse(parts[1])
+		value, err := intstr.GetValueFromIntOrPercent(&replicas, 100, false)
+		if err != nil || value < 0 || (replicas.Type == intstr.String && value > 100) {
+			return nil, fmt.Errorf("invalid --subset %q, COUNT must be a number or a percentage of at most 100%%", arg)
+		}
+		result = append(result, subsetReplicas{name: parts[0], replicas: replicas})
+	}
+	return result, nil
+}
+
+// scaleSubsets sets the replicas of the subsets of the UnitedDeployment held by info, and its
+// replicas if --replicas is given. Subsets are edited on the unstructured object, so that fields
+// unknown to the kruise-api version used here are kept.
+func (o *ScaleOptions) scaleSubsets(info *resource.Info) error {
+	if info.Mapping.GroupVersionKind.GroupKind() != kruiseappsv1alpha1.SchemeGroupVersion.WithKind("UnitedDeployment").GroupKind() {
+		return fmt.Errorf("%s %q has no subsets, --subset is only supported for uniteddeployments", info.Mapping.Resource.Resource, info.Name)
+	}
+	err := o.patchUnstructured(info, func(ud *unstructured.Unstructured) error {
+		return setSubsetReplicas(ud, o.Replicas, o.CurrentReplicas, o.subsetReplicas)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to scale the subsets of %s: %v", info.Name, err)
+	}
+	return nil
+}
+
+// patchesObjects returns true if the scale is done by patching objects instead of through the
+// scale subresource.
+func (o *ScaleOptions) patchesObjects() bool {
+	return len(o.Subsets) > 0 || len(o.PodsToDelete) > 0 || o.changesReserveOrdinals()
+}
+
+// patchUnstructured patches the unstructured object held by info with the changes made by mutate
+// to a copy of it, and refreshes info with the patched object. The patch is only applied to the
+// resource version of the object, or the one given by --resource-version, because merge patches
+// replace whole lists and would otherwise overwrite concurrent changes.
+func (o *ScaleOptions) patchUnstructured(info *resource.Info, mutate func(u *unstructured.Unstructured) error) error {
+	u, ok := info.Object.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", info.Object)
+	}
+	before, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	changed := u.DeepCopy()
+	if err := mutate(changed); err != nil {
+		return err
+	}
+	after, err := json.Marshal(changed)
+	if err != nil {
+		return err
+	}
+	patch, err := jsonmergepatch.CreateThreeWayJSONMergePatch(before, after, before)
+	if err != nil {
+		return err
+	}
+	resourceVersion := o.ResourceVersion
+	if len(resourceVersion) == 0 {
+		resourceVersion = u.GetResourceVersion()
+	}
+	return o.patch(info, patch, resourceVersion)
+}
+
+// patch applies a merge patch to the object held by info, if it still has the given resource
+// version, and refreshes info with the patched object.
+func (o *ScaleOptions) patch(info *resource.Info, patch []byte, resourceVersion string) error {
+	var err error
+	if len(resourceVersion) > 0 {
+		if patch, err = internalpolymorphichelpers.WithResourceVersion(patch, resourceVersion); err != nil {
+			return err
+		}
+	}
+
+	client, err := o.unstructuredClientForMapping(info.Mapping)
+	if err != nil {
+		return err
+	}
+	obj, err := resource.NewHelper(client, info.Mapping).Patch(info.Namespace, info.Name, types.MergePatchType, patch, nil)
+	if err != nil {
+		return err
+	}
+	return info.Refresh(obj, true)
+}
+
+// setSubsetReplicas sets the replicas of the given subsets of the unstructured UnitedDeployment ud,
+// and its replicas unless replicas is negative. An error is returned if currentReplicas is not
+// negative and does not match the replicas of ud.
+func setSubsetReplicas(ud *unstructured.Unstructured, replicas, currentReplicas int, subsets []subsetReplicas) error {
+	if err := setReplicas(ud, replicas, currentReplicas); err != nil {
+		return err
+	}
+
+	topology, _, err := unstructured.NestedSlice(ud.Object, "spec", "topology", "subsets")
+	if err != nil {
+		return err
+	}
+	for _, subset := range subsets {
+		found := false
+		for _, s := range topology {
+			s, ok := s.(map[string]interface{})
+			if !ok || s["name"] != subset.name {
+				continue
+			}
+			found = true
+			if subset.replicas.Type == intstr.Int {
+				s["replicas"] = int64(subset.replicas.IntVal)
+			} else {
+				s["replicas"] = subset.replicas.StrVal
+			}
+		}
+		if !found {
+			return fmt.Errorf("UnitedDeployment %s has no subset %q", ud.GetName(), subset.name)
+		}
+	}
+	return unstructured.SetNestedSlice(ud.Object, topology, "spec", "topology", "subsets")
+}
+
+// setReplicas sets the replicas of the unstructured workload u unless replicas is negative. An error is
+// returned if currentReplicas is not negative and does not match the replicas of u.
+func setReplicas(u *unstructured.Unstructured, replicas, currentReplicas int) error {
+	if currentReplicas >= 0 {
+		current, _, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		if err != nil {
+			return err
+		}
+		if int(current) != currentReplicas {
+			return fmt.Errorf("expected replicas to be %d, was %d", currentReplicas, current)
+		}
+	}
+	if replicas >= 0 {
+		return unstructured.SetNestedField(u.Object, int64(replicas), "spec", "replicas")
+	}
+	return nil
+}
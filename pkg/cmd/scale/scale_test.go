@@ -0,0 +1,193 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scale
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+)
+
+func TestParseSubsetReplicas(t *testing.T) {
+	subsets, err := parseSubsetReplicas([]string{"zone-a=5", "zone-b=30%"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(subsets) != 2 || subsets[0].name != "zone-a" || subsets[0].replicas.String() != "5" ||
+		subsets[1].name != "zone-b" || subsets[1].replicas.String() != "30%" {
+		t.Errorf("unexpected subsets: %#v", subsets)
+	}
+
+	for _, args := range [][]string{{"zone-a"}, {"=5"}, {"zone-a="}, {"zone-a=-1"}, {"zone-a=120%"}, {"zone-a=abc"}, {"zone-a=1", "zone-a=2"}} {
+		if _, err := parseSubsetReplicas(args); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
+
+func TestSetSubsetReplicas(t *testing.T) {
+	newUD := func() *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web"},
+			"spec": map[string]interface{}{
+				"replicas": int64(6),
+				"topology": map[string]interface{}{
+					"subsets": []interface{}{
+						map[string]interface{}{"name": "zone-a", "replicas": int64(2), "patch": map[string]interface{}{"metadata": map[string]interface{}{}}},
+						map[string]interface{}{"name": "zone-b"},
+					},
+				},
+			},
+		}}
+	}
+	subsets, err := parseSubsetReplicas([]string{"zone-a=5", "zone-b=50%"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ud := newUD()
+	if err := setSubsetReplicas(ud, 10, 6, subsets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(ud.Object, "spec", "replicas"); replicas != 10 {
+		t.Errorf("expected replicas 10, got %d", replicas)
+	}
+	topology, _, _ := unstructured.NestedSlice(ud.Object, "spec", "topology", "subsets")
+	zoneA, zoneB := topology[0].(map[string]interface{}), topology[1].(map[string]interface{})
+	if zoneA["replicas"] != int64(5) || zoneA["patch"] == nil || zoneB["replicas"] != "50%" {
+		t.Errorf("unexpected subsets: %#v", topology)
+	}
+
+	ud = newUD()
+	if err := setSubsetReplicas(ud, -1, -1, subsets); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if replicas, _, _ := unstructured.NestedInt64(ud.Object, "spec", "replicas"); replicas != 6 {
+		t.Errorf("expected replicas to be kept at 6, got %d", replicas)
+	}
+
+	if err := setSubsetReplicas(newUD(), -1, 3, subsets); err == nil {
+		t.Errorf("expected error for mismatching current replicas")
+	}
+	unknown, _ := parseSubsetReplicas([]string{"zone-c=1"})
+	if err := setSubsetReplicas(newUD(), -1, -1, unknown); err == nil {
+		t.Errorf("expected error for unknown subset")
+	}
+}
+
+// newFileScaleOptions returns ScaleOptions reading the resources of a manifest file, served by handler.
+func newFileScaleOptions(t *testing.T, manifest string, handler func(req *http.Request) (*http.Response, error)) (*ScaleOptions, func()) {
+	dir, err := ioutil.TempDir("", "scale")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "manifest.yaml")
+	if err := ioutil.WriteFile(file, []byte(manifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tf := cmdtesting.NewTestFactory().WithNamespace("test")
+	tf.UnstructuredClient = &fake.RESTClient{
+		NegotiatedSerializer: resource.UnstructuredPlusDefaultContentConfig().NegotiatedSerializer,
+		Client:               fake.CreateHTTPClient(handler),
+	}
+
+	o := NewScaleOptions(genericclioptions.NewTestIOStreamsDiscard())
+	o.FilenameOptions.Filenames = []string{file}
+	o.builder = tf.NewBuilder()
+	o.namespace = "test"
+	o.unstructuredClientForMapping = tf.UnstructuredClientForMapping
+	o.PrintObj = func(runtime.Object, io.Writer) error { return nil }
+	return o, func() {
+		tf.Cleanup()
+		os.RemoveAll(dir)
+	}
+}
+
+// jsonResponse returns a response with the JSON of obj.
+func jsonResponse(obj *unstructured.Unstructured) (*http.Response, error) {
+	body, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(), Body: ioutil.NopCloser(bytes.NewReader(body))}, nil
+}
+
+func TestScaleSubsetsOfFile(t *testing.T) {
+	manifest := `apiVersion: apps.kruise.io/v1alpha1
+kind: UnitedDeployment
+metadata:
+  name: web
+  namespace: test
+spec:
+  replicas: 6
+  topology:
+    subsets:
+    - name: zone-a
+`
+	// subset zone-b was added on the server after the file was written
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "UnitedDeployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "test", "resourceVersion": "42"},
+		"spec": map[string]interface{}{
+			"replicas": int64(6),
+			"topology": map[string]interface{}{
+				"subsets": []interface{}{
+					map[string]interface{}{"name": "zone-a"},
+					map[string]interface{}{"name": "zone-b", "replicas": int64(2)},
+				},
+			},
+		},
+	}}
+
+	var patch []byte
+	o, cleanup := newFileScaleOptions(t, manifest, func(req *http.Request) (*http.Response, error) {
+		switch p, m := req.URL.Path, req.Method; {
+		case p == "/namespaces/test/uniteddeployments/web" && m == http.MethodGet:
+			return jsonResponse(live)
+		case p == "/namespaces/test/uniteddeployments/web" && m == http.MethodPatch:
+			patch, _ = ioutil.ReadAll(req.Body)
+			return jsonResponse(live)
+		default:
+			t.Fatalf("unexpected request: %s %s", m, p)
+			return nil, nil
+		}
+	})
+	defer cleanup()
+	o.Subsets = []string{"zone-a=3"}
+	o.subsetReplicas, _ = parseSubsetReplicas(o.Subsets)
+
+	if err := o.RunScale(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"metadata":{"resourceVersion":"42"},"spec":{"topology":{"subsets":[{"name":"zone-a","replicas":3},{"name":"zone-b","replicas":2}]}}}`
+	if string(patch) != expected {
+		t.Errorf("expected patch %s, got %s", expected, patch)
+	}
+}
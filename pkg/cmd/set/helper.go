@@ -19,10 +19,16 @@ package set
 import (
 	"strings"
 
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	"github.com/spf13/cobra"
+
 	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/cli-runtime/pkg/resource"
 )
 
@@ -104,6 +110,16 @@ func CalculatePatch(patch *Patch, encoder runtime.Encoder, mutateFn PatchFn) boo
 		return false
 	}
 
+	// strategic merge patches need the Go type of an object, e.g. subset patches of
+	// UnitedDeployments are only edited on unstructured objects
+	if u, ok := patch.Info.Object.(*unstructured.Unstructured); ok {
+		patch.Patch, patch.Err = jsonmergepatch.CreateThreeWayJSONMergePatch(patch.Before, patch.After, patch.Before)
+		// a merge patch replaces the whole list of subsets, so it is pinned to the version it was computed from
+		if patch.Err == nil && string(patch.Patch) != "{}" && len(u.GetResourceVersion()) > 0 {
+			patch.Patch, patch.Err = internalpolymorphichelpers.WithResourceVersion(patch.Patch, u.GetResourceVersion())
+		}
+		return true
+	}
 	patch.Patch, patch.Err = strategicpatch.CreateTwoWayMergePatch(patch.Before, patch.After, patch.Info.Object)
 	return true
 }
@@ -154,3 +170,21 @@ func updateEnv(existing []v1.EnvVar, env []v1.EnvVar, remove []string) []v1.EnvV
 	}
 	return out
 }
+
+// addChangeCauseFlag adds the --change-cause flag, which records a message instead of the command line
+func addChangeCauseFlag(cmd *cobra.Command, changeCause *string) {
+	cmd.Flags().StringVar(changeCause, "change-cause", *changeCause, "The message to record in the kubernetes.io/change-cause annotation of the resource. Implies --record.")
+}
+
+// completeRecordFlags returns the Recorder for recordFlags. If changeCause is set, it is recorded
+// instead of the current command line, regardless of --record.
+func completeRecordFlags(recordFlags *genericclioptions.RecordFlags, cmd *cobra.Command, changeCause string) (genericclioptions.Recorder, error) {
+	if len(changeCause) == 0 {
+		recordFlags.Complete(cmd)
+		return recordFlags.ToRecorder()
+	}
+	record := true
+	recordFlags.Record = &record
+	recordFlags.CompleteWithChangeCause(changeCause)
+	return recordFlags.ToRecorder()
+}
@@ -48,6 +48,7 @@ func NewCmdSet(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Co
 	cmd.AddCommand(NewCmdSubject(f, streams))
 	cmd.AddCommand(NewCmdServiceAccount(f, streams))
 	cmd.AddCommand(NewCmdEnv(f, streams))
+	cmd.AddCommand(NewCmdUpdateStrategy(f, streams))
 
 	return cmd
 }
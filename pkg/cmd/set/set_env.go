@@ -37,6 +37,7 @@ import (
 	"k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog"
 	envutil "k8s.io/kubectl/pkg/cmd/set/env"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
@@ -46,7 +47,7 @@ import (
 var (
 	validEnvNameRegexp = regexp.MustCompile("[^a-zA-Z0-9_]")
 	envResources       = `
-  	pod (po), replicationcontroller (rc), deployment (deploy), daemonset (ds), job, replicaset (rs), cloneset (cs), advanced statefulset (asts)`
+  	pod (po), replicationcontroller (rc), deployment (deploy), daemonset (ds), job, replicaset (rs), cloneset (cs), advanced statefulset (asts), advanced daemonset (daemonset.apps.kruise.io), sidecarset, uniteddeployment (ud)`
 
 	envLong = templates.LongDesc(`
 		Update environment variables on a pod template.
@@ -61,7 +62,11 @@ var (
 		syntax.
 
 		Possible resources include (case insensitive):
-		` + envResources)
+		` + envResources + `
+
+		With --subset the environment is only updated in one subset of a
+		UnitedDeployment, by adding the change to the patch of the subset instead of
+		the template shared by all subsets.`)
 
 	envExample = templates.Examples(`
 		# Update deployment 'registry' with a new environment variable
@@ -71,12 +76,17 @@ var (
 		kubectl-kruise set env  cloneset/registry STORAGE_DIR=/local
 
 		# Update advanced statefulset 'registry' with a new environment variable
-		kubectl-kruise set env asts/registry STORAGE_DIR=/local`)
+		kubectl-kruise set env asts/registry STORAGE_DIR=/local
+
+		# Update the subset 'zone-a' of the uniteddeployment 'registry' only with a new environment variable
+		kubectl-kruise set env uniteddeployment/registry --subset=zone-a STORAGE_DIR=/local`)
 )
 
 // EnvOptions holds values for 'set env' command-lone options
 type EnvOptions struct {
-	PrintFlags *genericclioptions.PrintFlags
+	PrintFlags  *genericclioptions.PrintFlags
+	RecordFlags *genericclioptions.RecordFlags
+	ChangeCause string
 	resource.FilenameOptions
 
 	EnvParams         []string
@@ -89,9 +99,11 @@ type EnvOptions struct {
 	Selector          string
 	From              string
 	Prefix            string
+	Subset            string
 	Keys              []string
 
 	PrintObj printers.ResourcePrinterFunc
+	Recorder genericclioptions.Recorder
 
 	envArgs                []string
 	resources              []string
@@ -111,7 +123,10 @@ type EnvOptions struct {
 // pod templates are selected by default and allowing environment to be overwritten
 func NewEnvOptions(streams genericclioptions.IOStreams) *EnvOptions {
 	return &EnvOptions{
-		PrintFlags: genericclioptions.NewPrintFlags("env updated").WithTypeSetter(scheme.Scheme),
+		PrintFlags:  genericclioptions.NewPrintFlags("env updated").WithTypeSetter(scheme.Scheme),
+		RecordFlags: genericclioptions.NewRecordFlags(),
+
+		Recorder: genericclioptions.NoopRecorder{},
 
 		ContainerSelector: "*",
 		Overwrite:         true,
@@ -147,9 +162,12 @@ func NewCmdEnv(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Co
 	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter on")
 	cmd.Flags().BoolVar(&o.Local, "local", o.Local, "If true, set env will NOT contact api-server but run locally.")
 	cmd.Flags().BoolVar(&o.All, "all", o.All, "If true, select all resources in the namespace of the specified resource types")
+	cmd.Flags().StringVar(&o.Subset, "subset", o.Subset, "The subset of a UnitedDeployment to update the environment in, through the patch of the subset.")
 	cmd.Flags().BoolVar(&o.Overwrite, "overwrite", o.Overwrite, "If true, allow environment to be overwritten, otherwise reject updates that overwrite existing environment.")
 
 	o.PrintFlags.AddFlags(cmd)
+	o.RecordFlags.AddFlags(cmd)
+	addChangeCauseFlag(cmd, &o.ChangeCause)
 
 	cmdutil.AddDryRunFlag(cmd)
 	return cmd
@@ -192,9 +210,17 @@ func (o *EnvOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []stri
 		return fmt.Errorf("all resources must be specified before environment changes: %s", strings.Join(args, " "))
 	}
 
+	var err error
+	o.Recorder, err = completeRecordFlags(o.RecordFlags, cmd, o.ChangeCause)
+	if err != nil {
+		return err
+	}
+
 	o.updatePodSpecForObject = internalpolymorphichelpers.UpdatePodSpecForObjectFn
+	if len(o.Subset) > 0 {
+		o.updatePodSpecForObject = internalpolymorphichelpers.UpdateSubsetPodSpecForObject(o.Subset)
+	}
 	o.output = cmdutil.GetFlagString(cmd, "output")
-	var err error
 	o.dryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
 	if err != nil {
 		return err
@@ -322,8 +348,14 @@ func (o *EnvOptions) RunEnv() error {
 		}
 	}
 
-	b := o.builder().
-		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+	b := o.builder()
+	if len(o.Subset) > 0 {
+		// subset patches are dropped when decoding a UnitedDeployment into its Go type
+		b = b.Unstructured()
+	} else {
+		b = b.WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...)
+	}
+	b = b.
 		LocalParam(o.Local).
 		ContinueOnError().
 		NamespaceParam(o.namespace).DefaultNamespace().
@@ -447,10 +479,15 @@ func (o *EnvOptions) RunEnv() error {
 			return nil
 		})
 
-		if err == nil {
-			return runtime.Encode(scheme.DefaultJSONEncoder(), obj)
+		if err != nil {
+			return nil, err
+		}
+		// record this change (for rollout history)
+		if err := o.Recorder.Record(obj); err != nil {
+			klog.V(4).Infof("error recording current command: %v", err)
 		}
-		return nil, err
+
+		return runtime.Encode(scheme.DefaultJSONEncoder(), obj)
 	})
 
 	if o.List {
@@ -45,6 +45,7 @@ type SetImageOptions struct {
 
 	PrintFlags  *genericclioptions.PrintFlags
 	RecordFlags *genericclioptions.RecordFlags
+	ChangeCause string
 
 	Infos          []*resource.Info
 	Selector       string
@@ -53,6 +54,7 @@ type SetImageOptions struct {
 	All            bool
 	Output         string
 	Local          bool
+	Subset         string
 	ResolveImage   ImageResolver
 
 	PrintObj printers.ResourcePrinterFunc
@@ -67,13 +69,17 @@ type SetImageOptions struct {
 
 var (
 	imageResources = `
-  	pod (po), replicationcontroller (rc), deployment (deploy), daemonset (ds), replicaset (rs), cloneset (cs), advanced statefulset (asts)`
+  	pod (po), replicationcontroller (rc), deployment (deploy), daemonset (ds), replicaset (rs), cloneset (cs), advanced statefulset (asts), advanced daemonset (daemonset.apps.kruise.io), sidecarset, uniteddeployment (ud)`
 
 	imageLong = templates.LongDesc(`
 		Update existing container image(s) of resources.
 
 		Possible resources include (case insensitive):
-		` + imageResources)
+		` + imageResources + `
+
+		With --subset the image is only updated in one subset of a UnitedDeployment, by
+		adding the change to the patch of the subset instead of the template shared by
+		all subsets.`)
 
 	imageExample = templates.Examples(`
 		# Set a deployment's nginx container image to 'nginx:1.9.1', and its busybox container image to 'busybox'.
@@ -85,6 +91,14 @@ var (
 		# Set a asts's nginx container image to 'nginx:1.9.1' and its busybox container image to 'busybox'
 		kubectl-kruise set image asts/nginx busybox=busybox nginx=nginx:1.9.1
 
+		# Set an advanced daemonset's nginx container image to 'nginx:1.9.1'
+		kubectl-kruise set image daemonset.apps.kruise.io/nginx nginx=nginx:1.9.1
+
+		# Update the image of the sidecar container 'agent' of the sidecarset 'log-agent'
+		kubectl-kruise set image sidecarset/log-agent agent=log-agent:2.0
+
+		# Set the nginx container image to 'nginx:1.9.1' in the subset 'zone-a' of the uniteddeployment 'web' only
+		kubectl-kruise set image uniteddeployment/web nginx=nginx:1.9.1 --subset=zone-a
 
 		# Print result (in yaml format) of updating nginx container image from local file, without hitting the server
 		kubectl-kruise set image -f path/to/file.yaml nginx=nginx:1.9.1 --local -o yaml`)
@@ -121,12 +135,14 @@ func NewCmdImage(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.
 
 	o.PrintFlags.AddFlags(cmd)
 	o.RecordFlags.AddFlags(cmd)
+	addChangeCauseFlag(cmd, &o.ChangeCause)
 
 	usage := "identifying the resource to get from a server."
 	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
 	cmd.Flags().BoolVar(&o.All, "all", o.All, "Select all resources, including uninitialized ones, in the namespace of the specified resource types")
 	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter on, not including uninitialized ones, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
 	cmd.Flags().BoolVar(&o.Local, "local", o.Local, "If true, set image will NOT contact api-server but run locally.")
+	cmd.Flags().StringVar(&o.Subset, "subset", o.Subset, "The subset of a UnitedDeployment to update the image in, through the patch of the subset.")
 	cmdutil.AddDryRunFlag(cmd)
 	return cmd
 }
@@ -135,13 +151,15 @@ func NewCmdImage(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.
 func (o *SetImageOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	var err error
 
-	o.RecordFlags.Complete(cmd)
-	o.Recorder, err = o.RecordFlags.ToRecorder()
+	o.Recorder, err = completeRecordFlags(o.RecordFlags, cmd, o.ChangeCause)
 	if err != nil {
 		return err
 	}
 
 	o.UpdatePodSpecForObject = internalpolymorphichelpers.UpdatePodSpecForObjectFn
+	if len(o.Subset) > 0 {
+		o.UpdatePodSpecForObject = internalpolymorphichelpers.UpdateSubsetPodSpecForObject(o.Subset)
+	}
 	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
 	if err != nil {
 		return err
@@ -176,8 +194,14 @@ func (o *SetImageOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args [
 		return err
 	}
 
-	builder := f.NewBuilder().
-		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+	builder := f.NewBuilder()
+	if len(o.Subset) > 0 {
+		// subset patches are dropped when decoding a UnitedDeployment into its Go type
+		builder = builder.Unstructured()
+	} else {
+		builder = builder.WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...)
+	}
+	builder = builder.
 		LocalParam(o.Local).
 		ContinueOnError().
 		NamespaceParam(cmdNamespace).DefaultNamespace().
@@ -17,12 +17,14 @@ limitations under the License.
 package set
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"testing"
 
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
 	"github.com/stretchr/testify/assert"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -32,6 +34,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -774,3 +777,58 @@ func TestSetImageRemoteWithSpecificContainers(t *testing.T) {
 		})
 	}
 }
+
+func TestSetImageSubsetPatchHasResourceVersion(t *testing.T) {
+	ud := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "UnitedDeployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "test", "resourceVersion": "42"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"cloneSetTemplate": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": []interface{}{
+									map[string]interface{}{"name": "nginx", "image": "nginx:1.19"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"topology": map[string]interface{}{
+				"subsets": []interface{}{
+					map[string]interface{}{"name": "zone-a", "replicas": int64(3)},
+					map[string]interface{}{"name": "zone-b", "replicas": int64(2)},
+				},
+			},
+		},
+	}}
+
+	patch := &Patch{Info: &resource.Info{Object: ud}}
+	CalculatePatch(patch, scheme.DefaultJSONEncoder(), func(obj runtime.Object) ([]byte, error) {
+		_, err := internalpolymorphichelpers.UpdateSubsetPodSpecForObject("zone-a")(obj, func(spec *corev1.PodSpec) error {
+			setImage(spec.Containers, "nginx", "nginx:1.20")
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return runtime.Encode(scheme.DefaultJSONEncoder(), obj)
+	})
+	if patch.Err != nil {
+		t.Fatalf("unexpected error: %v", patch.Err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(patch.Patch, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rv, _, _ := unstructured.NestedString(got, "metadata", "resourceVersion"); rv != "42" {
+		t.Errorf("expected the patch to be pinned to resourceVersion 42, got %s", patch.Patch)
+	}
+	if subsets, _, _ := unstructured.NestedSlice(got, "spec", "topology", "subsets"); len(subsets) != 2 {
+		t.Errorf("expected the patch to replace both subsets, got %s", patch.Patch)
+	}
+}
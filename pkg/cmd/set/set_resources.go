@@ -41,7 +41,7 @@ import (
 
 var (
 	setresourcesResouces = `
-	replicationcontroller (rc), deployment (deploy), daemonset (ds), job, replicaset (rs), statefulset, cloneset (cs), advanced statefulset (asts)`
+	replicationcontroller (rc), deployment (deploy), daemonset (ds), job, replicaset (rs), statefulset, cloneset (cs), advanced statefulset (asts), advanced daemonset (daemonset.apps.kruise.io), sidecarset`
 
 	resourcesLong = templates.LongDesc(`
 		Specify compute resource requirements (cpu, memory) for any resource that defines a pod template.  If a pod is successfully scheduled, it is guaranteed the amount of resource requested, but may burst up to its specified limits.
@@ -71,6 +71,7 @@ type SetResourcesOptions struct {
 
 	PrintFlags  *genericclioptions.PrintFlags
 	RecordFlags *genericclioptions.RecordFlags
+	ChangeCause string
 
 	Infos             []*resource.Info
 	Selector          string
@@ -129,6 +130,7 @@ func NewCmdResources(f cmdutil.Factory, streams genericclioptions.IOStreams) *co
 
 	o.PrintFlags.AddFlags(cmd)
 	o.RecordFlags.AddFlags(cmd)
+	addChangeCauseFlag(cmd, &o.ChangeCause)
 
 	//usage := "Filename, directory, or URL to a file identifying the resource to get from the server"
 	//kubectl.AddJsonFilenameFlag(cmd, &options.Filenames, usage)
@@ -148,8 +150,7 @@ func NewCmdResources(f cmdutil.Factory, streams genericclioptions.IOStreams) *co
 func (o *SetResourcesOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
 	var err error
 
-	o.RecordFlags.Complete(cmd)
-	o.Recorder, err = o.RecordFlags.ToRecorder()
+	o.Recorder, err = completeRecordFlags(o.RecordFlags, cmd, o.ChangeCause)
 	if err != nil {
 		return err
 	}
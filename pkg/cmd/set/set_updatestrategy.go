@@ -0,0 +1,560 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package set
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	appspub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"github.com/spf13/cobra"
+
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/klog"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	updateStrategyLong = templates.LongDesc(`
+		Update the update strategy of Kruise workloads.
+
+		For a CloneSet the update type (ReCreate, InPlaceIfPossible or InPlaceOnly),
+		maxUnavailable, maxSurge, partition, priorityStrategy, scatterStrategy and the
+		grace period of in-place updates can be set.
+
+		For an Advanced StatefulSet the podUpdatePolicy, maxUnavailable and minReadySeconds
+		of the rolling update can be set.
+
+		The merge patch computed for every resource is printed before it is applied.`)
+
+	updateStrategyExample = templates.Examples(`
+		# Update the pods of the cloneset 'nginx' in-place if possible, 20% at a time
+		kubectl-kruise set update-strategy cloneset/nginx --type=InPlaceIfPossible --max-unavailable=20%
+
+		# Keep 3 pods of the cloneset 'nginx' at the old revision and surge one pod while updating
+		kubectl-kruise set update-strategy cloneset/nginx --partition=3 --max-surge=1
+
+		# Update the pods of the cloneset 'nginx' in the order of the 'priority' label, spreading them across zones
+		kubectl-kruise set update-strategy cloneset/nginx --priority-order-key=priority --scatter=zone=a --scatter=zone=b
+
+		# Update the pods labeled 'tier=canary' of the cloneset 'nginx' first
+		kubectl-kruise set update-strategy cloneset/nginx --priority-weight=100:tier=canary
+
+		# Update the pods of the advanced statefulset 'web' in-place, waiting 10 seconds after each pod is ready
+		kubectl-kruise set update-strategy asts/web --pod-update-policy=InPlaceOnly --min-ready-seconds=10
+
+		# Print the result (in yaml format) of updating a cloneset from a local file, without hitting the server
+		kubectl-kruise set update-strategy -f cloneset.yaml --type=InPlaceOnly --local -o yaml`)
+)
+
+// SetUpdateStrategyOptions is the start of the data required to perform the operation. As new fields are added, add them here instead of
+// referencing the cmd.Flags()
+type SetUpdateStrategyOptions struct {
+	resource.FilenameOptions
+
+	PrintFlags  *genericclioptions.PrintFlags
+	RecordFlags *genericclioptions.RecordFlags
+	ChangeCause string
+
+	Infos          []*resource.Info
+	Selector       string
+	DryRunStrategy cmdutil.DryRunStrategy
+	DryRunVerifier *resource.DryRunVerifier
+	All            bool
+	Output         string
+	Local          bool
+	Resources      []string
+
+	Type               string
+	MaxUnavailable     string
+	MaxSurge           string
+	Partition          string
+	PriorityOrderKeys  []string
+	PriorityWeights    []string
+	Scatter            []string
+	GracePeriodSeconds *int32
+	PodUpdatePolicy    string
+	MinReadySeconds    *int32
+
+	maxUnavailable   *intstr.IntOrString
+	maxSurge         *intstr.IntOrString
+	partition        *intstr.IntOrString
+	priorityStrategy *appspub.UpdatePriorityStrategy
+	scatterStrategy  kruiseappsv1alpha1.UpdateScatterStrategy
+
+	PrintObj printers.ResourcePrinterFunc
+	Recorder genericclioptions.Recorder
+
+	genericclioptions.IOStreams
+}
+
+// NewUpdateStrategyOptions returns an initialized SetUpdateStrategyOptions instance
+func NewUpdateStrategyOptions(streams genericclioptions.IOStreams) *SetUpdateStrategyOptions {
+	return &SetUpdateStrategyOptions{
+		PrintFlags:  genericclioptions.NewPrintFlags("update strategy updated").WithTypeSetter(internalclient.Scheme),
+		RecordFlags: genericclioptions.NewRecordFlags(),
+
+		Recorder: genericclioptions.NoopRecorder{},
+
+		IOStreams: streams,
+	}
+}
+
+// NewCmdUpdateStrategy returns an initialized Command instance for the 'set update-strategy' sub command
+func NewCmdUpdateStrategy(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := NewUpdateStrategyOptions(streams)
+
+	var gracePeriodSeconds, minReadySeconds int32
+	cmd := &cobra.Command{
+		Use:                   "update-strategy (-f FILENAME | TYPE NAME) [flags]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Update the update strategy of a cloneset or advanced statefulset"),
+		Long:                  updateStrategyLong,
+		Example:               updateStrategyExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			if cmd.Flags().Changed("grace-period-seconds") {
+				o.GracePeriodSeconds = &gracePeriodSeconds
+			}
+			if cmd.Flags().Changed("min-ready-seconds") {
+				o.MinReadySeconds = &minReadySeconds
+			}
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+	o.RecordFlags.AddFlags(cmd)
+	addChangeCauseFlag(cmd, &o.ChangeCause)
+
+	usage := "identifying the resource to get from a server."
+	cmdutil.AddFilenameOptionFlags(cmd, &o.FilenameOptions, usage)
+	cmd.Flags().BoolVar(&o.All, "all", o.All, "Select all resources, including uninitialized ones, in the namespace of the specified resource types")
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter on, not including uninitialized ones, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&o.Local, "local", o.Local, "If true, set update-strategy will NOT contact api-server but run locally.")
+	cmd.Flags().StringVar(&o.Type, "type", o.Type, "The update type of a cloneset, one of: ReCreate, InPlaceIfPossible, InPlaceOnly.")
+	cmd.Flags().StringVar(&o.MaxUnavailable, "max-unavailable", o.MaxUnavailable, "The maximum number (e.g. 3) or percentage (e.g. 20%) of pods that can be unavailable during the update.")
+	cmd.Flags().StringVar(&o.MaxSurge, "max-surge", o.MaxSurge, "The maximum number or percentage of pods of a cloneset that can be created over the desired replicas during the update.")
+	cmd.Flags().StringVar(&o.Partition, "partition", o.Partition, "The number or percentage of pods of a cloneset that should be kept at the old revision.")
+	cmd.Flags().StringArrayVar(&o.PriorityOrderKeys, "priority-order-key", o.PriorityOrderKeys, "A pod label whose integer value orders the update of a cloneset, highest first. Can be repeated.")
+	cmd.Flags().StringArrayVar(&o.PriorityWeights, "priority-weight", o.PriorityWeights, "A WEIGHT:SELECTOR term (e.g. 50:app=canary) weighting the update order of the selected pods of a cloneset. Can be repeated.")
+	cmd.Flags().StringArrayVar(&o.Scatter, "scatter", o.Scatter, "A KEY=VALUE pod label that should be scattered across the update of a cloneset. Can be repeated.")
+	cmd.Flags().Int32Var(&gracePeriodSeconds, "grace-period-seconds", gracePeriodSeconds, "The seconds between marking a pod of a cloneset not ready and updating its image in-place.")
+	cmd.Flags().StringVar(&o.PodUpdatePolicy, "pod-update-policy", o.PodUpdatePolicy, "The pod update policy of an advanced statefulset, one of: ReCreate, InPlaceIfPossible, InPlaceOnly.")
+	cmd.Flags().Int32Var(&minReadySeconds, "min-ready-seconds", minReadySeconds, "The seconds an updated pod of an advanced statefulset should be ready before the next pod is updated.")
+	cmdutil.AddDryRunFlag(cmd)
+	return cmd
+}
+
+// Complete completes all required options
+func (o *SetUpdateStrategyOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	var err error
+
+	o.Recorder, err = completeRecordFlags(o.RecordFlags, cmd, o.ChangeCause)
+	if err != nil {
+		return err
+	}
+
+	o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := f.DynamicClient()
+	if err != nil {
+		return err
+	}
+	discoveryClient, err := f.ToDiscoveryClient()
+	if err != nil {
+		return err
+	}
+	o.DryRunVerifier = resource.NewDryRunVerifier(dynamicClient, discoveryClient)
+	o.Output = cmdutil.GetFlagString(cmd, "output")
+
+	cmdutil.PrintFlagsWithDryRunStrategy(o.PrintFlags, o.DryRunStrategy)
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		return err
+	}
+	o.PrintObj = printer.PrintObj
+
+	cmdNamespace, enforceNamespace, err := f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+
+	o.Resources = args
+	builder := f.NewBuilder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		LocalParam(o.Local).
+		ContinueOnError().
+		NamespaceParam(cmdNamespace).DefaultNamespace().
+		FilenameParam(enforceNamespace, &o.FilenameOptions).
+		Flatten()
+
+	if !o.Local {
+		builder.LabelSelectorParam(o.Selector).
+			ResourceTypeOrNameArgs(o.All, o.Resources...).
+			Latest()
+	} else if len(o.Resources) > 0 {
+		return resource.LocalResourceError
+	}
+
+	o.Infos, err = builder.Do().Infos()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate makes sure provided values in SetUpdateStrategyOptions are valid
+func (o *SetUpdateStrategyOptions) Validate() error {
+	errors := []error{}
+	if o.All && len(o.Selector) > 0 {
+		errors = append(errors, fmt.Errorf("cannot set --all and --selector at the same time"))
+	}
+	if len(o.Resources) < 1 && cmdutil.IsFilenameSliceEmpty(o.Filenames, o.Kustomize) {
+		errors = append(errors, fmt.Errorf("one or more resources must be specified as <resource> <name> or <resource>/<name>"))
+	}
+	if o.Local && o.DryRunStrategy == cmdutil.DryRunServer {
+		errors = append(errors, fmt.Errorf("cannot specify --local and --dry-run=server - did you mean --dry-run=client?"))
+	}
+	if !o.hasCloneSetFields() && !o.hasStatefulSetFields() && len(o.MaxUnavailable) == 0 {
+		errors = append(errors, fmt.Errorf("at least one update strategy field is required"))
+	}
+
+	if len(o.Type) > 0 && !isPodUpdateType(o.Type) {
+		errors = append(errors, fmt.Errorf("invalid --type %q, must be one of: ReCreate, InPlaceIfPossible, InPlaceOnly", o.Type))
+	}
+	if len(o.PodUpdatePolicy) > 0 && !isPodUpdateType(o.PodUpdatePolicy) {
+		errors = append(errors, fmt.Errorf("invalid --pod-update-policy %q, must be one of: ReCreate, InPlaceIfPossible, InPlaceOnly", o.PodUpdatePolicy))
+	}
+
+	var err error
+	if o.maxUnavailable, err = parseIntOrPercent("max-unavailable", o.MaxUnavailable); err != nil {
+		errors = append(errors, err)
+	}
+	if o.maxSurge, err = parseIntOrPercent("max-surge", o.MaxSurge); err != nil {
+		errors = append(errors, err)
+	}
+	if o.partition, err = parseIntOrPercent("partition", o.Partition); err != nil {
+		errors = append(errors, err)
+	}
+
+	if o.priorityStrategy, err = parsePriorityStrategy(o.PriorityOrderKeys, o.PriorityWeights); err != nil {
+		errors = append(errors, err)
+	}
+	if o.scatterStrategy, err = parseScatterStrategy(o.Scatter); err != nil {
+		errors = append(errors, err)
+	}
+
+	if o.GracePeriodSeconds != nil && *o.GracePeriodSeconds < 0 {
+		errors = append(errors, fmt.Errorf("--grace-period-seconds must not be negative"))
+	}
+	if o.MinReadySeconds != nil && (*o.MinReadySeconds < 0 || *o.MinReadySeconds > kruiseappsv1beta1.MaxMinReadySeconds) {
+		errors = append(errors, fmt.Errorf("--min-ready-seconds must be in the range 0-%d", kruiseappsv1beta1.MaxMinReadySeconds))
+	}
+	return utilerrors.NewAggregate(errors)
+}
+
+// Run performs the execution of 'set update-strategy' sub command
+func (o *SetUpdateStrategyOptions) Run() error {
+	allErrs := []error{}
+
+	patches := CalculatePatches(o.Infos, scheme.DefaultJSONEncoder(), func(obj runtime.Object) ([]byte, error) {
+		if err := o.updateStrategyForObject(obj); err != nil {
+			return nil, err
+		}
+		// record this change (for rollout history)
+		if err := o.Recorder.Record(obj); err != nil {
+			klog.V(4).Infof("error recording current command: %v", err)
+		}
+
+		return runtime.Encode(scheme.DefaultJSONEncoder(), obj)
+	})
+
+	for _, patch := range patches {
+		info := patch.Info
+		name := info.ObjectName()
+		if patch.Err != nil {
+			allErrs = append(allErrs, fmt.Errorf("error: %s %v\n", name, patch.Err))
+			continue
+		}
+
+		// no changes
+		if string(patch.Patch) == "{}" || len(patch.Patch) == 0 {
+			continue
+		}
+
+		// keep the printed object parseable when an output format is requested
+		patchOut := o.Out
+		if len(o.Output) > 0 {
+			patchOut = o.ErrOut
+		}
+		fmt.Fprintf(patchOut, "%s patch: %s\n", name, patch.Patch)
+
+		if o.Local || o.DryRunStrategy == cmdutil.DryRunClient {
+			if err := o.PrintObj(info.Object, o.Out); err != nil {
+				allErrs = append(allErrs, err)
+			}
+			continue
+		}
+
+		if o.DryRunStrategy == cmdutil.DryRunServer {
+			if err := o.DryRunVerifier.HasSupport(info.Mapping.GroupVersionKind); err != nil {
+				return err
+			}
+		}
+		actual, err := resource.
+			NewHelper(info.Client, info.Mapping).
+			DryRun(o.DryRunStrategy == cmdutil.DryRunServer).
+			Patch(info.Namespace, info.Name, types.MergePatchType, patch.Patch, nil)
+		if err != nil {
+			allErrs = append(allErrs, fmt.Errorf("failed to patch update strategy: %v", err))
+			continue
+		}
+
+		if err := o.PrintObj(actual, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// updateStrategyForObject sets the requested update strategy fields on a CloneSet or an Advanced StatefulSet.
+// Fields that the kind of the object does not have are rejected instead of being ignored.
+func (o *SetUpdateStrategyOptions) updateStrategyForObject(obj runtime.Object) error {
+	switch t := obj.(type) {
+	case *kruiseappsv1alpha1.CloneSet:
+		if err := rejectFields("a cloneset", map[string]bool{
+			"pod-update-policy": len(o.PodUpdatePolicy) > 0,
+			"min-ready-seconds": o.MinReadySeconds != nil,
+		}); err != nil {
+			return err
+		}
+		o.updateCloneSetStrategy(&t.Spec.UpdateStrategy)
+		return nil
+
+	case *kruiseappsv1beta1.StatefulSet:
+		if err := o.rejectStatefulSetFields(t.Spec.UpdateStrategy.Type); err != nil {
+			return err
+		}
+		if t.Spec.UpdateStrategy.RollingUpdate == nil {
+			t.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{}
+		}
+		rollingUpdate := t.Spec.UpdateStrategy.RollingUpdate
+		if len(o.PodUpdatePolicy) > 0 {
+			rollingUpdate.PodUpdatePolicy = kruiseappsv1beta1.PodUpdateStrategyType(o.PodUpdatePolicy)
+		}
+		if o.maxUnavailable != nil {
+			rollingUpdate.MaxUnavailable = o.maxUnavailable
+		}
+		if o.MinReadySeconds != nil {
+			rollingUpdate.MinReadySeconds = o.MinReadySeconds
+		}
+		return nil
+
+	case *kruiseappsv1alpha1.StatefulSet:
+		if err := o.rejectStatefulSetFields(t.Spec.UpdateStrategy.Type); err != nil {
+			return err
+		}
+		if t.Spec.UpdateStrategy.RollingUpdate == nil {
+			t.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1alpha1.RollingUpdateStatefulSetStrategy{}
+		}
+		rollingUpdate := t.Spec.UpdateStrategy.RollingUpdate
+		if len(o.PodUpdatePolicy) > 0 {
+			rollingUpdate.PodUpdatePolicy = kruiseappsv1alpha1.PodUpdateStrategyType(o.PodUpdatePolicy)
+		}
+		if o.maxUnavailable != nil {
+			rollingUpdate.MaxUnavailable = o.maxUnavailable
+		}
+		if o.MinReadySeconds != nil {
+			rollingUpdate.MinReadySeconds = o.MinReadySeconds
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("setting the update strategy is only supported for cloneset and advanced statefulset, not %s", obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+}
+
+func (o *SetUpdateStrategyOptions) updateCloneSetStrategy(strategy *kruiseappsv1alpha1.CloneSetUpdateStrategy) {
+	if len(o.Type) > 0 {
+		strategy.Type = kruiseappsv1alpha1.CloneSetUpdateStrategyType(o.Type)
+	}
+	if o.maxUnavailable != nil {
+		strategy.MaxUnavailable = o.maxUnavailable
+	}
+	if o.maxSurge != nil {
+		strategy.MaxSurge = o.maxSurge
+	}
+	if o.partition != nil {
+		strategy.Partition = o.partition
+	}
+	if o.priorityStrategy != nil {
+		strategy.PriorityStrategy = o.priorityStrategy
+	}
+	if len(o.scatterStrategy) > 0 {
+		strategy.ScatterStrategy = o.scatterStrategy
+	}
+	if o.GracePeriodSeconds != nil {
+		if strategy.InPlaceUpdateStrategy == nil {
+			strategy.InPlaceUpdateStrategy = &appspub.InPlaceUpdateStrategy{}
+		}
+		strategy.InPlaceUpdateStrategy.GracePeriodSeconds = *o.GracePeriodSeconds
+	}
+}
+
+func (o *SetUpdateStrategyOptions) rejectStatefulSetFields(updateType apps.StatefulSetUpdateStrategyType) error {
+	if err := rejectFields("an advanced statefulset", map[string]bool{
+		"type":                 len(o.Type) > 0,
+		"max-surge":            len(o.MaxSurge) > 0,
+		"partition":            len(o.Partition) > 0,
+		"priority-order-key":   len(o.PriorityOrderKeys) > 0,
+		"priority-weight":      len(o.PriorityWeights) > 0,
+		"scatter":              len(o.Scatter) > 0,
+		"grace-period-seconds": o.GracePeriodSeconds != nil,
+	}); err != nil {
+		return err
+	}
+	if updateType == apps.OnDeleteStatefulSetStrategyType {
+		return fmt.Errorf("the rolling update of an advanced statefulset with the %s update strategy type can not be set", updateType)
+	}
+	return nil
+}
+
+func rejectFields(kind string, fields map[string]bool) error {
+	var names []string
+	for name, set := range fields {
+		if set {
+			names = append(names, "--"+name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+	return fmt.Errorf("%s can not be set for %s", strings.Join(names, ", "), kind)
+}
+
+func (o *SetUpdateStrategyOptions) hasCloneSetFields() bool {
+	return len(o.Type) > 0 || len(o.MaxSurge) > 0 || len(o.Partition) > 0 ||
+		len(o.PriorityOrderKeys) > 0 || len(o.PriorityWeights) > 0 || len(o.Scatter) > 0 ||
+		o.GracePeriodSeconds != nil
+}
+
+func (o *SetUpdateStrategyOptions) hasStatefulSetFields() bool {
+	return len(o.PodUpdatePolicy) > 0 || o.MinReadySeconds != nil
+}
+
+func isPodUpdateType(t string) bool {
+	switch kruiseappsv1alpha1.CloneSetUpdateStrategyType(t) {
+	case kruiseappsv1alpha1.RecreateCloneSetUpdateStrategyType,
+		kruiseappsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+		kruiseappsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType:
+		return true
+	}
+	return false
+}
+
+// parseIntOrPercent parses a non-negative number or a percentage in the range 0%-100%.
+// An empty value means the flag was not set.
+func parseIntOrPercent(flag, value string) (*intstr.IntOrString, error) {
+	if len(value) == 0 {
+		return nil, nil
+	}
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, fmt.Errorf("invalid --%s %q, must be a non-negative number or a percentage between 0%% and 100%%", flag, value)
+		}
+		v := intstr.FromString(value)
+		return &v, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid --%s %q, must be a non-negative number or a percentage between 0%% and 100%%", flag, value)
+	}
+	v := intstr.FromInt(n)
+	return &v, nil
+}
+
+// parsePriorityStrategy builds the priority strategy of a cloneset from ordered keys and WEIGHT:SELECTOR terms.
+func parsePriorityStrategy(orderKeys, weights []string) (*appspub.UpdatePriorityStrategy, error) {
+	if len(orderKeys) == 0 && len(weights) == 0 {
+		return nil, nil
+	}
+	strategy := &appspub.UpdatePriorityStrategy{}
+	for _, key := range orderKeys {
+		strategy.OrderPriority = append(strategy.OrderPriority, appspub.UpdatePriorityOrderTerm{OrderedKey: key})
+	}
+	for _, term := range weights {
+		parts := strings.SplitN(term, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --priority-weight %q, expected WEIGHT:SELECTOR", term)
+		}
+		weight, err := strconv.ParseInt(parts[0], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --priority-weight %q, weight must be a number", term)
+		}
+		selector, err := metav1.ParseToLabelSelector(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid --priority-weight %q: %v", term, err)
+		}
+		strategy.WeightPriority = append(strategy.WeightPriority, appspub.UpdatePriorityWeightTerm{
+			Weight:        int32(weight),
+			MatchSelector: *selector,
+		})
+	}
+	if err := strategy.FieldsValidation(); err != nil {
+		return nil, fmt.Errorf("invalid priority strategy: %v", err)
+	}
+	return strategy, nil
+}
+
+// parseScatterStrategy builds the scatter strategy of a cloneset from KEY=VALUE terms.
+func parseScatterStrategy(terms []string) (kruiseappsv1alpha1.UpdateScatterStrategy, error) {
+	var strategy kruiseappsv1alpha1.UpdateScatterStrategy
+	for _, term := range terms {
+		parts := strings.SplitN(term, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid --scatter %q, expected KEY=VALUE", term)
+		}
+		strategy = append(strategy, kruiseappsv1alpha1.UpdateScatterTerm{Key: parts[0], Value: parts[1]})
+	}
+	if err := strategy.FieldsValidation(); err != nil {
+		return nil, fmt.Errorf("invalid scatter strategy: %v", err)
+	}
+	return strategy, nil
+}
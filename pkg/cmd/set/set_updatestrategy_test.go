@@ -0,0 +1,304 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package set
+
+import (
+	"strings"
+	"testing"
+
+	appspub "github.com/openkruise/kruise-api/apps/pub"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"github.com/stretchr/testify/assert"
+
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func TestSetUpdateStrategyValidation(t *testing.T) {
+	resources := []string{"cloneset/nginx"}
+
+	testCases := []struct {
+		name      string
+		options   *SetUpdateStrategyOptions
+		expectErr string
+	}{
+		{
+			name:      "no resources and no fields",
+			options:   &SetUpdateStrategyOptions{},
+			expectErr: "[one or more resources must be specified as <resource> <name> or <resource>/<name>, at least one update strategy field is required]",
+		},
+		{
+			name:      "local and server dry-run",
+			options:   &SetUpdateStrategyOptions{Resources: resources, Type: "InPlaceOnly", Local: true, DryRunStrategy: cmdutil.DryRunServer},
+			expectErr: "cannot specify --local and --dry-run=server - did you mean --dry-run=client?",
+		},
+		{
+			name:      "invalid type",
+			options:   &SetUpdateStrategyOptions{Resources: resources, Type: "RollingUpdate"},
+			expectErr: `invalid --type "RollingUpdate", must be one of: ReCreate, InPlaceIfPossible, InPlaceOnly`,
+		},
+		{
+			name:      "invalid pod update policy",
+			options:   &SetUpdateStrategyOptions{Resources: resources, PodUpdatePolicy: "inplace"},
+			expectErr: `invalid --pod-update-policy "inplace", must be one of: ReCreate, InPlaceIfPossible, InPlaceOnly`,
+		},
+		{
+			name:      "invalid max unavailable",
+			options:   &SetUpdateStrategyOptions{Resources: resources, MaxUnavailable: "-1"},
+			expectErr: `invalid --max-unavailable "-1", must be a non-negative number or a percentage between 0% and 100%`,
+		},
+		{
+			name:      "invalid max surge percentage",
+			options:   &SetUpdateStrategyOptions{Resources: resources, MaxSurge: "120%"},
+			expectErr: `invalid --max-surge "120%", must be a non-negative number or a percentage between 0% and 100%`,
+		},
+		{
+			name:      "order and weight priority together",
+			options:   &SetUpdateStrategyOptions{Resources: resources, PriorityOrderKeys: []string{"priority"}, PriorityWeights: []string{"50:app=canary"}},
+			expectErr: "invalid priority strategy: only one of weightPriority and orderPriority can be used",
+		},
+		{
+			name:      "malformed priority weight",
+			options:   &SetUpdateStrategyOptions{Resources: resources, PriorityWeights: []string{"app=canary"}},
+			expectErr: `invalid --priority-weight "app=canary", expected WEIGHT:SELECTOR`,
+		},
+		{
+			name:      "duplicated scatter term",
+			options:   &SetUpdateStrategyOptions{Resources: resources, Scatter: []string{"zone=a", "zone=a"}},
+			expectErr: "invalid scatter strategy: duplicated key=zone value=a",
+		},
+		{
+			name:      "min ready seconds too large",
+			options:   &SetUpdateStrategyOptions{Resources: resources, MinReadySeconds: int32Ptr(301)},
+			expectErr: "--min-ready-seconds must be in the range 0-300",
+		},
+		{
+			name: "success case",
+			options: &SetUpdateStrategyOptions{
+				Resources:          resources,
+				Type:               "InPlaceIfPossible",
+				MaxUnavailable:     "20%",
+				MaxSurge:           "1",
+				Partition:          "3",
+				PriorityWeights:    []string{"50:app=canary"},
+				Scatter:            []string{"zone=a", "zone=b"},
+				GracePeriodSeconds: int32Ptr(10),
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := testCase.options.Validate()
+			if len(testCase.expectErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Equal(t, testCase.expectErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestUpdateStrategyForObject(t *testing.T) {
+	maxUnavailable := intstr.FromString("20%")
+	maxSurge := intstr.FromInt(1)
+
+	testCases := []struct {
+		name      string
+		options   *SetUpdateStrategyOptions
+		object    runtime.Object
+		expected  runtime.Object
+		expectErr string
+	}{
+		{
+			name: "cloneset",
+			options: &SetUpdateStrategyOptions{
+				Type:               "InPlaceOnly",
+				MaxUnavailable:     "20%",
+				MaxSurge:           "1",
+				PriorityOrderKeys:  []string{"priority"},
+				Scatter:            []string{"zone=a"},
+				GracePeriodSeconds: int32Ptr(10),
+			},
+			object: &kruiseappsv1alpha1.CloneSet{},
+			expected: &kruiseappsv1alpha1.CloneSet{
+				Spec: kruiseappsv1alpha1.CloneSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{
+						Type:           kruiseappsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType,
+						MaxUnavailable: &maxUnavailable,
+						MaxSurge:       &maxSurge,
+						PriorityStrategy: &appspub.UpdatePriorityStrategy{
+							OrderPriority: []appspub.UpdatePriorityOrderTerm{{OrderedKey: "priority"}},
+						},
+						ScatterStrategy:       kruiseappsv1alpha1.UpdateScatterStrategy{{Key: "zone", Value: "a"}},
+						InPlaceUpdateStrategy: &appspub.InPlaceUpdateStrategy{GracePeriodSeconds: 10},
+					},
+				},
+			},
+		},
+		{
+			name:    "cloneset keeps fields that are not set",
+			options: &SetUpdateStrategyOptions{MaxSurge: "1"},
+			object: &kruiseappsv1alpha1.CloneSet{
+				Spec: kruiseappsv1alpha1.CloneSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{
+						Type:           kruiseappsv1alpha1.RecreateCloneSetUpdateStrategyType,
+						MaxUnavailable: &maxUnavailable,
+					},
+				},
+			},
+			expected: &kruiseappsv1alpha1.CloneSet{
+				Spec: kruiseappsv1alpha1.CloneSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{
+						Type:           kruiseappsv1alpha1.RecreateCloneSetUpdateStrategyType,
+						MaxUnavailable: &maxUnavailable,
+						MaxSurge:       &maxSurge,
+					},
+				},
+			},
+		},
+		{
+			name:      "cloneset rejects statefulset fields",
+			options:   &SetUpdateStrategyOptions{PodUpdatePolicy: "InPlaceOnly", MinReadySeconds: int32Ptr(10)},
+			object:    &kruiseappsv1alpha1.CloneSet{},
+			expectErr: "--min-ready-seconds, --pod-update-policy can not be set for a cloneset",
+		},
+		{
+			name:    "advanced statefulset v1beta1",
+			options: &SetUpdateStrategyOptions{PodUpdatePolicy: "InPlaceIfPossible", MaxUnavailable: "20%", MinReadySeconds: int32Ptr(10)},
+			object:  &kruiseappsv1beta1.StatefulSet{},
+			expected: &kruiseappsv1beta1.StatefulSet{
+				Spec: kruiseappsv1beta1.StatefulSetSpec{
+					UpdateStrategy: kruiseappsv1beta1.StatefulSetUpdateStrategy{
+						RollingUpdate: &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{
+							PodUpdatePolicy: kruiseappsv1beta1.InPlaceIfPossiblePodUpdateStrategyType,
+							MaxUnavailable:  &maxUnavailable,
+							MinReadySeconds: int32Ptr(10),
+						},
+					},
+				},
+			},
+		},
+		{
+			name:    "advanced statefulset v1alpha1",
+			options: &SetUpdateStrategyOptions{PodUpdatePolicy: "ReCreate"},
+			object: &kruiseappsv1alpha1.StatefulSet{
+				Spec: kruiseappsv1alpha1.StatefulSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.StatefulSetUpdateStrategy{
+						RollingUpdate: &kruiseappsv1alpha1.RollingUpdateStatefulSetStrategy{Partition: int32Ptr(2)},
+					},
+				},
+			},
+			expected: &kruiseappsv1alpha1.StatefulSet{
+				Spec: kruiseappsv1alpha1.StatefulSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.StatefulSetUpdateStrategy{
+						RollingUpdate: &kruiseappsv1alpha1.RollingUpdateStatefulSetStrategy{
+							Partition:       int32Ptr(2),
+							PodUpdatePolicy: kruiseappsv1alpha1.RecreatePodUpdateStrategyType,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "advanced statefulset rejects cloneset fields",
+			options:   &SetUpdateStrategyOptions{Type: "InPlaceOnly", MaxSurge: "1"},
+			object:    &kruiseappsv1beta1.StatefulSet{},
+			expectErr: "--max-surge, --type can not be set for an advanced statefulset",
+		},
+		{
+			name:    "advanced statefulset with OnDelete strategy",
+			options: &SetUpdateStrategyOptions{PodUpdatePolicy: "InPlaceOnly"},
+			object: &kruiseappsv1beta1.StatefulSet{
+				Spec: kruiseappsv1beta1.StatefulSetSpec{
+					UpdateStrategy: kruiseappsv1beta1.StatefulSetUpdateStrategy{Type: apps.OnDeleteStatefulSetStrategyType},
+				},
+			},
+			expectErr: "the rolling update of an advanced statefulset with the OnDelete update strategy type can not be set",
+		},
+		{
+			name:      "unsupported kind",
+			options:   &SetUpdateStrategyOptions{MaxUnavailable: "1"},
+			object:    &apps.Deployment{TypeMeta: metav1.TypeMeta{Kind: "Deployment"}},
+			expectErr: "setting the update strategy is only supported for cloneset and advanced statefulset, not Deployment",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			testCase.options.Resources = []string{"x"}
+			if !assert.NoError(t, testCase.options.Validate()) {
+				return
+			}
+			err := testCase.options.updateStrategyForObject(testCase.object)
+			if len(testCase.expectErr) > 0 {
+				if assert.Error(t, err) {
+					assert.Equal(t, testCase.expectErr, err.Error())
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, testCase.object)
+		})
+	}
+}
+
+func TestSetUpdateStrategyLocalPrintsPatch(t *testing.T) {
+	streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+	o := NewUpdateStrategyOptions(streams)
+	o.Local = true
+	o.Filenames = []string{"cloneset.yaml"}
+	o.Type = "InPlaceOnly"
+	o.Partition = "2"
+	o.Output = "name"
+	o.Infos = []*resource.Info{{
+		Name:      "nginx",
+		Namespace: "default",
+		Mapping: &meta.RESTMapping{
+			Resource: kruiseappsv1alpha1.SchemeGroupVersion.WithResource("clonesets"),
+		},
+		Object: &kruiseappsv1alpha1.CloneSet{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "apps.kruise.io/v1alpha1", Kind: "CloneSet"},
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"},
+		},
+	}}
+	if err := o.PrintFlags.Complete("%s"); err != nil {
+		t.Fatal(err)
+	}
+	o.PrintFlags.OutputFormat = &o.Output
+	printer, err := o.PrintFlags.ToPrinter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.PrintObj = printer.PrintObj
+
+	assert.NoError(t, o.Validate())
+	assert.NoError(t, o.Run())
+	assert.Equal(t, `clonesets/nginx patch: {"spec":{"updateStrategy":{"partition":2,"type":"InPlaceOnly"}}}`, strings.TrimSpace(errOut.String()))
+	assert.Equal(t, "cloneset.apps.kruise.io/nginx", strings.TrimSpace(out.String()))
+}
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
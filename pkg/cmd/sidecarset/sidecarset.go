@@ -0,0 +1,100 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"fmt"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// sidecarSetResource is the resource SidecarSet names are resolved as.
+const sidecarSetResource = "sidecarsets.apps.kruise.io"
+
+var (
+	sidecarSetLong = templates.LongDesc(`
+		Manage SidecarSets
+
+		These commands show which pods a SidecarSet injects its sidecar containers
+		into and how far the sidecars have been updated, and pause or resume their
+		update.
+
+		Use "kubectl-kruise set image sidecarset/NAME" to update the image of a
+		sidecar container in place.`)
+)
+
+// NewCmdSidecarSet returns an initialized Command instance for 'sidecarset' sub command
+func NewCmdSidecarSet(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "sidecarset SUBCOMMAND",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Manage SidecarSets"),
+		Long:                  sidecarSetLong,
+		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+
+	// add subcommands
+	cmd.AddCommand(NewCmdSidecarSetStatus(f, streams))
+	cmd.AddCommand(NewCmdSidecarSetPods(f, streams))
+	cmd.AddCommand(NewCmdSidecarSetPause(f, streams))
+	cmd.AddCommand(NewCmdSidecarSetResume(f, streams))
+
+	return cmd
+}
+
+// sidecarSetInfos returns the SidecarSets with the given names, or all SidecarSets matching
+// selector if no names are given.
+func sidecarSetInfos(builder *resource.Builder, names []string, selector string) ([]*resource.Info, error) {
+	r := builder.
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		LabelSelectorParam(selector).
+		ResourceTypeOrNameArgs(true, append([]string{sidecarSetResource}, names...)...).
+		ContinueOnError().
+		Latest().
+		Flatten().
+		Do()
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return r.Infos()
+}
+
+// toSidecarSet returns the SidecarSet held by info.
+func toSidecarSet(info *resource.Info) (*kruiseappsv1alpha1.SidecarSet, error) {
+	switch obj := info.Object.(type) {
+	case *kruiseappsv1alpha1.SidecarSet:
+		return obj, nil
+	case runtime.Unstructured:
+		sidecarSet := &kruiseappsv1alpha1.SidecarSet{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), sidecarSet); err != nil {
+			return nil, fmt.Errorf("failed to convert %T to %T: %v", obj, sidecarSet, err)
+		}
+		return sidecarSet, nil
+	default:
+		return nil, fmt.Errorf("%s %q is not a SidecarSet", info.Mapping.Resource.Resource, info.Name)
+	}
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"fmt"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/kubectl/pkg/cmd/set"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// PauseOptions holds the options for 'sidecarset pause' and 'sidecarset resume' sub commands
+type PauseOptions struct {
+	PrintFlags *genericclioptions.PrintFlags
+	ToPrinter  func(string) (printers.ResourcePrinter, error)
+
+	// Pause is true for 'sidecarset pause' and false for 'sidecarset resume'
+	Pause bool
+	Names []string
+
+	Builder func() *resource.Builder
+	PatchFn func(obj runtime.Object) ([]byte, error)
+
+	genericclioptions.IOStreams
+}
+
+var (
+	pauseLong = templates.LongDesc(`
+		Pause the update of SidecarSets.
+
+		The sidecars of pods that have not been updated yet keep running their
+		current version until the SidecarSet is resumed. New pods are still injected
+		with the latest sidecars.`)
+
+	pauseExample = templates.Examples(`
+		# Pause the update of the SidecarSet log-agent
+		kubectl-kruise sidecarset pause log-agent`)
+
+	resumeLong = templates.LongDesc(`
+		Resume the paused update of SidecarSets.`)
+
+	resumeExample = templates.Examples(`
+		# Resume the update of the SidecarSet log-agent
+		kubectl-kruise sidecarset resume log-agent`)
+)
+
+// NewCmdSidecarSetPause returns a Command instance for 'sidecarset pause' sub command
+func NewCmdSidecarSetPause(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	return newCmdPause(f, streams, true, "pause NAME...", i18n.T("Pause the update of SidecarSets"), pauseLong, pauseExample)
+}
+
+// NewCmdSidecarSetResume returns a Command instance for 'sidecarset resume' sub command
+func NewCmdSidecarSetResume(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	return newCmdPause(f, streams, false, "resume NAME...", i18n.T("Resume the update of SidecarSets"), resumeLong, resumeExample)
+}
+
+func newCmdPause(f cmdutil.Factory, streams genericclioptions.IOStreams, pause bool, use, short, long, example string) *cobra.Command {
+	o := &PauseOptions{
+		PrintFlags: genericclioptions.NewPrintFlags("").WithTypeSetter(internalclient.Scheme),
+		Pause:      pause,
+		IOStreams:  streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   use,
+		DisableFlagsInUseLine: true,
+		Short:                 short,
+		Long:                  long,
+		Example:               example,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	o.PrintFlags.AddFlags(cmd)
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *PauseOptions) Complete(f cmdutil.Factory, args []string) error {
+	o.Names = args
+	o.Builder = f.NewBuilder
+	o.PatchFn = pausedPatchFn(o.Pause)
+	o.ToPrinter = func(operation string) (printers.ResourcePrinter, error) {
+		o.PrintFlags.NamePrintFlags.Operation = operation
+		return o.PrintFlags.ToPrinter()
+	}
+	return nil
+}
+
+// Validate makes sure all the provided values for command-line options are valid
+func (o *PauseOptions) Validate() error {
+	if len(o.Names) == 0 {
+		return fmt.Errorf("required SidecarSet name not specified")
+	}
+	return nil
+}
+
+// Run performs the execution of 'sidecarset pause' and 'sidecarset resume' sub commands
+func (o *PauseOptions) Run() error {
+	var allErrs []error
+	infos, err := sidecarSetInfos(o.Builder(), o.Names, "")
+	if err != nil {
+		// keep going with the SidecarSets that could be retrieved
+		allErrs = append(allErrs, err)
+	}
+	if err := o.patchInfos(infos); err != nil {
+		allErrs = append(allErrs, err)
+	}
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// patchInfos pauses or resumes the SidecarSets held by infos, those already in that state are left
+// as they are and reported as such.
+func (o *PauseOptions) patchInfos(infos []*resource.Info) error {
+	var allErrs []error
+	done, skipped := "resumed", "not paused"
+	if o.Pause {
+		done, skipped = "paused", "already paused"
+	}
+	for _, patch := range set.CalculatePatches(infos, scheme.DefaultJSONEncoder(), set.PatchFn(o.PatchFn)) {
+		info := patch.Info
+
+		if patch.Err != nil {
+			allErrs = append(allErrs, fmt.Errorf("error: %s %q %v", sidecarSetResource, info.Name, patch.Err))
+			continue
+		}
+
+		operation := done
+		if string(patch.Patch) == "{}" || len(patch.Patch) == 0 {
+			operation = skipped
+		} else {
+			obj, err := resource.NewHelper(info.Client, info.Mapping).Patch(info.Namespace, info.Name, types.MergePatchType, patch.Patch, nil)
+			if err != nil {
+				allErrs = append(allErrs, fmt.Errorf("failed to patch: %v", err))
+				continue
+			}
+			info.Refresh(obj, true)
+		}
+
+		printer, err := o.ToPrinter(operation)
+		if err != nil {
+			allErrs = append(allErrs, err)
+			continue
+		}
+		if err = printer.PrintObj(info.Object, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+		}
+	}
+
+	return utilerrors.NewAggregate(allErrs)
+}
+
+// pausedPatchFn returns a set.PatchFn that sets whether the update of a SidecarSet is paused. A
+// SidecarSet that already is in that state is encoded unchanged, so that its patch is empty.
+func pausedPatchFn(paused bool) func(obj runtime.Object) ([]byte, error) {
+	return func(obj runtime.Object) ([]byte, error) {
+		sidecarSet, ok := obj.(*kruiseappsv1alpha1.SidecarSet)
+		if !ok {
+			return nil, fmt.Errorf("%T is not a SidecarSet", obj)
+		}
+		sidecarSet.Spec.UpdateStrategy.Paused = paused
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), sidecarSet)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/rest/fake"
+	cmdtesting "k8s.io/kubectl/pkg/cmd/testing"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+func TestPauseSidecarSets(t *testing.T) {
+	tests := []struct {
+		name        string
+		pause       bool
+		paused      bool
+		expectPatch string
+		expectOut   string
+	}{
+		{
+			name:        "pause",
+			pause:       true,
+			expectPatch: `{"spec":{"updateStrategy":{"paused":true}}}`,
+			expectOut:   "sidecarset.apps.kruise.io/log-agent paused",
+		},
+		{
+			name:      "already paused",
+			pause:     true,
+			paused:    true,
+			expectOut: "sidecarset.apps.kruise.io/log-agent already paused",
+		},
+		{
+			name:        "resume",
+			paused:      true,
+			expectPatch: `{"spec":{"updateStrategy":{"paused":null}}}`,
+			expectOut:   "sidecarset.apps.kruise.io/log-agent resumed",
+		},
+		{
+			name:      "not paused",
+			expectOut: "sidecarset.apps.kruise.io/log-agent not paused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sidecarSet := &kruiseappsv1alpha1.SidecarSet{
+				TypeMeta:   metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.GroupVersion.String(), Kind: "SidecarSet"},
+				ObjectMeta: metav1.ObjectMeta{Name: "log-agent"},
+				Spec: kruiseappsv1alpha1.SidecarSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.SidecarSetUpdateStrategy{Paused: tt.paused},
+				},
+			}
+
+			var patches []string
+			client := &fake.RESTClient{
+				GroupVersion:         kruiseappsv1alpha1.GroupVersion,
+				NegotiatedSerializer: scheme.Codecs.WithoutConversion(),
+				Client: fake.CreateHTTPClient(func(req *http.Request) (*http.Response, error) {
+					if req.Method != http.MethodPatch || req.URL.Path != "/sidecarsets/log-agent" {
+						t.Fatalf("unexpected request: %s %s", req.Method, req.URL.Path)
+					}
+					body, err := ioutil.ReadAll(req.Body)
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					patches = append(patches, string(body))
+					patched := sidecarSet.DeepCopy()
+					patched.Spec.UpdateStrategy.Paused = tt.pause
+					return &http.Response{StatusCode: http.StatusOK, Header: cmdtesting.DefaultHeader(),
+						Body: ioutil.NopCloser(bytes.NewReader([]byte(runtime.EncodeOrDie(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.GroupVersion), patched))))}, nil
+				}),
+			}
+			info := &resource.Info{
+				Client: client,
+				Mapping: &meta.RESTMapping{
+					Resource:         kruiseappsv1alpha1.GroupVersion.WithResource("sidecarsets"),
+					GroupVersionKind: kruiseappsv1alpha1.GroupVersion.WithKind("SidecarSet"),
+					Scope:            meta.RESTScopeRoot,
+				},
+				Name:   sidecarSet.Name,
+				Object: sidecarSet.DeepCopy(),
+			}
+
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			printFlags := genericclioptions.NewPrintFlags("").WithTypeSetter(internalclient.Scheme)
+			o := &PauseOptions{
+				PrintFlags: printFlags,
+				Pause:      tt.pause,
+				PatchFn:    pausedPatchFn(tt.pause),
+				ToPrinter: func(operation string) (printers.ResourcePrinter, error) {
+					printFlags.NamePrintFlags.Operation = operation
+					return printFlags.ToPrinter()
+				},
+				IOStreams: streams,
+			}
+			if err := o.patchInfos([]*resource.Info{info}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(tt.expectPatch) == 0 && len(patches) > 0 {
+				t.Errorf("expected no patch, got %v", patches)
+			}
+			if len(tt.expectPatch) > 0 && (len(patches) != 1 || patches[0] != tt.expectPatch) {
+				t.Errorf("expected patch %s, got %v", tt.expectPatch, patches)
+			}
+			if got := strings.TrimSpace(out.String()); got != tt.expectOut {
+				t.Errorf("expected output %q, got %q", tt.expectOut, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// sidecarSetHashAnnotation is set by Kruise on SidecarSets to the hash of their sidecars, and on
+// pods to the hashes of the sidecars injected by each SidecarSet.
+const sidecarSetHashAnnotation = "kruise.io/sidecarset-hash"
+
+// PodsOptions holds the options for 'sidecarset pods' sub command
+type PodsOptions struct {
+	Name       string
+	NotUpdated bool
+	NoHeaders  bool
+
+	Builder   func() *resource.Builder
+	Clientset kubernetes.Interface
+
+	genericclioptions.IOStreams
+}
+
+var (
+	podsLong = templates.LongDesc(`
+		List the pods a SidecarSet matches.
+
+		For each pod matched by the selector of the SidecarSet it is shown whether
+		the sidecars have been injected, whether they are at the latest version of
+		the SidecarSet and whether they are ready.`)
+
+	podsExample = templates.Examples(`
+		# List the pods of the SidecarSet log-agent
+		kubectl-kruise sidecarset pods log-agent
+
+		# List the pods of the SidecarSet log-agent that still run old sidecars
+		kubectl-kruise sidecarset pods log-agent --not-updated`)
+)
+
+// NewCmdSidecarSetPods returns a Command instance for 'sidecarset pods' sub command
+func NewCmdSidecarSetPods(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &PodsOptions{
+		IOStreams: streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "pods NAME",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List the pods a SidecarSet matches"),
+		Long:                  podsLong,
+		Example:               podsExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.NotUpdated, "not-updated", o.NotUpdated, "If true, only list the pods whose sidecars are not at the latest version of the SidecarSet.")
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", o.NoHeaders, "If present, print output without headers")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *PodsOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "exactly one SidecarSet name is required")
+	}
+	o.Name = args[0]
+	o.Builder = f.NewBuilder
+
+	var err error
+	o.Clientset, err = f.KubernetesClientSet()
+	return err
+}
+
+// Run performs the execution of 'sidecarset pods' sub command
+func (o *PodsOptions) Run() error {
+	infos, err := sidecarSetInfos(o.Builder(), []string{o.Name}, "")
+	if err != nil {
+		return err
+	}
+	if len(infos) != 1 {
+		return fmt.Errorf("SidecarSet %q not found", o.Name)
+	}
+	sidecarSet, err := toSidecarSet(infos[0])
+	if err != nil {
+		return err
+	}
+
+	selector, err := metav1.LabelSelectorAsSelector(sidecarSet.Spec.Selector)
+	if err != nil {
+		return fmt.Errorf("failed to create selector for SidecarSet %s: %v", sidecarSet.Name, err)
+	}
+	// SidecarSets without a namespace match pods in all namespaces
+	podList, err := o.Clientset.CoreV1().Pods(sidecarSet.Spec.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return err
+	}
+	pods := podList.Items
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+
+	w := printers.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NAMESPACE\tNAME\tINJECTED\tUPDATED\tREADY\tNODE")
+	}
+	for i := range pods {
+		pod := &pods[i]
+		state := podSidecarState(sidecarSet, pod)
+		if o.NotUpdated && state.updated {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%t\t%t\t%t\t%s\n", pod.Namespace, pod.Name, state.injected, state.updated, state.ready, pod.Spec.NodeName)
+	}
+	return nil
+}
+
+// sidecarState is the state of the sidecars of a SidecarSet in a pod.
+type sidecarState struct {
+	// injected is true if the sidecars have been injected into the pod
+	injected bool
+	// updated is true if the injected sidecars are at the latest version of the SidecarSet
+	updated bool
+	// ready is true if all injected sidecar containers are ready
+	ready bool
+}
+
+// podSidecarState returns the state of the sidecars of sidecarSet in pod.
+func podSidecarState(sidecarSet *kruiseappsv1alpha1.SidecarSet, pod *corev1.Pod) sidecarState {
+	var state sidecarState
+	hash, found := podSidecarSetHash(pod, sidecarSet.Name)
+	if !found {
+		return state
+	}
+	state.injected = true
+	state.updated = hash == sidecarSet.Annotations[sidecarSetHashAnnotation]
+
+	ready := make(map[string]bool, len(pod.Status.ContainerStatuses))
+	for _, status := range pod.Status.ContainerStatuses {
+		ready[status.Name] = status.Ready
+	}
+	state.ready = true
+	for _, c := range sidecarSet.Spec.Containers {
+		if !ready[c.Name] {
+			state.ready = false
+		}
+	}
+	return state
+}
+
+// podSidecarSetHash returns the hash of the sidecars the SidecarSet named name injected into pod,
+// and whether it injected them at all.
+func podSidecarSetHash(pod *corev1.Pod, name string) (string, bool) {
+	value, ok := pod.Annotations[sidecarSetHashAnnotation]
+	if !ok {
+		return "", false
+	}
+	hashes := make(map[string]json.RawMessage)
+	if err := json.Unmarshal([]byte(value), &hashes); err != nil {
+		return "", false
+	}
+	raw, ok := hashes[name]
+	if !ok {
+		return "", false
+	}
+	// Older versions of Kruise record the bare hash instead of the upgrade spec
+	var hash string
+	if err := json.Unmarshal(raw, &hash); err == nil {
+		return hash, true
+	}
+	upgradeSpec := struct {
+		Hash string `json:"hash"`
+	}{}
+	if err := json.Unmarshal(raw, &upgradeSpec); err != nil {
+		return "", true
+	}
+	return upgradeSpec.Hash, true
+}
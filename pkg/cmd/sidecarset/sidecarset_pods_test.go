@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodSidecarState(t *testing.T) {
+	sidecarSet := &kruiseappsv1alpha1.SidecarSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "log-agent",
+			Annotations: map[string]string{sidecarSetHashAnnotation: "v2"},
+		},
+		Spec: kruiseappsv1alpha1.SidecarSetSpec{
+			Containers: []kruiseappsv1alpha1.SidecarContainer{{Container: corev1.Container{Name: "agent"}}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		annotation string
+		ready      bool
+		expected   sidecarState
+	}{
+		{
+			name:     "not injected",
+			expected: sidecarState{},
+		},
+		{
+			name:       "injected by another sidecarset",
+			annotation: `{"mesh":{"hash":"v2"}}`,
+			expected:   sidecarState{},
+		},
+		{
+			name:       "updated and ready",
+			annotation: `{"log-agent":{"hash":"v2","sidecarSetName":"log-agent"}}`,
+			ready:      true,
+			expected:   sidecarState{injected: true, updated: true, ready: true},
+		},
+		{
+			name:       "old sidecar not ready",
+			annotation: `{"log-agent":{"hash":"v1","sidecarSetName":"log-agent"}}`,
+			expected:   sidecarState{injected: true},
+		},
+		{
+			name:       "bare hash",
+			annotation: `{"log-agent":"v2"}`,
+			ready:      true,
+			expected:   sidecarState{injected: true, updated: true, ready: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{
+				Status: corev1.PodStatus{
+					ContainerStatuses: []corev1.ContainerStatus{
+						{Name: "main", Ready: true},
+						{Name: "agent", Ready: tt.ready},
+					},
+				},
+			}
+			if len(tt.annotation) > 0 {
+				pod.Annotations = map[string]string{sidecarSetHashAnnotation: tt.annotation}
+			}
+			if state := podSidecarState(sidecarSet, pod); state != tt.expected {
+				t.Errorf("expected %+v, got %+v", tt.expected, state)
+			}
+		})
+	}
+}
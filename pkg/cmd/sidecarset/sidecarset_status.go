@@ -0,0 +1,135 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sidecarset
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// StatusOptions holds the options for 'sidecarset status' sub command
+type StatusOptions struct {
+	Names     []string
+	Selector  string
+	NoHeaders bool
+
+	Builder func() *resource.Builder
+
+	genericclioptions.IOStreams
+}
+
+var (
+	statusLong = templates.LongDesc(`
+		Show the update status of SidecarSets.
+
+		For each SidecarSet the number of pods its sidecars are injected into is
+		printed, along with how many of them run the latest sidecars and how many
+		are ready. All SidecarSets are shown if no name is given.`)
+
+	statusExample = templates.Examples(`
+		# Show the status of all SidecarSets
+		kubectl-kruise sidecarset status
+
+		# Show the status of the SidecarSet log-agent
+		kubectl-kruise sidecarset status log-agent`)
+)
+
+// NewCmdSidecarSetStatus returns a Command instance for 'sidecarset status' sub command
+func NewCmdSidecarSetStatus(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &StatusOptions{
+		IOStreams: streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "status [NAME...] [-l label]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Show the update status of SidecarSets"),
+		Long:                  statusLong,
+		Example:               statusExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, args))
+			cmdutil.CheckErr(o.Validate())
+			cmdutil.CheckErr(o.Run())
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector (label query) to filter on, supports '=', '==', and '!='.(e.g. -l key1=value1,key2=value2)")
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", o.NoHeaders, "If present, print output without headers")
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *StatusOptions) Complete(f cmdutil.Factory, args []string) error {
+	o.Names = args
+	o.Builder = f.NewBuilder
+	return nil
+}
+
+// Validate makes sure all the provided values for command-line options are valid
+func (o *StatusOptions) Validate() error {
+	if len(o.Names) > 0 && len(o.Selector) > 0 {
+		return fmt.Errorf("only one of NAME or --selector can be provided")
+	}
+	return nil
+}
+
+// Run performs the execution of 'sidecarset status' sub command
+func (o *StatusOptions) Run() error {
+	infos, err := sidecarSetInfos(o.Builder(), o.Names, o.Selector)
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(o.ErrOut, "No resources found")
+		return nil
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	defer w.Flush()
+	if !o.NoHeaders {
+		fmt.Fprintln(w, "NAME\tMATCHED\tUPDATED\tREADY\tUPDATED-READY\tPAUSED\tSTATUS")
+	}
+	for _, info := range infos {
+		sidecarSet, err := toSidecarSet(info)
+		if err != nil {
+			return err
+		}
+		status := sidecarSet.Status
+
+		state := "Updating"
+		switch {
+		case sidecarSet.Generation > status.ObservedGeneration:
+			state = "Pending"
+		case sidecarSet.Spec.UpdateStrategy.Paused:
+			state = "Paused"
+		case status.UpdatedReadyPods >= status.MatchedPods:
+			state = "Updated"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%t\t%s\n", sidecarSet.Name, status.MatchedPods, status.UpdatedPods,
+			status.ReadyPods, status.UpdatedReadyPods, sidecarSet.Spec.UpdateStrategy.Paused, state)
+	}
+	return nil
+}
@@ -0,0 +1,365 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spread
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	internalpolymorphichelpers "github.com/hantmac/kubectl-kruise/pkg/internal/polymorphichelpers"
+	"github.com/hantmac/kubectl-kruise/pkg/internal/workloadspread"
+	"github.com/spf13/cobra"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/printers"
+	"k8s.io/cli-runtime/pkg/resource"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/scheme"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// SpreadOptions holds the options for 'spread' sub command
+type SpreadOptions struct {
+	Resources []string
+	Namespace string
+
+	Builder       func() *resource.Builder
+	Clientset     kubernetes.Interface
+	DynamicClient dynamic.Interface
+
+	genericclioptions.IOStreams
+}
+
+var (
+	spreadLong = templates.LongDesc(`
+		Show how the pods of a workload are spread by Kruise WorkloadSpreads.
+
+		For every WorkloadSpread of the workload, its subsets are listed with their
+		max replicas, the number of pods in them, the number of pods they can still
+		take and the node selector pods of the subset are required to run on. Then
+		every pod is listed with the subset it was injected into and its node.
+
+		Pods are flagged if they were not injected into a subset, were injected into a
+		subset the WorkloadSpread no longer has, run on a node the subset does not
+		select, or exceed the max replicas of their subset.`)
+
+	spreadExample = templates.Examples(`
+		# Show how the pods of the cloneset abc are spread
+		kubectl-kruise spread cloneset/abc
+
+		# Show how the pods of the deployment web are spread
+		kubectl-kruise spread deployment web`)
+)
+
+// subsetRow is a subset of a WorkloadSpread as it is printed.
+type subsetRow struct {
+	Name         string
+	MaxReplicas  string
+	Pods         int
+	Missing      string
+	NodeSelector string
+}
+
+// podRow is a pod of a workload as it is printed.
+type podRow struct {
+	Name      string
+	Subset    string
+	Node      string
+	Violation string
+}
+
+// NewCmdSpread returns a Command instance for 'spread' sub command
+func NewCmdSpread(f cmdutil.Factory, streams genericclioptions.IOStreams) *cobra.Command {
+	o := &SpreadOptions{
+		IOStreams: streams,
+	}
+
+	validArgs := []string{"cloneset", "deployment", "replicaset", "job"}
+
+	cmd := &cobra.Command{
+		Use:                   "spread (TYPE NAME | TYPE/NAME)",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Show how the pods of a workload are spread by WorkloadSpreads"),
+		Long:                  spreadLong,
+		Example:               spreadExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(o.Complete(f, cmd, args))
+			cmdutil.CheckErr(o.Run())
+		},
+		ValidArgs: validArgs,
+	}
+
+	return cmd
+}
+
+// Complete completes all the required options
+func (o *SpreadOptions) Complete(f cmdutil.Factory, cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "a workload is required")
+	}
+	o.Resources = args
+	o.Builder = f.NewBuilder
+
+	var err error
+	o.Namespace, _, err = f.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	if o.Clientset, err = f.KubernetesClientSet(); err != nil {
+		return err
+	}
+	if o.DynamicClient, err = f.DynamicClient(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run performs the execution of 'spread' sub command
+func (o *SpreadOptions) Run() error {
+	infos, err := o.Builder().
+		WithScheme(internalclient.Scheme, scheme.Scheme.PrioritizedVersionsAllGroups()...).
+		NamespaceParam(o.Namespace).DefaultNamespace().
+		ResourceTypeOrNameArgs(false, o.Resources...).
+		SingleResourceType().
+		Flatten().
+		Latest().
+		Do().
+		Infos()
+	if err != nil {
+		return err
+	}
+	if len(infos) != 1 {
+		return fmt.Errorf("spread is only supported on a single workload")
+	}
+	info := infos[0]
+
+	gvk := info.Mapping.GroupVersionKind
+	workload := workloadspread.TargetReference{APIVersion: gvk.GroupVersion().String(), Kind: gvk.Kind, Name: info.Name}
+	spreads, err := workloadspread.ForWorkload(context.TODO(), o.DynamicClient, info.Namespace, workload)
+	if err != nil {
+		return err
+	}
+	if len(spreads) == 0 {
+		return fmt.Errorf("%s %q is not targeted by any WorkloadSpread", strings.ToLower(gvk.Kind), info.Name)
+	}
+
+	// terminating pods would use up the slots of the subsets of the pods replacing them
+	namespace, selector, err := internalpolymorphichelpers.SelectorsForObject(info.Object)
+	if err != nil {
+		return err
+	}
+	pods, err := internalpolymorphichelpers.ControlledPods(o.Clientset, info.Object, namespace, selector)
+	if err != nil {
+		return err
+	}
+	nodes, err := o.nodesOfPods(pods)
+	if err != nil {
+		return err
+	}
+	replicas, err := workloadReplicas(info.Object, len(pods))
+	if err != nil {
+		return err
+	}
+
+	for i, ws := range spreads {
+		if i > 0 {
+			fmt.Fprintln(o.Out)
+		}
+		strategy := ws.Spec.ScheduleStrategy.Type
+		if len(strategy) == 0 {
+			strategy = "Fixed"
+		}
+		fmt.Fprintf(o.Out, "WorkloadSpread %s (%s schedule strategy)\n", ws.Name, strategy)
+
+		subsets, podRows, err := spreadRows(ws, pods, nodes, replicas)
+		if err != nil {
+			return err
+		}
+		w := printers.GetNewTabWriter(o.Out)
+		fmt.Fprintln(w, "SUBSET\tMAX-REPLICAS\tPODS\tMISSING\tREQUIRED-NODE-SELECTOR")
+		for _, subset := range subsets {
+			fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", subset.Name, subset.MaxReplicas, subset.Pods, subset.Missing, subset.NodeSelector)
+		}
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "POD\tSUBSET\tNODE\tVIOLATION")
+		for _, pod := range podRows {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", pod.Name, pod.Subset, pod.Node, pod.Violation)
+		}
+		w.Flush()
+	}
+	return nil
+}
+
+// nodesOfPods returns the nodes pods are scheduled to by name, nodes that are gone are left out.
+func (o *SpreadOptions) nodesOfPods(pods []*corev1.Pod) (map[string]*corev1.Node, error) {
+	nodes := make(map[string]*corev1.Node)
+	for _, pod := range pods {
+		name := pod.Spec.NodeName
+		if len(name) == 0 {
+			continue
+		}
+		if _, ok := nodes[name]; ok {
+			continue
+		}
+		node, err := o.Clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			nodes[name] = nil
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		nodes[name] = node
+	}
+	return nodes, nil
+}
+
+// workloadReplicas returns the desired replicas of the workload obj, which percentages of max
+// replicas are relative to, or defaultReplicas if it has none.
+func workloadReplicas(obj runtime.Object, defaultReplicas int) (int, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return 0, err
+	}
+	replicas, found, err := unstructured.NestedInt64(content, "spec", "replicas")
+	if err != nil || !found {
+		return defaultReplicas, err
+	}
+	return int(replicas), nil
+}
+
+// spreadRows returns the subsets of the WorkloadSpread and the pods of its workload, with the
+// violations of the pods.
+func spreadRows(ws *workloadspread.WorkloadSpread, pods []*corev1.Pod, nodes map[string]*corev1.Node, replicas int) ([]subsetRow, []podRow, error) {
+	subsetPods := make(map[string][]*corev1.Pod)
+	violations := make(map[types.UID]string)
+	for _, pod := range pods {
+		wsName, subsetName := workloadspread.MatchedSubset(pod)
+		if wsName != ws.Name {
+			violations[pod.UID] = "not injected into a subset"
+			continue
+		}
+		subset := ws.Subset(subsetName)
+		if subset == nil {
+			violations[pod.UID] = fmt.Sprintf("subset %s no longer exists", subsetName)
+			continue
+		}
+		subsetPods[subsetName] = append(subsetPods[subsetName], pod)
+
+		node := nodes[pod.Spec.NodeName]
+		if node == nil {
+			continue
+		}
+		matches, err := subset.MatchesNode(node)
+		if err != nil {
+			return nil, nil, fmt.Errorf("subset %s: %v", subsetName, err)
+		}
+		if !matches {
+			violations[pod.UID] = "node not selected by the subset"
+		}
+	}
+
+	var subsets []subsetRow
+	for i := range ws.Spec.Subsets {
+		subset := &ws.Spec.Subsets[i]
+		row := subsetRow{Name: subset.Name, MaxReplicas: "<unlimited>", Pods: len(subsetPods[subset.Name]), Missing: "<unknown>", NodeSelector: formatNodeSelectorTerm(subset.RequiredNodeSelectorTerm)}
+		if missing, ok := ws.MissingReplicas(subset.Name); ok && missing >= 0 {
+			row.Missing = fmt.Sprintf("%d", missing)
+		}
+		if subset.MaxReplicas != nil {
+			row.MaxReplicas = subset.MaxReplicas.String()
+			maxReplicas, err := intstr.GetValueFromIntOrPercent(subset.MaxReplicas, replicas, true)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid max replicas of subset %s: %v", subset.Name, err)
+			}
+			// the newest pods are the ones that should not have been put into the subset
+			inSubset := subsetPods[subset.Name]
+			sort.SliceStable(inSubset, func(i, j int) bool {
+				return inSubset[i].CreationTimestamp.Before(&inSubset[j].CreationTimestamp)
+			})
+			for _, pod := range inSubset[minInt(maxReplicas, len(inSubset)):] {
+				if _, ok := violations[pod.UID]; !ok {
+					violations[pod.UID] = fmt.Sprintf("exceeds %d max replicas of the subset", maxReplicas)
+				}
+			}
+		}
+		subsets = append(subsets, row)
+	}
+
+	var podRows []podRow
+	for _, pod := range pods {
+		_, subsetName := workloadspread.MatchedSubset(pod)
+		row := podRow{Name: pod.Name, Subset: subsetName, Node: pod.Spec.NodeName, Violation: violations[pod.UID]}
+		if len(row.Subset) == 0 {
+			row.Subset = "<none>"
+		}
+		if len(row.Node) == 0 {
+			row.Node = "<none>"
+		}
+		if len(row.Violation) == 0 {
+			row.Violation = "<none>"
+		}
+		podRows = append(podRows, row)
+	}
+	sort.Slice(podRows, func(i, j int) bool {
+		if podRows[i].Subset != podRows[j].Subset {
+			return podRows[i].Subset < podRows[j].Subset
+		}
+		return podRows[i].Name < podRows[j].Name
+	})
+	return subsets, podRows, nil
+}
+
+// formatNodeSelectorTerm returns the requirements of term like 'key In [a,b]', separated by commas.
+func formatNodeSelectorTerm(term *corev1.NodeSelectorTerm) string {
+	if term == nil {
+		return "<none>"
+	}
+	var reqs []string
+	for _, req := range append(append([]corev1.NodeSelectorRequirement(nil), term.MatchExpressions...), term.MatchFields...) {
+		switch req.Operator {
+		case corev1.NodeSelectorOpExists, corev1.NodeSelectorOpDoesNotExist:
+			reqs = append(reqs, fmt.Sprintf("%s %s", req.Key, req.Operator))
+		default:
+			reqs = append(reqs, fmt.Sprintf("%s %s [%s]", req.Key, req.Operator, strings.Join(req.Values, ",")))
+		}
+	}
+	if len(reqs) == 0 {
+		return "<none>"
+	}
+	return strings.Join(reqs, ", ")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
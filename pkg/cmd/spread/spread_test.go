@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package spread
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hantmac/kubectl-kruise/pkg/internal/workloadspread"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestSpreadRows(t *testing.T) {
+	maxReplicas := intstr.FromInt(1)
+	ws := &workloadspread.WorkloadSpread{
+		ObjectMeta: metav1.ObjectMeta{Name: "abc-spread"},
+		Spec: workloadspread.WorkloadSpreadSpec{
+			Subsets: []workloadspread.WorkloadSpreadSubset{
+				{
+					Name: "zone-a",
+					RequiredNodeSelectorTerm: &corev1.NodeSelectorTerm{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}}},
+					},
+					MaxReplicas: &maxReplicas,
+				},
+				{Name: "zone-b"},
+			},
+		},
+		Status: workloadspread.WorkloadSpreadStatus{
+			SubsetStatuses: []workloadspread.WorkloadSpreadSubsetStatus{
+				{Name: "zone-a", MissingReplicas: 0},
+				{Name: "zone-b", MissingReplicas: -1},
+			},
+		},
+	}
+	created := time.Now()
+	pod := func(name, subset, node string, age time.Duration) *corev1.Pod {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name), CreationTimestamp: metav1.NewTime(created.Add(-age))},
+			Spec:       corev1.PodSpec{NodeName: node},
+		}
+		if len(subset) > 0 {
+			pod.Annotations = map[string]string{workloadspread.MatchedWorkloadSpreadAnnotation: `{"name":"abc-spread","subset":"` + subset + `"}`}
+		}
+		return pod
+	}
+	pods := []*corev1.Pod{
+		pod("abc-1", "zone-a", "node-a", 2*time.Hour),
+		pod("abc-2", "zone-a", "node-a", time.Hour),
+		pod("abc-3", "zone-b", "node-b", time.Hour),
+		pod("abc-4", "zone-a", "node-b", 3*time.Hour),
+		pod("abc-5", "", "node-b", time.Hour),
+		pod("abc-6", "zone-c", "", time.Hour),
+	}
+	nodes := map[string]*corev1.Node{
+		"node-a": {ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: map[string]string{"zone": "a"}}},
+		"node-b": {ObjectMeta: metav1.ObjectMeta{Name: "node-b", Labels: map[string]string{"zone": "b"}}},
+	}
+
+	subsets, podRows, err := spreadRows(ws, pods, nodes, 6)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedSubsets := []subsetRow{
+		{Name: "zone-a", MaxReplicas: "1", Pods: 3, Missing: "0", NodeSelector: "zone In [a]"},
+		{Name: "zone-b", MaxReplicas: "<unlimited>", Pods: 1, Missing: "<unknown>", NodeSelector: "<none>"},
+	}
+	if !reflect.DeepEqual(subsets, expectedSubsets) {
+		t.Errorf("expected subsets %+v, got %+v", expectedSubsets, subsets)
+	}
+	expectedPods := []podRow{
+		{Name: "abc-5", Subset: "<none>", Node: "node-b", Violation: "not injected into a subset"},
+		{Name: "abc-1", Subset: "zone-a", Node: "node-a", Violation: "exceeds 1 max replicas of the subset"},
+		{Name: "abc-2", Subset: "zone-a", Node: "node-a", Violation: "exceeds 1 max replicas of the subset"},
+		{Name: "abc-4", Subset: "zone-a", Node: "node-b", Violation: "node not selected by the subset"},
+		{Name: "abc-3", Subset: "zone-b", Node: "node-b", Violation: "<none>"},
+		{Name: "abc-6", Subset: "zone-c", Node: "<none>", Violation: "subset zone-c no longer exists"},
+	}
+	if !reflect.DeepEqual(podRows, expectedPods) {
+		t.Errorf("expected pods %+v, got %+v", expectedPods, podRows)
+	}
+}
+
+func TestWorkloadReplicas(t *testing.T) {
+	replicas := int32(4)
+	cs := &kruiseappsv1alpha1.CloneSet{Spec: kruiseappsv1alpha1.CloneSetSpec{Replicas: &replicas}}
+	if n, err := workloadReplicas(cs, 1); err != nil || n != 4 {
+		t.Errorf("expected 4 replicas, got %d (%v)", n, err)
+	}
+	if n, err := workloadReplicas(&kruiseappsv1alpha1.CloneSet{}, 2); err != nil || n != 2 {
+		t.Errorf("expected default of 2 replicas, got %d (%v)", n, err)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -56,3 +57,22 @@ func GetPodsOwnedByCloneSet(ns, name string, cr client.Reader) (*corev1.PodList,
 
 	return pods, err
 }
+
+// GetNodeNamesOfCloneSetPods returns the sorted names of the nodes the Pods of a CloneSet are scheduled on
+func GetNodeNamesOfCloneSetPods(ns, name string, cr client.Reader) ([]string, error) {
+	pods, err := GetPodsOwnedByCloneSet(ns, name, cr)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := sets.NewString()
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if len(pod.Spec.NodeName) == 0 || pod.DeletionTimestamp != nil {
+			continue
+		}
+		nodes.Insert(pod.Spec.NodeName)
+	}
+
+	return nodes.List(), nil
+}
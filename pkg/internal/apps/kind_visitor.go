@@ -35,6 +35,7 @@ type KindVisitor interface {
 	VisitCronJob(kind GroupKindElement)
 	VisitCloneSet(kind GroupKindElement)
 	VisitAdvancedStatefulSet(kind GroupKindElement)
+	VisitAdvancedDaemonSet(kind GroupKindElement)
 }
 
 // GroupKindElement defines a Kubernetes API group elem
@@ -63,6 +64,8 @@ func (elem GroupKindElement) Accept(visitor KindVisitor) error {
 		visitor.VisitCloneSet(elem)
 	case elem.GroupMatch("apps.kruise.io") && elem.Kind == "StatefulSet":
 		visitor.VisitAdvancedStatefulSet(elem)
+	case elem.GroupMatch("apps.kruise.io") && elem.Kind == "DaemonSet":
+		visitor.VisitAdvancedDaemonSet(elem)
 	default:
 		return fmt.Errorf("no visitor method exists for %v", elem)
 	}
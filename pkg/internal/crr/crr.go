@@ -0,0 +1,170 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package crr works with Kruise ContainerRecreateRequests, which restart containers of a pod in
+// place. The kruise-api version used here does not ship the type, so requests are handled as
+// unstructured objects.
+package crr
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersionResource is the resource of ContainerRecreateRequests.
+var GroupVersionResource = schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "containerrecreaterequests"}
+
+const (
+	// PhaseCompleted is the phase of a request whose containers have all been recreated or have failed.
+	PhaseCompleted = "Completed"
+	// containerPhaseFailed is the phase of a container that could not be recreated.
+	containerPhaseFailed = "Failed"
+
+	// ttlSecondsAfterFinished keeps finished requests around long enough to be inspected.
+	ttlSecondsAfterFinished = int64(600)
+)
+
+// Strategy controls how the containers of a request are recreated.
+type Strategy struct {
+	// OrderedRecreate recreates the containers one after another, in the order of their priorities.
+	OrderedRecreate bool
+	// TerminationGracePeriodSeconds overrides how long the containers may take to stop before they
+	// are killed, the grace period of the pod is used if nil.
+	TerminationGracePeriodSeconds *int64
+}
+
+// NewRequest returns a ContainerRecreateRequest that restarts the given containers of pod in place.
+// All containers of the pod are restarted if containers is empty.
+func NewRequest(pod *corev1.Pod, containers []string, strategy Strategy) *unstructured.Unstructured {
+	if len(containers) == 0 {
+		for _, c := range pod.Spec.Containers {
+			containers = append(containers, c.Name)
+		}
+	}
+	requestContainers := make([]interface{}, 0, len(containers))
+	for _, name := range containers {
+		requestContainers = append(requestContainers, map[string]interface{}{"name": name})
+	}
+
+	requestStrategy := map[string]interface{}{
+		"failurePolicy": "Fail",
+	}
+	if strategy.OrderedRecreate {
+		requestStrategy["orderedRecreate"] = true
+	}
+	if strategy.TerminationGracePeriodSeconds != nil {
+		requestStrategy["terminationGracePeriodSeconds"] = *strategy.TerminationGracePeriodSeconds
+	}
+	req := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"podName":                 pod.Name,
+			"containers":              requestContainers,
+			"strategy":                requestStrategy,
+			"ttlSecondsAfterFinished": ttlSecondsAfterFinished,
+		},
+	}}
+	req.SetAPIVersion(GroupVersionResource.GroupVersion().String())
+	req.SetKind("ContainerRecreateRequest")
+	req.SetNamespace(pod.Namespace)
+	req.SetGenerateName(pod.Name + "-")
+	return req
+}
+
+// Completed returns true if the request has finished, and an error if any of its containers failed
+// to be recreated.
+func Completed(req *unstructured.Unstructured) (bool, error) {
+	phase, _, err := unstructured.NestedString(req.Object, "status", "phase")
+	if err != nil {
+		return false, err
+	}
+	states, _, err := unstructured.NestedSlice(req.Object, "status", "containerRecreateStates")
+	if err != nil {
+		return false, err
+	}
+	for _, state := range states {
+		state, ok := state.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if state["phase"] == containerPhaseFailed {
+			return true, fmt.Errorf("failed to recreate container %v of pod %s: %v",
+				state["name"], podName(req), state["message"])
+		}
+	}
+	return phase == PhaseCompleted, nil
+}
+
+// WaitForCompletion waits for the named requests in namespace like WaitForResults, and returns the
+// first reason, in the order of names, that one of them did not recreate its containers.
+func WaitForCompletion(ctx context.Context, client dynamic.Interface, namespace string, names []string, interval time.Duration) error {
+	results, err := WaitForResults(ctx, client, namespace, names, interval)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WaitForResults polls the named requests in namespace until all of them have finished or ctx is
+// done. It returns the result of every request by name, which is nil if its containers have been
+// recreated and the reason they were not otherwise. A request that can not be read is not waited
+// for anymore, the error reading it is its result.
+func WaitForResults(ctx context.Context, client dynamic.Interface, namespace string, names []string, interval time.Duration) (map[string]error, error) {
+	results := make(map[string]error, len(names))
+	pending := append([]string(nil), names...)
+	err := wait.PollImmediateUntil(interval, func() (bool, error) {
+		var remaining []string
+		for _, name := range pending {
+			req, err := client.Resource(GroupVersionResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				results[name] = fmt.Errorf("failed to get ContainerRecreateRequest %s: %v", name, err)
+				continue
+			}
+			done, err := Completed(req)
+			if !done && err == nil {
+				remaining = append(remaining, name)
+				continue
+			}
+			results[name] = err
+		}
+		pending = remaining
+		return len(pending) == 0, nil
+	}, ctx.Done())
+	if err == wait.ErrWaitTimeout {
+		for _, name := range pending {
+			results[name] = fmt.Errorf("timed out waiting for the containers to be recreated")
+		}
+		return results, nil
+	}
+	return results, err
+}
+
+func podName(req *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(req.Object, "spec", "podName")
+	return name
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crr
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestNewRequest(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "foo-abc"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+	}
+
+	req := NewRequest(pod, nil, Strategy{})
+	if req.GetNamespace() != "bar" || req.GetGenerateName() != "foo-abc-" || req.GetKind() != "ContainerRecreateRequest" {
+		t.Errorf("unexpected request metadata: %#v", req.Object)
+	}
+	containers, _, _ := unstructured.NestedSlice(req.Object, "spec", "containers")
+	if len(containers) != 2 {
+		t.Errorf("expected all containers to be restarted, got %v", containers)
+	}
+
+	gracePeriod := int64(30)
+	req = NewRequest(pod, []string{"sidecar"}, Strategy{OrderedRecreate: true, TerminationGracePeriodSeconds: &gracePeriod})
+	containers, _, _ = unstructured.NestedSlice(req.Object, "spec", "containers")
+	if len(containers) != 1 || containers[0].(map[string]interface{})["name"] != "sidecar" {
+		t.Errorf("expected only sidecar to be restarted, got %v", containers)
+	}
+	strategy, _, _ := unstructured.NestedMap(req.Object, "spec", "strategy")
+	if strategy["orderedRecreate"] != true || strategy["terminationGracePeriodSeconds"] != int64(30) {
+		t.Errorf("unexpected strategy: %v", strategy)
+	}
+}
+
+func TestWaitForResults(t *testing.T) {
+	request := func(name string, status map[string]interface{}) *unstructured.Unstructured {
+		req := NewRequest(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: name}}, []string{"app"}, Strategy{})
+		req.SetName(name)
+		req.Object["status"] = status
+		return req
+	}
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		request("succeeded", map[string]interface{}{
+			"phase":                   "Completed",
+			"containerRecreateStates": []interface{}{map[string]interface{}{"name": "app", "phase": "Succeeded"}},
+		}),
+		request("failed", map[string]interface{}{
+			"phase":                   "Completed",
+			"containerRecreateStates": []interface{}{map[string]interface{}{"name": "app", "phase": "Failed", "message": "boom"}},
+		}),
+		request("recreating", map[string]interface{}{
+			"phase":                   "Recreating",
+			"containerRecreateStates": []interface{}{map[string]interface{}{"name": "app", "phase": "Recreating"}},
+		}),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	results, err := WaitForResults(ctx, client, "bar", []string{"succeeded", "failed", "recreating", "missing"}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("expected 4 results, got %v", results)
+	}
+	if results["succeeded"] != nil {
+		t.Errorf("expected succeeded request to have no error, got %v", results["succeeded"])
+	}
+	if results["failed"] == nil || results["recreating"] == nil || results["missing"] == nil {
+		t.Errorf("expected failed, recreating and missing requests to have errors, got %v", results)
+	}
+
+	if err := WaitForCompletion(context.Background(), client, "bar", []string{"succeeded"}, 10*time.Millisecond); err != nil {
+		t.Errorf("expected succeeded request to complete, got %v", err)
+	}
+	if err := WaitForCompletion(context.Background(), client, "bar", []string{"succeeded", "failed"}, 10*time.Millisecond); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the failure of the failed request, got %v", err)
+	}
+}
+
+func TestCompleted(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    map[string]interface{}
+		done      bool
+		expectErr bool
+	}{
+		{
+			name: "pending",
+			done: false,
+		},
+		{
+			name: "recreating",
+			status: map[string]interface{}{
+				"phase": "Recreating",
+				"containerRecreateStates": []interface{}{
+					map[string]interface{}{"name": "app", "phase": "Recreating"},
+				},
+			},
+			done: false,
+		},
+		{
+			name: "completed",
+			status: map[string]interface{}{
+				"phase": "Completed",
+				"containerRecreateStates": []interface{}{
+					map[string]interface{}{"name": "app", "phase": "Succeeded"},
+				},
+			},
+			done: true,
+		},
+		{
+			name: "failed",
+			status: map[string]interface{}{
+				"phase": "Completed",
+				"containerRecreateStates": []interface{}{
+					map[string]interface{}{"name": "app", "phase": "Failed", "message": "boom"},
+				},
+			},
+			done:      true,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &unstructured.Unstructured{Object: map[string]interface{}{
+				"spec": map[string]interface{}{"podName": "foo-abc"},
+			}}
+			if test.status != nil {
+				req.Object["status"] = test.status
+			}
+			done, err := Completed(req)
+			if (err != nil) != test.expectErr {
+				t.Fatalf("expected error %t, got %v", test.expectErr, err)
+			}
+			if done != test.done {
+				t.Errorf("expected done %t, got %t", test.done, done)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package dynamicutil
+
+import (
+	"context"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestListIfInstalled(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "workloadspreads"}
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps.kruise.io/v1alpha1")
+	obj.SetKind("WorkloadSpread")
+	obj.SetNamespace("bar")
+	obj.SetName("ws")
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(), obj)
+
+	items, err := ListIfInstalled(context.TODO(), client, resource, "bar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 1 || items[0].GetName() != "ws" {
+		t.Errorf("expected workloadspread ws, got %v", items)
+	}
+
+	client.PrependReactor("list", "workloadspreads", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(resource.GroupResource(), "")
+	})
+	items, err = ListIfInstalled(context.TODO(), client, resource, "bar")
+	if err != nil || len(items) != 0 {
+		t.Errorf("expected no objects of a resource that is not installed, got %v, %v", items, err)
+	}
+}
+
+func TestAPIGroup(t *testing.T) {
+	tests := map[string]string{
+		"apps.kruise.io/v1alpha1": "apps.kruise.io",
+		"apps/v1":                 "apps",
+		"v1":                      "",
+	}
+	for apiVersion, expected := range tests {
+		if group := APIGroup(apiVersion); group != expected {
+			t.Errorf("expected group %q of %s, got %q", expected, apiVersion, group)
+		}
+	}
+}
@@ -22,16 +22,22 @@ import (
 	"sort"
 	"time"
 
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+
 	appsv1 "k8s.io/api/apps/v1"
 	appsv1beta1 "k8s.io/api/apps/v1beta1"
 	appsv1beta2 "k8s.io/api/apps/v1beta2"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 	coreclient "k8s.io/client-go/kubernetes/typed/core/v1"
 	watchtools "k8s.io/client-go/tools/watch"
 )
@@ -169,6 +175,31 @@ func SelectorsForObject(object runtime.Object) (namespace string, selector label
 			return "", nil, fmt.Errorf("invalid label selector: %v", err)
 		}
 
+	case *kruiseappsv1alpha1.CloneSet:
+		namespace = t.Namespace
+		selector, err = metav1.LabelSelectorAsSelector(t.Spec.Selector)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+	case *kruiseappsv1alpha1.StatefulSet:
+		namespace = t.Namespace
+		selector, err = metav1.LabelSelectorAsSelector(t.Spec.Selector)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+	case *kruiseappsv1beta1.StatefulSet:
+		namespace = t.Namespace
+		selector, err = metav1.LabelSelectorAsSelector(t.Spec.Selector)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+	case *kruiseappsv1alpha1.DaemonSet:
+		namespace = t.Namespace
+		selector, err = metav1.LabelSelectorAsSelector(t.Spec.Selector)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid label selector: %v", err)
+		}
+
 	case *batchv1.Job:
 		namespace = t.Namespace
 		selector, err = metav1.LabelSelectorAsSelector(t.Spec.Selector)
@@ -189,3 +220,41 @@ func SelectorsForObject(object runtime.Object) (namespace string, selector label
 
 	return namespace, selector, nil
 }
+
+// ControlledPods returns the pods in namespace matching selector that are controlled by owner, or
+// by one of its ReplicaSets if owner is a Deployment. Terminating pods are left out.
+func ControlledPods(client kubernetes.Interface, owner runtime.Object, namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	accessor, err := meta.Accessor(owner)
+	if err != nil {
+		return nil, err
+	}
+	owners := sets.NewString(string(accessor.GetUID()))
+	if _, ok := owner.(*appsv1.Deployment); ok {
+		rsList, err := client.AppsV1().ReplicaSets(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			return nil, err
+		}
+		for i := range rsList.Items {
+			if metav1.IsControlledBy(&rsList.Items[i], accessor) {
+				owners.Insert(string(rsList.Items[i].UID))
+			}
+		}
+	}
+
+	podList, err := client.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	var pods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		if ref := metav1.GetControllerOf(pod); ref == nil || !owners.Has(string(ref.UID)) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	return pods, nil
+}
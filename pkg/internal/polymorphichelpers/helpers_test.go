@@ -0,0 +1,98 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestControlledPods(t *testing.T) {
+	deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "web", UID: "d-uid"}}
+	cs := &kruiseappsv1alpha1.CloneSet{ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "web", UID: "cs-uid"}}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "bar",
+		Name:            "web-1",
+		UID:             "rs-uid",
+		Labels:          map[string]string{"app": "web"},
+		OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"))},
+	}}
+	now := metav1.Now()
+	newPod := func(name string, owner metav1.Object, gvk schema.GroupVersionKind, deleted bool) *corev1.Pod {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "bar",
+			Name:            name,
+			Labels:          map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(owner, gvk)},
+		}}
+		if deleted {
+			pod.DeletionTimestamp = &now
+		}
+		return pod
+	}
+	objects := []runtime.Object{
+		rs,
+		newPod("web-1-a", rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), false),
+		newPod("web-1-b", rs, appsv1.SchemeGroupVersion.WithKind("ReplicaSet"), true),
+		newPod("web-a", cs, kruiseappsv1alpha1.SchemeGroupVersion.WithKind("CloneSet"), false),
+	}
+
+	testCases := []struct {
+		name     string
+		owner    runtime.Object
+		expected []string
+	}{
+		{name: "deployment", owner: deployment, expected: []string{"web-1-a"}},
+		{name: "cloneset", owner: cs, expected: []string{"web-a"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(objects...)
+			if testCase.owner != deployment {
+				// only the ReplicaSets of a Deployment are looked up
+				client.PrependReactor("list", "replicasets", func(clienttesting.Action) (bool, runtime.Object, error) {
+					return true, nil, apierrors.NewForbidden(appsv1.Resource("replicasets"), "", nil)
+				})
+			}
+			pods, err := ControlledPods(client, testCase.owner, "bar", labels.SelectorFromSet(map[string]string{"app": "web"}))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var names []string
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			sort.Strings(names)
+			if !reflect.DeepEqual(names, testCase.expected) {
+				t.Errorf("expected pods %v, got %v", testCase.expected, names)
+			}
+		})
+	}
+}
@@ -21,8 +21,11 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"sort"
 	"text/tabwriter"
 
+	"github.com/pmezard/go-difflib/difflib"
+
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
 	"github.com/hantmac/kubectl-kruise/pkg/fetcher"
 	internalapps "github.com/hantmac/kubectl-kruise/pkg/internal/apps"
@@ -45,15 +48,69 @@ import (
 	deploymentutil "k8s.io/kubectl/pkg/util/deployment"
 	sliceutil "k8s.io/kubectl/pkg/util/slice"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
 )
 
 const (
 	ChangeCauseAnnotation = "kubernetes.io/change-cause"
+
+	// historyHashLabel is the label ControllerRevisions carry their hash in
+	historyHashLabel = "controller.kubernetes.io/hash"
 )
 
 // HistoryViewer provides an interface for resources have historical information.
 type HistoryViewer interface {
 	ViewHistory(namespace, name string, revision int64) (string, error)
+	DiffHistory(namespace, name string, revision1, revision2 int64) (string, error)
+}
+
+// RevisionHistoryViewer is implemented by HistoryViewers that can report structured revision metadata.
+type RevisionHistoryViewer interface {
+	RevisionHistory(namespace, name string) (*RolloutHistory, error)
+}
+
+// RolloutHistory is the structured revision history of a workload.
+type RolloutHistory struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	UpdateRevision  string `json:"updateRevision,omitempty"`
+
+	// Revisions are sorted by revision number.
+	Revisions []RevisionInfo `json:"revisions"`
+}
+
+// RevisionInfo describes a single ControllerRevision of a workload.
+type RevisionInfo struct {
+	Revision int64 `json:"revision"`
+	// Name is the name of the ControllerRevision.
+	Name string `json:"name"`
+	// Hash is the hash label of the ControllerRevision.
+	Hash              string      `json:"hash,omitempty"`
+	CreationTimestamp metav1.Time `json:"creationTimestamp"`
+	ChangeCause       string      `json:"changeCause,omitempty"`
+	// Pods is the number of live pods whose controller-revision-hash label points at this revision.
+	Pods int32 `json:"pods"`
+	// Current is true if this is the current revision of the workload.
+	Current bool `json:"current"`
+	// Update is true if this is the update revision of the workload.
+	Update bool `json:"update"`
+}
+
+// DeepCopyObject implements runtime.Object.
+func (h *RolloutHistory) DeepCopyObject() runtime.Object {
+	if h == nil {
+		return nil
+	}
+	out := *h
+	h.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Revisions = make([]RevisionInfo, len(h.Revisions))
+	for i := range h.Revisions {
+		out.Revisions[i] = h.Revisions[i]
+		h.Revisions[i].CreationTimestamp.DeepCopyInto(&out.Revisions[i].CreationTimestamp)
+	}
+	return &out
 }
 
 type HistoryVisitor struct {
@@ -86,6 +143,12 @@ func (v *HistoryVisitor) VisitAdvancedStatefulSet(kind internalapps.GroupKindEle
 	v.result = &AdvancedStatefulSetHistoryViewer{v.c, v.clientset}
 }
 
+func (v *HistoryVisitor) VisitAdvancedDaemonSet(kind internalapps.GroupKindElement) {
+	mgr := internalclient.NewManager()
+	v.c = mgr.GetAPIReader()
+	v.result = &AdvancedDaemonSetHistoryViewer{v.c, v.clientset}
+}
+
 func (v *HistoryVisitor) VisitJob(kind internalapps.GroupKindElement)                   {}
 func (v *HistoryVisitor) VisitPod(kind internalapps.GroupKindElement)                   {}
 func (v *HistoryVisitor) VisitReplicaSet(kind internalapps.GroupKindElement)            {}
@@ -135,6 +198,30 @@ func (h *CloneSetHistoryViewer) ViewHistory(namespace, name string, revision int
 	})
 }
 
+// RevisionHistory returns the structured revision history of a CloneSet
+func (h *CloneSetHistoryViewer) RevisionHistory(namespace, name string) (*RolloutHistory, error) {
+	cs, history, err := clonesetHistory(h.k.AppsV1(), h.c, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return revisionHistory(h.k, cs, cs.Spec.Selector, history, cs.Status.CurrentRevision, cs.Status.UpdateRevision)
+}
+
+// DiffHistory returns a unified diff between the pod templates of two revisions of a CloneSet
+func (h *CloneSetHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	cs, history, err := clonesetHistory(h.k.AppsV1(), h.c, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return diffHistory(history, revision1, revision2, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		csOfHistory, err := applyCloneSetHistory(cs, history)
+		if err != nil {
+			return nil, err
+		}
+		return &csOfHistory.Spec.Template, err
+	})
+}
+
 type AdvancedStatefulSetHistoryViewer struct {
 	c client.Reader
 	k kubernetes.Interface
@@ -154,41 +241,91 @@ func (h *AdvancedStatefulSetHistoryViewer) ViewHistory(namespace, name string, r
 	})
 }
 
-type DeploymentHistoryViewer struct {
-	c kubernetes.Interface
+// RevisionHistory returns the structured revision history of an Advanced StatefulSet
+func (h *AdvancedStatefulSetHistoryViewer) RevisionHistory(namespace, name string) (*RolloutHistory, error) {
+	asts, history, err := advancedstsHistory(h.k.AppsV1(), h.c, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return revisionHistory(h.k, asts, asts.Spec.Selector, history, asts.Status.CurrentRevision, asts.Status.UpdateRevision)
 }
 
-// ViewHistory returns a revision-to-replicaset map as the revision history of a deployment
-// TODO: this should be a describer
-func (h *DeploymentHistoryViewer) ViewHistory(namespace, name string, revision int64) (string, error) {
-	versionedAppsClient := h.c.AppsV1()
-	deployment, err := versionedAppsClient.Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+// DiffHistory returns a unified diff between the pod templates of two revisions of an Advanced StatefulSet
+func (h *AdvancedStatefulSetHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	asts, history, err := advancedstsHistory(h.k.AppsV1(), h.c, namespace, name)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve deployment %s: %v", name, err)
+		return "", err
 	}
-	_, allOldRSs, newRS, err := deploymentutil.GetAllReplicaSets(deployment, versionedAppsClient)
+	return diffHistory(history, revision1, revision2, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		astsOfHistory, err := applyAdvancedStatefulSetHistory(asts, history)
+		if err != nil {
+			return nil, err
+		}
+		return &astsOfHistory.Spec.Template, err
+	})
+}
+
+type AdvancedDaemonSetHistoryViewer struct {
+	c client.Reader
+	k kubernetes.Interface
+}
+
+// ViewHistory returns a list of the revision history of an Advanced DaemonSet
+func (h *AdvancedDaemonSetHistoryViewer) ViewHistory(namespace, name string, revision int64) (string, error) {
+	ads, history, err := advancedDaemonSetHistory(h.k.AppsV1(), h.c, namespace, name)
 	if err != nil {
-		return "", fmt.Errorf("failed to retrieve replica sets from deployment %s: %v", name, err)
-	}
-	allRSs := allOldRSs
-	if newRS != nil {
-		allRSs = append(allRSs, newRS)
+		return "", err
 	}
-
-	historyInfo := make(map[int64]*corev1.PodTemplateSpec)
-	for _, rs := range allRSs {
-		v, err := deploymentutil.Revision(rs)
+	return printHistory(history, revision, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		adsOfHistory, err := applyAdvancedDaemonSetHistory(ads, history)
 		if err != nil {
-			continue
+			return nil, err
 		}
-		historyInfo[v] = &rs.Spec.Template
-		changeCause := getChangeCause(rs)
-		if historyInfo[v].Annotations == nil {
-			historyInfo[v].Annotations = make(map[string]string)
+		return &adsOfHistory.Spec.Template, err
+	})
+}
+
+// RevisionHistory returns the structured revision history of an Advanced DaemonSet
+func (h *AdvancedDaemonSetHistoryViewer) RevisionHistory(namespace, name string) (*RolloutHistory, error) {
+	ads, history, err := advancedDaemonSetHistory(h.k.AppsV1(), h.c, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	// Advanced DaemonSet only reports the hash of its update revision
+	var updateRevision string
+	for _, history := range history {
+		if history.Labels[historyHashLabel] == ads.Status.DaemonSetHash {
+			updateRevision = history.Name
 		}
-		if len(changeCause) > 0 {
-			historyInfo[v].Annotations[ChangeCauseAnnotation] = changeCause
+	}
+	return revisionHistory(h.k, ads, ads.Spec.Selector, history, "", updateRevision)
+}
+
+// DiffHistory returns a unified diff between the pod templates of two revisions of an Advanced DaemonSet
+func (h *AdvancedDaemonSetHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	ads, history, err := advancedDaemonSetHistory(h.k.AppsV1(), h.c, namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return diffHistory(history, revision1, revision2, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		adsOfHistory, err := applyAdvancedDaemonSetHistory(ads, history)
+		if err != nil {
+			return nil, err
 		}
+		return &adsOfHistory.Spec.Template, err
+	})
+}
+
+type DeploymentHistoryViewer struct {
+	c kubernetes.Interface
+}
+
+// ViewHistory returns a revision-to-replicaset map as the revision history of a deployment
+// TODO: this should be a describer
+func (h *DeploymentHistoryViewer) ViewHistory(namespace, name string, revision int64) (string, error) {
+	historyInfo, err := deploymentHistory(h.c.AppsV1(), namespace, name)
+	if err != nil {
+		return "", err
 	}
 
 	if len(historyInfo) == 0 {
@@ -225,6 +362,59 @@ func (h *DeploymentHistoryViewer) ViewHistory(namespace, name string, revision i
 	})
 }
 
+// DiffHistory returns a unified diff between the pod templates of two revisions of a deployment
+func (h *DeploymentHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	historyInfo, err := deploymentHistory(h.c.AppsV1(), namespace, name)
+	if err != nil {
+		return "", err
+	}
+	from, ok := historyInfo[revision1]
+	if !ok {
+		return "", fmt.Errorf("unable to find revision %d", revision1)
+	}
+	to, ok := historyInfo[revision2]
+	if !ok {
+		return "", fmt.Errorf("unable to find revision %d", revision2)
+	}
+	return diffTemplates(revision1, from, revision2, to)
+}
+
+// deploymentHistory returns a revision-to-podTemplate map of the replica sets owned by the deployment
+func deploymentHistory(
+	versionedAppsClient clientappsv1.AppsV1Interface,
+	namespace, name string) (map[int64]*corev1.PodTemplateSpec, error) {
+	deployment, err := versionedAppsClient.Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve deployment %s: %v", name, err)
+	}
+	_, allOldRSs, newRS, err := deploymentutil.GetAllReplicaSets(deployment, versionedAppsClient)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve replica sets from deployment %s: %v", name, err)
+	}
+	allRSs := allOldRSs
+	if newRS != nil {
+		allRSs = append(allRSs, newRS)
+	}
+
+	historyInfo := make(map[int64]*corev1.PodTemplateSpec)
+	for _, rs := range allRSs {
+		v, err := deploymentutil.Revision(rs)
+		if err != nil {
+			continue
+		}
+		historyInfo[v] = &rs.Spec.Template
+		changeCause := getChangeCause(rs)
+		if historyInfo[v].Annotations == nil {
+			historyInfo[v].Annotations = make(map[string]string)
+		}
+		if len(changeCause) > 0 {
+			historyInfo[v].Annotations[ChangeCauseAnnotation] = changeCause
+		}
+	}
+
+	return historyInfo, nil
+}
+
 func printTemplate(template *corev1.PodTemplateSpec) (string, error) {
 	buf := bytes.NewBuffer([]byte{})
 	w := describe.NewPrefixWriter(buf)
@@ -252,6 +442,21 @@ func (h *DaemonSetHistoryViewer) ViewHistory(namespace, name string, revision in
 	})
 }
 
+// DiffHistory returns a unified diff between the pod templates of two revisions of a DaemonSet
+func (h *DaemonSetHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	ds, history, err := daemonSetHistory(h.c.AppsV1(), namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return diffHistory(history, revision1, revision2, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		dsOfHistory, err := applyDaemonSetHistory(ds, history)
+		if err != nil {
+			return nil, err
+		}
+		return &dsOfHistory.Spec.Template, err
+	})
+}
+
 // printHistory returns the podTemplate of the given revision if it is non-zero
 // else returns the overall revisions
 func printHistory(history []*appsv1.ControllerRevision, revision int64, getPodTemplate func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error)) (string, error) {
@@ -299,6 +504,115 @@ func printHistory(history []*appsv1.ControllerRevision, revision int64, getPodTe
 	})
 }
 
+// revisionHistory returns the structured revision history of obj, counting the live pods of each revision
+func revisionHistory(
+	k kubernetes.Interface,
+	obj metav1.Object,
+	labelSelector *metav1.LabelSelector,
+	history []*appsv1.ControllerRevision,
+	currentRevision, updateRevision string) (*RolloutHistory, error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create selector for %s: %v", obj.GetName(), err)
+	}
+	podList, err := k.CoreV1().Pods(obj.GetNamespace()).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %v", obj.GetName(), err)
+	}
+	podsPerRevision := make(map[string]int32)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || !metav1.IsControlledBy(pod, obj) {
+			continue
+		}
+		podsPerRevision[pod.Labels[appsv1.ControllerRevisionHashLabelKey]]++
+	}
+
+	result := &RolloutHistory{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: OutputGroupVersion.String(),
+			Kind:       "RolloutHistory",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+		CurrentRevision: currentRevision,
+		UpdateRevision:  updateRevision,
+		Revisions:       make([]RevisionInfo, 0, len(history)),
+	}
+	for _, history := range history {
+		hash := history.Labels[historyHashLabel]
+		pods := podsPerRevision[history.Name]
+		// Pods may be labeled with the bare hash instead of the revision name
+		if len(hash) > 0 && hash != history.Name {
+			pods += podsPerRevision[hash]
+		}
+		result.Revisions = append(result.Revisions, RevisionInfo{
+			Revision:          history.Revision,
+			Name:              history.Name,
+			Hash:              hash,
+			CreationTimestamp: history.CreationTimestamp,
+			ChangeCause:       history.Annotations[ChangeCauseAnnotation],
+			Pods:              pods,
+			Current:           history.Name == currentRevision,
+			Update:            history.Name == updateRevision,
+		})
+	}
+	sort.Slice(result.Revisions, func(i, j int) bool {
+		return result.Revisions[i].Revision < result.Revisions[j].Revision
+	})
+	return result, nil
+}
+
+// diffHistory returns a unified diff between the podTemplates of the two given revisions
+func diffHistory(history []*appsv1.ControllerRevision, revision1, revision2 int64, getPodTemplate func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error)) (string, error) {
+	historyInfo := make(map[int64]*appsv1.ControllerRevision)
+	for _, history := range history {
+		historyInfo[history.Revision] = history
+	}
+
+	templates := make([]*corev1.PodTemplateSpec, 0, 2)
+	for _, revision := range []int64{revision1, revision2} {
+		history, ok := historyInfo[revision]
+		if !ok {
+			return "", fmt.Errorf("unable to find revision %d", revision)
+		}
+		podTemplate, err := getPodTemplate(history)
+		if err != nil {
+			return "", fmt.Errorf("unable to parse history %s", history.Name)
+		}
+		templates = append(templates, podTemplate)
+	}
+	return diffTemplates(revision1, templates[0], revision2, templates[1])
+}
+
+// diffTemplates returns a unified diff between the YAML of two podTemplates
+func diffTemplates(revision1 int64, template1 *corev1.PodTemplateSpec, revision2 int64, template2 *corev1.PodTemplateSpec) (string, error) {
+	from, err := yaml.Marshal(template1)
+	if err != nil {
+		return "", err
+	}
+	to, err := yaml.Marshal(template2)
+	if err != nil {
+		return "", err
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(from)),
+		B:        difflib.SplitLines(string(to)),
+		FromFile: fmt.Sprintf("revision %d", revision1),
+		ToFile:   fmt.Sprintf("revision %d", revision2),
+		Context:  3,
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(diff) == 0 {
+		return fmt.Sprintf("No differences found between revision %d and %d.", revision1, revision2), nil
+	}
+	return diff, nil
+}
+
 type StatefulSetHistoryViewer struct {
 	c kubernetes.Interface
 }
@@ -319,6 +633,21 @@ func (h *StatefulSetHistoryViewer) ViewHistory(namespace, name string, revision
 	})
 }
 
+// DiffHistory returns a unified diff between the pod templates of two revisions of a StatefulSet
+func (h *StatefulSetHistoryViewer) DiffHistory(namespace, name string, revision1, revision2 int64) (string, error) {
+	sts, history, err := statefulSetHistory(h.c.AppsV1(), namespace, name)
+	if err != nil {
+		return "", err
+	}
+	return diffHistory(history, revision1, revision2, func(history *appsv1.ControllerRevision) (*corev1.PodTemplateSpec, error) {
+		stsOfHistory, err := applyStatefulSetHistory(sts, history)
+		if err != nil {
+			return nil, err
+		}
+		return &stsOfHistory.Spec.Template, err
+	})
+}
+
 // controlledHistories returns all ControllerRevisions in namespace that selected by selector and owned by accessor
 // TODO: Rename this to controllerHistory when other controllers have been upgraded
 func controlledHistoryV1(
@@ -433,6 +762,32 @@ func advancedstsHistory(
 	return asts, history, nil
 }
 
+// advancedDaemonSetHistory returns the Advanced DaemonSet named name in namespace and all ControllerRevisions in its history.
+func advancedDaemonSetHistory(
+	apps clientappsv1.AppsV1Interface, cr client.Reader,
+	namespace, name string) (*kruiseappsv1alpha1.DaemonSet, []*appsv1.ControllerRevision, error) {
+	ads, found, err := fetcher.GetAdvancedDaemonSetInCache(namespace, name, cr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to retrieve Advanced DaemonSet %s: %v", name, err)
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("failed to retrieve Advanced DaemonSet %s: not found", name)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(ads.Spec.Selector)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create selector for Advanced DaemonSet %s: %v", name, err)
+	}
+	accessor, err := meta.Accessor(ads)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to obtain accessor for Advanced DaemonSet %s: %v", name, err)
+	}
+	history, err := controlledHistoryV1(apps, namespace, selector, accessor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to find history controlled by Advanced DaemonSet %s: %v", name, err)
+	}
+	return ads, history, nil
+}
+
 // statefulSetHistory returns the StatefulSet named name in namespace and all ControllerRevisions in its history.
 func statefulSetHistory(
 	apps clientappsv1.AppsV1Interface,
@@ -532,6 +887,25 @@ func applyAdvancedStatefulSetHistory(asts *kruiseappsv1beta1.StatefulSet,
 	return result, nil
 }
 
+// applyAdvancedDaemonSetHistory returns a specific revision of Advanced DaemonSet by applying the given history to a copy of the given Advanced DaemonSet
+func applyAdvancedDaemonSetHistory(ads *kruiseappsv1alpha1.DaemonSet,
+	history *appsv1.ControllerRevision) (*kruiseappsv1alpha1.DaemonSet, error) {
+	adsBytes, err := json.Marshal(ads)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(adsBytes, history.Data.Raw, ads)
+	if err != nil {
+		return nil, err
+	}
+	result := &kruiseappsv1alpha1.DaemonSet{}
+	err = json.Unmarshal(patched, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 // TODO: copied here until this becomes a describer
 func tabbedString(f func(io.Writer) error) (string, error) {
 	out := new(tabwriter.Writer)
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"strings"
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func podTemplateWithImage(image string) *corev1.PodTemplateSpec {
+	return &corev1.PodTemplateSpec{
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{Name: "nginx", Image: image}},
+		},
+	}
+}
+
+func TestDiffTemplates(t *testing.T) {
+	diff, err := diffTemplates(1, podTemplateWithImage("nginx:1.19"), 2, podTemplateWithImage("nginx:1.20"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, expected := range []string{"--- revision 1", "+++ revision 2", "-  - image: nginx:1.19", "+  - image: nginx:1.20"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("expected diff to contain %q, got:\n%s", expected, diff)
+		}
+	}
+
+	diff, err = diffTemplates(1, podTemplateWithImage("nginx:1.19"), 2, podTemplateWithImage("nginx:1.19"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff != "No differences found between revision 1 and 2." {
+		t.Errorf("unexpected diff for identical templates: %q", diff)
+	}
+}
+
+func TestRevisionHistory(t *testing.T) {
+	cs := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "cs", UID: "cs-uid"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cs"}},
+		},
+	}
+	owner := *metav1.NewControllerRef(cs, kruiseappsv1alpha1.SchemeGroupVersion.WithKind("CloneSet"))
+	newPod := func(name, revision string, ownerRefs ...metav1.OwnerReference) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace:       "bar",
+			Name:            name,
+			Labels:          map[string]string{"app": "cs", appsv1.ControllerRevisionHashLabelKey: revision},
+			OwnerReferences: ownerRefs,
+		}}
+	}
+	newRevision := func(name, hash string, revision int64, changeCause string) *appsv1.ControllerRevision {
+		return &appsv1.ControllerRevision{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   "bar",
+				Name:        name,
+				Labels:      map[string]string{historyHashLabel: hash},
+				Annotations: map[string]string{ChangeCauseAnnotation: changeCause},
+			},
+			Revision: revision,
+		}
+	}
+
+	k := fake.NewSimpleClientset(
+		newPod("cs-a", "cs-1111", owner),
+		newPod("cs-b", "cs-2222", owner),
+		newPod("cs-c", "2222", owner),
+		newPod("orphan", "cs-2222"),
+	)
+	history := []*appsv1.ControllerRevision{
+		newRevision("cs-2222", "2222", 2, "set image"),
+		newRevision("cs-1111", "1111", 1, ""),
+	}
+
+	result, err := revisionHistory(k, cs, cs.Spec.Selector, history, "cs-1111", "cs-2222")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Name != "cs" || result.Kind != "RolloutHistory" || len(result.Revisions) != 2 {
+		t.Fatalf("unexpected history: %#v", result)
+	}
+	expected := []RevisionInfo{
+		{Revision: 1, Name: "cs-1111", Hash: "1111", Pods: 1, Current: true},
+		{Revision: 2, Name: "cs-2222", Hash: "2222", ChangeCause: "set image", Pods: 2, Update: true},
+	}
+	for i := range expected {
+		if result.Revisions[i] != expected[i] {
+			t.Errorf("expected revision %#v, got %#v", expected[i], result.Revisions[i])
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/rest"
 )
@@ -100,6 +101,15 @@ type ObjectResumerFunc func(runtime.Object) ([]byte, error)
 // in case the object is already resumed.
 var ObjectResumerFn ObjectResumerFunc = defaultObjectResumer
 
+// ObjectApproverFunc is a function type that lowers the update partition of the object in a given info,
+// either by batch or down to the given partition.
+type ObjectApproverFunc func(obj runtime.Object, batch, to *intstr.IntOrString) ([]byte, error)
+
+// ObjectApproverFn gives a way to easily override the function for unit testing if needed.
+// Returns the patched object in bytes and any error that occurred during the encoding or
+// in case the object is already fully approved.
+var ObjectApproverFn ObjectApproverFunc = defaultObjectApprover
+
 // RollbackerFunc gives a way to change the rollback version of the specified RESTMapping type
 type RollbackerFunc func(restClientGetter genericclioptions.RESTClientGetter, mapping *meta.RESTMapping) (Rollbacker, error)
 
@@ -14,14 +14,21 @@ limitations under the License.
 package polymorphichelpers
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"sort"
 	"strings"
 	"time"
 
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -155,6 +162,9 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 		}
 
 		return ret, nil
+
+	case *kruiseappsv1alpha1.BroadcastJob:
+		return broadcastJobLogs(clientset, t, options, timeout, allContainers)
 	}
 
 	namespace, selector, err := SelectorsForObject(object)
@@ -174,6 +184,101 @@ func logsForObjectWithClient(clientset corev1client.CoreV1Interface, object, opt
 	return logsForObjectWithClient(clientset, pod, options, timeout, allContainers)
 }
 
+// broadcastJobControllerUIDLabel is the label the BroadcastJob controller puts the uid of the job in on its pods.
+const broadcastJobControllerUIDLabel = "broadcastjob-controller-uid"
+
+// broadcastJobLogs returns the logs of the pods of a BroadcastJob, each line prefixed with the node the pod runs on.
+func broadcastJobLogs(clientset corev1client.CoreV1Interface, job *kruiseappsv1alpha1.BroadcastJob, options runtime.Object, timeout time.Duration, allContainers bool) (map[corev1.ObjectReference]rest.ResponseWrapper, error) {
+	// the controller labels the pods with the uid of their job, the labels of the template may be
+	// empty and would select every pod in the namespace
+	selector := labels.SelectorFromSet(labels.Set{broadcastJobControllerUIDLabel: string(job.UID)})
+	podList, err := clientset.Pods(job.Namespace).List(context.TODO(), metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[corev1.ObjectReference]rest.ResponseWrapper)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !metav1.IsControlledBy(pod, job) || len(pod.Spec.NodeName) == 0 {
+			continue
+		}
+		currRet, err := logsForObjectWithClient(clientset, pod, options, timeout, allContainers)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range currRet {
+			ret[k] = &prefixedLogs{ResponseWrapper: v, prefix: []byte(fmt.Sprintf("[%s] ", pod.Spec.NodeName))}
+		}
+	}
+	if len(ret) == 0 {
+		return nil, fmt.Errorf("no pods of BroadcastJob %s have been scheduled to a node", job.Name)
+	}
+	return ret, nil
+}
+
+// prefixedLogs is a log request that prefixes every line of the log with prefix.
+type prefixedLogs struct {
+	rest.ResponseWrapper
+	prefix []byte
+}
+
+// DoRaw returns the whole log with every line prefixed.
+func (l *prefixedLogs) DoRaw(ctx context.Context) ([]byte, error) {
+	data, err := l.ResponseWrapper.DoRaw(ctx)
+	if err != nil {
+		return data, err
+	}
+	out := &bytes.Buffer{}
+	w := &prefixingWriter{prefix: l.prefix, writer: out}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// Stream returns a stream of the log with every line prefixed.
+func (l *prefixedLogs) Stream(ctx context.Context) (io.ReadCloser, error) {
+	stream, err := l.ResponseWrapper.Stream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reader, writer := io.Pipe()
+	go func() {
+		defer stream.Close()
+		_, err := io.Copy(&prefixingWriter{prefix: l.prefix, writer: writer}, stream)
+		writer.CloseWithError(err)
+	}()
+	return reader, nil
+}
+
+// prefixingWriter writes prefix at the start of every line written to writer.
+type prefixingWriter struct {
+	prefix  []byte
+	writer  io.Writer
+	midLine bool
+}
+
+func (pw *prefixingWriter) Write(p []byte) (int, error) {
+	for written := 0; written < len(p); {
+		if !pw.midLine {
+			if _, err := pw.writer.Write(pw.prefix); err != nil {
+				return written, err
+			}
+		}
+		line := p[written:]
+		if i := bytes.IndexByte(line, '\n'); i >= 0 {
+			line = line[:i+1]
+		}
+		n, err := pw.writer.Write(line)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		pw.midLine = line[len(line)-1] != '\n'
+	}
+	return len(p), nil
+}
+
 // findContainerByName searches for a container by name amongst all containers in a pod.
 // Returns a pointer to a container and a field path.
 func findContainerByName(pod *corev1.Pod, name string) (container *corev1.Container, fieldPath string) {
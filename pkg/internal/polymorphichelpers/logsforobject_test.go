@@ -0,0 +1,85 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func TestBroadcastJobLogs(t *testing.T) {
+	job := &kruiseappsv1alpha1.BroadcastJob{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.GroupVersion.String(), Kind: "BroadcastJob"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "bj", UID: types.UID("bj-uid")},
+	}
+	newPod := func(name, node string, owner types.UID) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "bar",
+				Name:            name,
+				Labels:          map[string]string{broadcastJobControllerUIDLabel: string(owner)},
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(&metav1.ObjectMeta{Name: "bj", UID: owner}, job.GroupVersionKind())},
+			},
+			Spec: corev1.PodSpec{NodeName: node, Containers: []corev1.Container{{Name: "main"}}},
+		}
+	}
+	client := fake.NewSimpleClientset(
+		newPod("bj-a", "node-1", job.UID),
+		newPod("bj-b", "", job.UID),
+		newPod("other", "node-1", "other-uid"),
+	)
+
+	logs, err := broadcastJobLogs(client.CoreV1(), job, &corev1.PodLogOptions{}, time.Second, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(logs) != 1 {
+		t.Fatalf("expected the logs of the scheduled pod bj-a only, got %v", logs)
+	}
+	for ref := range logs {
+		if ref.Name != "bj-a" {
+			t.Errorf("expected the logs of pod bj-a, got %v", ref)
+		}
+	}
+	list, ok := client.Actions()[0].(clienttesting.ListAction)
+	if !ok || list.GetListRestrictions().Labels.String() != "broadcastjob-controller-uid=bj-uid" {
+		t.Errorf("expected pods to be listed by the uid of the job, got %v", client.Actions()[0])
+	}
+}
+
+func TestPrefixingWriter(t *testing.T) {
+	out := &bytes.Buffer{}
+	w := &prefixingWriter{prefix: []byte("[node-1] "), writer: out}
+	for _, chunk := range []string{"first line\nsec", "ond line\n", "", "third\nfourth"} {
+		if _, err := w.Write([]byte(chunk)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	expected := "[node-1] first line\n[node-1] second line\n[node-1] third\n[node-1] fourth"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"errors"
+	"fmt"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/kubectl/pkg/scheme"
+)
+
+// defaultObjectApprover lowers the update partition of the object, either by batch or down to a
+// target partition. Currently only supports CloneSets and Advanced StatefulSets.
+func defaultObjectApprover(obj runtime.Object, batch, to *intstr.IntOrString) ([]byte, error) {
+	switch obj := obj.(type) {
+	case *kruiseappsv1alpha1.CloneSet:
+		replicas := int32(1)
+		if obj.Spec.Replicas != nil {
+			replicas = *obj.Spec.Replicas
+		}
+		partition, err := ApprovedPartition(obj.Spec.UpdateStrategy.Partition, batch, to, replicas)
+		if err != nil {
+			return nil, err
+		}
+		obj.Spec.UpdateStrategy.Partition = partition
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+
+	case *kruiseappsv1beta1.StatefulSet:
+		replicas := int32(1)
+		if obj.Spec.Replicas != nil {
+			replicas = *obj.Spec.Replicas
+		}
+		if obj.Spec.UpdateStrategy.RollingUpdate == nil {
+			return nil, errors.New("is already fully approved")
+		}
+		var current *intstr.IntOrString
+		if obj.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
+			p := intstr.FromInt(int(*obj.Spec.UpdateStrategy.RollingUpdate.Partition))
+			current = &p
+		}
+		partition, err := ApprovedPartition(current, batch, to, replicas)
+		if err != nil {
+			return nil, err
+		}
+		// Advanced StatefulSet partitions are ordinals, so percentages are resolved here.
+		value, err := PartitionValue(partition, replicas)
+		if err != nil {
+			return nil, err
+		}
+		obj.Spec.UpdateStrategy.RollingUpdate.Partition = &value
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1beta1.SchemeGroupVersion), obj)
+
+	default:
+		return nil, fmt.Errorf("approving is not supported")
+	}
+}
+
+// ApprovedPartition returns the partition that results from lowering current either by batch or
+// down to to. Exactly one of batch and to must be set. Percentages are kept as percentages as long
+// as current and the step agree on the type, otherwise both are resolved against replicas.
+func ApprovedPartition(current, batch, to *intstr.IntOrString, replicas int32) (*intstr.IntOrString, error) {
+	if (batch == nil) == (to == nil) {
+		return nil, errors.New("exactly one of batch and to must be specified")
+	}
+	currentValue, err := PartitionValue(current, replicas)
+	if err != nil {
+		return nil, err
+	}
+	if currentValue == 0 {
+		return nil, errors.New("is already fully approved")
+	}
+
+	if to != nil {
+		toValue, err := PartitionValue(to, replicas)
+		if err != nil {
+			return nil, err
+		}
+		if toValue >= currentValue {
+			return nil, fmt.Errorf("partition %s is not lower than the current partition %s", to.String(), current.String())
+		}
+		result := *to
+		return &result, nil
+	}
+
+	if current.Type == intstr.String && batch.Type == intstr.String {
+		currentPercent, err := percentValue(current)
+		if err != nil {
+			return nil, err
+		}
+		batchPercent, err := percentValue(batch)
+		if err != nil {
+			return nil, err
+		}
+		if batchPercent <= 0 {
+			return nil, fmt.Errorf("batch must be greater than zero: %s", batch.String())
+		}
+		result := intstr.FromString(fmt.Sprintf("%d%%", maxInt(currentPercent-batchPercent, 0)))
+		return &result, nil
+	}
+
+	batchValue, err := intstr.GetValueFromIntOrPercent(batch, int(replicas), true)
+	if err != nil {
+		return nil, err
+	}
+	if batchValue <= 0 {
+		return nil, fmt.Errorf("batch must be greater than zero: %s", batch.String())
+	}
+	result := intstr.FromInt(maxInt(int(currentValue)-batchValue, 0))
+	return &result, nil
+}
+
+// PartitionValue resolves a partition against replicas, rounding percentages up as the Kruise
+// controllers do. A nil partition means no pods are held back.
+func PartitionValue(partition *intstr.IntOrString, replicas int32) (int32, error) {
+	if partition == nil {
+		return 0, nil
+	}
+	value, err := intstr.GetValueFromIntOrPercent(partition, int(replicas), true)
+	if err != nil {
+		return 0, fmt.Errorf("invalid partition %s: %v", partition.String(), err)
+	}
+	if value < 0 {
+		return 0, fmt.Errorf("partition must not be negative: %s", partition.String())
+	}
+	if value > int(replicas) {
+		value = int(replicas)
+	}
+	return int32(value), nil
+}
+
+func percentValue(value *intstr.IntOrString) (int, error) {
+	return intstr.GetValueFromIntOrPercent(value, 100, true)
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func intOrStrPtr(s string) *intstr.IntOrString {
+	v := intstr.Parse(s)
+	return &v
+}
+
+func TestApprovedPartition(t *testing.T) {
+	tests := []struct {
+		name      string
+		current   *intstr.IntOrString
+		batch     *intstr.IntOrString
+		to        *intstr.IntOrString
+		replicas  int32
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "lower int partition by int batch",
+			current:  intOrStrPtr("8"),
+			batch:    intOrStrPtr("3"),
+			replicas: 10,
+			expected: "5",
+		},
+		{
+			name:     "lower int partition by percent batch",
+			current:  intOrStrPtr("8"),
+			batch:    intOrStrPtr("25%"),
+			replicas: 10,
+			expected: "5",
+		},
+		{
+			name:     "lower percent partition by percent batch",
+			current:  intOrStrPtr("80%"),
+			batch:    intOrStrPtr("20%"),
+			replicas: 10,
+			expected: "60%",
+		},
+		{
+			name:     "lower percent partition by int batch",
+			current:  intOrStrPtr("80%"),
+			batch:    intOrStrPtr("2"),
+			replicas: 10,
+			expected: "6",
+		},
+		{
+			name:     "batch larger than partition",
+			current:  intOrStrPtr("2"),
+			batch:    intOrStrPtr("5"),
+			replicas: 10,
+			expected: "0",
+		},
+		{
+			name:     "lower partition to target",
+			current:  intOrStrPtr("8"),
+			to:       intOrStrPtr("50%"),
+			replicas: 10,
+			expected: "50%",
+		},
+		{
+			name:      "target not lower than partition",
+			current:   intOrStrPtr("4"),
+			to:        intOrStrPtr("50%"),
+			replicas:  10,
+			expectErr: true,
+		},
+		{
+			name:      "already fully approved",
+			batch:     intOrStrPtr("1"),
+			replicas:  10,
+			expectErr: true,
+		},
+		{
+			name:      "both batch and to",
+			current:   intOrStrPtr("4"),
+			batch:     intOrStrPtr("1"),
+			to:        intOrStrPtr("1"),
+			replicas:  10,
+			expectErr: true,
+		},
+		{
+			name:      "invalid batch",
+			current:   intOrStrPtr("4"),
+			batch:     intOrStrPtr("abc"),
+			replicas:  10,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partition, err := ApprovedPartition(tt.current, tt.batch, tt.to, tt.replicas)
+			if tt.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got partition %s", partition.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if partition.String() != tt.expected {
+				t.Errorf("expected partition %s, got %s", tt.expected, partition.String())
+			}
+		})
+	}
+}
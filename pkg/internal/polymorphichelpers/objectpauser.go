@@ -72,6 +72,29 @@ func defaultObjectPauser(obj runtime.Object) ([]byte, error) {
 		}
 		obj.Spec.UpdateStrategy.RollingUpdate.Paused = true
 		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1beta1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.SidecarSet:
+		if obj.Spec.UpdateStrategy.Paused {
+			return nil, errors.New("is already paused")
+		}
+		obj.Spec.UpdateStrategy.Paused = true
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.DaemonSet:
+		if obj.Spec.UpdateStrategy.RollingUpdate == nil {
+			obj.Spec.UpdateStrategy.RollingUpdate = &kruiseappsv1alpha1.RollingUpdateDaemonSet{}
+		}
+		if paused := obj.Spec.UpdateStrategy.RollingUpdate.Paused; paused != nil && *paused {
+			return nil, errors.New("is already paused")
+		}
+		paused := true
+		obj.Spec.UpdateStrategy.RollingUpdate.Paused = &paused
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.AdvancedCronJob:
+		if obj.Spec.Paused != nil && *obj.Spec.Paused {
+			return nil, errors.New("is already paused")
+		}
+		paused := true
+		obj.Spec.Paused = &paused
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
 
 	default:
 		return nil, fmt.Errorf("pausing is not supported")
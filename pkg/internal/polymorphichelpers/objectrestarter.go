@@ -125,8 +125,15 @@ func defaultObjectRestarter(obj runtime.Object) ([]byte, error) {
 			obj.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
 		}
 
-		// TODO is InPlaceOnly UpdateStrategy spport rollout restart?
-
+		// Bumping a template annotation recreates the pods with the ReCreate update type, while
+		// InPlaceIfPossible and InPlaceOnly only update pod metadata in place and leave the
+		// containers running, use 'rollout restart --in-place' to restart them.
+		obj.Spec.Template.ObjectMeta.Annotations["kubectl.kruise.io/restartedAt"] = time.Now().Format(time.RFC3339)
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.DaemonSet:
+		if obj.Spec.Template.ObjectMeta.Annotations == nil {
+			obj.Spec.Template.ObjectMeta.Annotations = make(map[string]string)
+		}
 		obj.Spec.Template.ObjectMeta.Annotations["kubectl.kruise.io/restartedAt"] = time.Now().Format(time.RFC3339)
 		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
 
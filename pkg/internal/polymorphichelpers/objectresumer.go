@@ -71,6 +71,27 @@ func defaultObjectResumer(obj runtime.Object) ([]byte, error) {
 		}
 		obj.Spec.UpdateStrategy.RollingUpdate.Paused = false
 		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1beta1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.SidecarSet:
+		if !obj.Spec.UpdateStrategy.Paused {
+			return nil, errors.New("is not paused")
+		}
+		obj.Spec.UpdateStrategy.Paused = false
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.DaemonSet:
+		rollingUpdate := obj.Spec.UpdateStrategy.RollingUpdate
+		if rollingUpdate == nil || rollingUpdate.Paused == nil || !*rollingUpdate.Paused {
+			return nil, errors.New("is not paused")
+		}
+		paused := false
+		rollingUpdate.Paused = &paused
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
+	case *kruiseappsv1alpha1.AdvancedCronJob:
+		if obj.Spec.Paused == nil || !*obj.Spec.Paused {
+			return nil, errors.New("is not paused")
+		}
+		paused := false
+		obj.Spec.Paused = &paused
+		return runtime.Encode(scheme.Codecs.LegacyCodec(kruiseappsv1alpha1.SchemeGroupVersion), obj)
 
 	default:
 		return nil, fmt.Errorf("resuming is not supported")
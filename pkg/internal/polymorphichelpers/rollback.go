@@ -21,6 +21,8 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
 	internalapps "github.com/hantmac/kubectl-kruise/pkg/internal/apps"
@@ -54,6 +56,12 @@ type Rollbacker interface {
 	Rollback(obj runtime.Object, updatedAnnotations map[string]string, toRevision int64, dryRunStrategy cmdutil.DryRunStrategy) (string, error)
 }
 
+// RevisionNameRollbacker is implemented by Rollbackers that can also roll back to a ControllerRevision
+// identified by its name or hash.
+type RevisionNameRollbacker interface {
+	RollbackToRevisionName(obj runtime.Object, updatedAnnotations map[string]string, toRevisionName string, dryRunStrategy cmdutil.DryRunStrategy) (string, error)
+}
+
 type RollbackVisitor struct {
 	clientset kubernetes.Interface
 	cr        client.Reader
@@ -91,6 +99,13 @@ func (v *RollbackVisitor) VisitAdvancedStatefulSet(kind internalapps.GroupKindEl
 	v.result = &AdvancedStatefulSetRollbacker{cr: cr, c: c, k: v.clientset}
 }
 
+func (v *RollbackVisitor) VisitAdvancedDaemonSet(kind internalapps.GroupKindElement) {
+	mgr := internalclient.NewManager()
+	cr := mgr.GetAPIReader()
+	c := mgr.GetClient()
+	v.result = &AdvancedDaemonSetRollbacker{cr: cr, c: c, k: v.clientset}
+}
+
 // RollbackerFor returns an implementation of Rollbacker interface for the given schema kind
 func RollbackerFor(kind schema.GroupKind, c kubernetes.Interface) (Rollbacker, error) {
 	elem := internalapps.GroupKindElement(kind)
@@ -166,6 +181,9 @@ func (r *DeploymentRollbacker) Rollback(obj runtime.Object, updatedAnnotations m
 			annotations[k] = v
 		}
 	}
+	for k, v := range updatedAnnotations {
+		annotations[k] = v
+	}
 
 	// make patch to restore
 	patchType, patch, err := getDeploymentPatch(&rsForRevision.Spec.Template, annotations)
@@ -322,7 +340,11 @@ func (r *DaemonSetRollbacker) Rollback(obj runtime.Object, updatedAnnotations ma
 		patchOptions.DryRun = []string{metav1.DryRunAll}
 	}
 	// Restore revision
-	if _, err = r.c.AppsV1().DaemonSets(accessor.GetNamespace()).Patch(context.TODO(), accessor.GetName(), types.StrategicMergePatchType, toHistory.Data.Raw, patchOptions); err != nil {
+	patch, err := patchWithAnnotations(toHistory.Data.Raw, updatedAnnotations)
+	if err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
+	}
+	if _, err = r.c.AppsV1().DaemonSets(accessor.GetNamespace()).Patch(context.TODO(), accessor.GetName(), types.StrategicMergePatchType, patch, patchOptions); err != nil {
 		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
 	}
 
@@ -413,7 +435,11 @@ func (r *StatefulSetRollbacker) Rollback(obj runtime.Object, updatedAnnotations
 		patchOptions.DryRun = []string{metav1.DryRunAll}
 	}
 	// Restore revision
-	if _, err = r.c.AppsV1().StatefulSets(sts.Namespace).Patch(context.TODO(), sts.Name, types.StrategicMergePatchType, toHistory.Data.Raw, patchOptions); err != nil {
+	patch, err := patchWithAnnotations(toHistory.Data.Raw, updatedAnnotations)
+	if err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
+	}
+	if _, err = r.c.AppsV1().StatefulSets(sts.Namespace).Patch(context.TODO(), sts.Name, types.StrategicMergePatchType, patch, patchOptions); err != nil {
 		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
 	}
 
@@ -450,10 +476,43 @@ func (r *AdvancedStatefulSetRollbacker) Rollback(obj runtime.Object,
 	if toRevision == 0 && len(history) <= 1 {
 		return "", fmt.Errorf("no latest revision to roll back to")
 	}
-	toHistory := findHistory(toRevision, history)
+	toHistory, err := findHistoryByRevisionOrName(toRevision, history)
+	if err != nil {
+		return "", err
+	}
 	if toHistory == nil {
 		return "", revisionNotFoundErr(toRevision)
 	}
+	return r.rollbackTo(asts, toHistory, updatedAnnotations, dryRunStrategy)
+}
+
+// RollbackToRevisionName rolls the Advanced StatefulSet back to the ControllerRevision with the given name or hash
+func (r *AdvancedStatefulSetRollbacker) RollbackToRevisionName(obj runtime.Object,
+	updatedAnnotations map[string]string,
+	toRevisionName string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to create accessor for kind %v: %s", obj.GetObjectKind(), err.Error())
+	}
+	asts, history, err := advancedstsHistory(r.k.AppsV1(), r.cr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return "", err
+	}
+	toHistory, err := findHistoryByName(toRevisionName, history)
+	if err != nil {
+		return "", err
+	}
+	if toHistory == nil {
+		return "", revisionNameNotFoundErr(toRevisionName)
+	}
+	return r.rollbackTo(asts, toHistory, updatedAnnotations, dryRunStrategy)
+}
+
+func (r *AdvancedStatefulSetRollbacker) rollbackTo(asts *kruiseappsv1beta1.StatefulSet,
+	toHistory *appsv1.ControllerRevision,
+	updatedAnnotations map[string]string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
 	if dryRunStrategy == cmdutil.DryRunClient {
 		appliedSS, err := applyAdvancedStatefulSetRevision(asts, toHistory)
 		if err != nil {
@@ -461,25 +520,28 @@ func (r *AdvancedStatefulSetRollbacker) Rollback(obj runtime.Object,
 		}
 		return printPodTemplate(&appliedSS.Spec.Template)
 	}
-	// Skip if the revision already matches current CloneSet
+	// Skip if the revision already matches current Advanced StatefulSet
 	done, err := astsMatch(asts, toHistory)
 	if err != nil {
 		return "", err
 	}
 	if done {
-		return fmt.Sprintf("%s (current template already matches revision %d)", rollbackSkipped, toRevision), nil
+		return fmt.Sprintf("%s (current template already matches revision %d)", rollbackSkipped, toHistory.Revision), nil
+	}
+
+	patch, err := patchWithAnnotations(toHistory.Data.Raw, updatedAnnotations)
+	if err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
 	}
 
 	// Restore revision
+	var opts []client.PatchOption
 	if dryRunStrategy == cmdutil.DryRunServer {
-		if err = r.c.Patch(context.TODO(), asts, client.RawPatch(types.MergePatchType,
-			toHistory.Data.Raw), client.DryRunAll); err != nil {
-			return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
-		}
+		opts = append(opts, client.DryRunAll)
 	}
 	if err = r.c.Patch(context.TODO(), asts, client.RawPatch(types.MergePatchType,
-		toHistory.Data.Raw)); err != nil {
-		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
+		patch), opts...); err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
 	}
 
 	return rollbackSuccess, nil
@@ -504,11 +566,43 @@ func (r *CloneSetRollbacker) Rollback(obj runtime.Object,
 	if toRevision == 0 && len(history) <= 1 {
 		return "", fmt.Errorf("no last revision to roll back to")
 	}
-	toHistory := findHistory(toRevision, history)
+	toHistory, err := findHistoryByRevisionOrName(toRevision, history)
+	if err != nil {
+		return "", err
+	}
 	if toHistory == nil {
 		return "", revisionNotFoundErr(toRevision)
 	}
+	return r.rollbackTo(cs, toHistory, updatedAnnotations, dryRunStrategy)
+}
+
+// RollbackToRevisionName rolls the CloneSet back to the ControllerRevision with the given name or hash
+func (r *CloneSetRollbacker) RollbackToRevisionName(obj runtime.Object,
+	updatedAnnotations map[string]string,
+	toRevisionName string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to create accessor for kind %v: %s", obj.GetObjectKind(), err.Error())
+	}
+	cs, history, err := clonesetHistory(r.k.AppsV1(), r.cr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return "", err
+	}
+	toHistory, err := findHistoryByName(toRevisionName, history)
+	if err != nil {
+		return "", err
+	}
+	if toHistory == nil {
+		return "", revisionNameNotFoundErr(toRevisionName)
+	}
+	return r.rollbackTo(cs, toHistory, updatedAnnotations, dryRunStrategy)
+}
 
+func (r *CloneSetRollbacker) rollbackTo(cs *kruiseappsv1alpha1.CloneSet,
+	toHistory *appsv1.ControllerRevision,
+	updatedAnnotations map[string]string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
 	if dryRunStrategy == cmdutil.DryRunClient {
 		appliedSS, err := applyCloneSetRevision(cs, toHistory)
 		if err != nil {
@@ -523,19 +617,118 @@ func (r *CloneSetRollbacker) Rollback(obj runtime.Object,
 		return "", err
 	}
 	if done {
-		return fmt.Sprintf("%s (current template already matches revision %d)", rollbackSkipped, toRevision), nil
+		return fmt.Sprintf("%s (current template already matches revision %d)", rollbackSkipped, toHistory.Revision), nil
+	}
+
+	patch, err := patchWithAnnotations(toHistory.Data.Raw, updatedAnnotations)
+	if err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
 	}
 
 	// Restore revision
+	var opts []client.PatchOption
 	if dryRunStrategy == cmdutil.DryRunServer {
-		if err = r.c.Patch(context.TODO(), cs, client.RawPatch(types.MergePatchType,
-			toHistory.Data.Raw), client.DryRunAll); err != nil {
-			return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
-		}
+		opts = append(opts, client.DryRunAll)
 	}
 	if err = r.c.Patch(context.TODO(), cs, client.RawPatch(types.MergePatchType,
-		toHistory.Data.Raw)); err != nil {
-		return "", fmt.Errorf("failed restoring revision %d: %v", toRevision, err)
+		patch), opts...); err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
+	}
+
+	return rollbackSuccess, nil
+}
+
+type AdvancedDaemonSetRollbacker struct {
+	cr client.Reader
+	c  client.Client
+	k  kubernetes.Interface
+}
+
+func (r *AdvancedDaemonSetRollbacker) Rollback(obj runtime.Object,
+	updatedAnnotations map[string]string,
+	toRevision int64,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
+	if toRevision < 0 {
+		return "", revisionNotFoundErr(toRevision)
+	}
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to create accessor for kind %v: %s", obj.GetObjectKind(), err.Error())
+	}
+	ads, history, err := advancedDaemonSetHistory(r.k.AppsV1(), r.cr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return "", err
+	}
+	if toRevision == 0 && len(history) <= 1 {
+		return "", fmt.Errorf("no last revision to roll back to")
+	}
+	toHistory, err := findHistoryByRevisionOrName(toRevision, history)
+	if err != nil {
+		return "", err
+	}
+	if toHistory == nil {
+		return "", revisionNotFoundErr(toRevision)
+	}
+	return r.rollbackTo(ads, toHistory, updatedAnnotations, dryRunStrategy)
+}
+
+// RollbackToRevisionName rolls the Advanced DaemonSet back to the ControllerRevision with the given name or hash
+func (r *AdvancedDaemonSetRollbacker) RollbackToRevisionName(obj runtime.Object,
+	updatedAnnotations map[string]string,
+	toRevisionName string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", fmt.Errorf("failed to create accessor for kind %v: %s", obj.GetObjectKind(), err.Error())
+	}
+	ads, history, err := advancedDaemonSetHistory(r.k.AppsV1(), r.cr, accessor.GetNamespace(), accessor.GetName())
+	if err != nil {
+		return "", err
+	}
+	toHistory, err := findHistoryByName(toRevisionName, history)
+	if err != nil {
+		return "", err
+	}
+	if toHistory == nil {
+		return "", revisionNameNotFoundErr(toRevisionName)
+	}
+	return r.rollbackTo(ads, toHistory, updatedAnnotations, dryRunStrategy)
+}
+
+func (r *AdvancedDaemonSetRollbacker) rollbackTo(ads *kruiseappsv1alpha1.DaemonSet,
+	toHistory *appsv1.ControllerRevision,
+	updatedAnnotations map[string]string,
+	dryRunStrategy cmdutil.DryRunStrategy) (string, error) {
+	if dryRunStrategy == cmdutil.DryRunClient {
+		appliedDS, err := applyAdvancedDaemonSetRevision(ads, toHistory)
+		if err != nil {
+			return "", err
+		}
+		return printPodTemplate(&appliedDS.Spec.Template)
+	}
+
+	// Skip if the revision already matches current Advanced DaemonSet
+	done, err := advancedDaemonSetMatch(ads, toHistory)
+	if err != nil {
+		return "", err
+	}
+	if done {
+		return fmt.Sprintf("%s (current template already matches revision %d)", rollbackSkipped, toHistory.Revision), nil
+	}
+
+	patch, err := patchWithAnnotations(toHistory.Data.Raw, updatedAnnotations)
+	if err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
+	}
+
+	// Restore revision
+	var opts []client.PatchOption
+	if dryRunStrategy == cmdutil.DryRunServer {
+		opts = append(opts, client.DryRunAll)
+	}
+	if err = r.c.Patch(context.TODO(), ads, client.RawPatch(types.MergePatchType,
+		patch), opts...); err != nil {
+		return "", fmt.Errorf("failed restoring revision %d: %v", toHistory.Revision, err)
 	}
 
 	return rollbackSuccess, nil
@@ -576,6 +769,22 @@ func applyCloneSetRevision(cs *kruiseappsv1alpha1.CloneSet,
 	return result, nil
 }
 
+// applyAdvancedDaemonSetRevision returns a new Advanced DaemonSet constructed by restoring the state in revision to ads.
+// If the returned error is nil, the returned Advanced DaemonSet is valid.
+func applyAdvancedDaemonSetRevision(ads *kruiseappsv1alpha1.DaemonSet,
+	revision *appsv1.ControllerRevision) (*kruiseappsv1alpha1.DaemonSet, error) {
+	patched, err := strategicpatch.StrategicMergePatch([]byte(runtime.EncodeOrDie(kruiseAppsCodec, ads)), revision.Data.Raw, ads)
+	if err != nil {
+		return nil, err
+	}
+	result := &kruiseappsv1alpha1.DaemonSet{}
+	err = json.Unmarshal(patched, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
 var kruisev1beta1AppsCodec = scheme.Codecs.LegacyCodec(kruiseappsv1beta1.SchemeGroupVersion)
 
 // apply  applyAdvancedStatefulSetRevision returns a new Advanced StatefulSet constructed by restoring the state in revision to set.
@@ -591,7 +800,7 @@ func applyAdvancedStatefulSetRevision(asts *kruiseappsv1beta1.StatefulSet,
 	if err != nil {
 		return nil, err
 	}
-	return nil, err
+	return result, nil
 }
 
 // statefulsetMatch check if the given StatefulSet's template matches the template stored in the given history.
@@ -621,6 +830,15 @@ func astsMatch(asts *kruiseappsv1beta1.StatefulSet, history *appsv1.ControllerRe
 	return bytes.Equal(patch, history.Data.Raw), nil
 }
 
+// advancedDaemonSetMatch check if the given Advanced DaemonSet's template matches the template stored in the given history.
+func advancedDaemonSetMatch(ads *kruiseappsv1alpha1.DaemonSet, history *appsv1.ControllerRevision) (bool, error) {
+	patch, err := getAdvancedDaemonSetPatch(ads)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(patch, history.Data.Raw), nil
+}
+
 // getStatefulSetPatch returns a strategic merge patch that can be applied to restore a StatefulSet to a
 // previous version. If the returned error is nil the patch is valid. The current state that we save is just the
 // PodSpecTemplate. We can modify this later to encompass more state (or less) and remain compatible with previously
@@ -691,6 +909,28 @@ func getAdvancedStatefulSetPatch(asts *kruiseappsv1beta1.StatefulSet) ([]byte, e
 	return patch, err
 }
 
+// getAdvancedDaemonSetPatch returns a strategic merge patch that can be applied to restore an Advanced DaemonSet to
+// a previous version.
+func getAdvancedDaemonSetPatch(ads *kruiseappsv1alpha1.DaemonSet) ([]byte, error) {
+	str, err := runtime.Encode(kruiseAppsCodec, ads)
+	if err != nil {
+		return nil, err
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(str), &raw); err != nil {
+		return nil, err
+	}
+	objCopy := make(map[string]interface{})
+	specCopy := make(map[string]interface{})
+	spec := raw["spec"].(map[string]interface{})
+	template := spec["template"].(map[string]interface{})
+	specCopy["template"] = template
+	template["$patch"] = "replace"
+	objCopy["spec"] = specCopy
+	patch, err := json.Marshal(objCopy)
+	return patch, err
+}
+
 // findHistory returns a controllerrevision of a specific revision from the given controllerrevisions.
 // It returns nil if no such controllerrevision exists.
 // If toRevision is 0, the last previously used history is returned.
@@ -717,6 +957,68 @@ func findHistory(toRevision int64, allHistory []*appsv1.ControllerRevision) *app
 	return toHistory
 }
 
+// patchWithAnnotations returns the given revision patch with annotations added to the object metadata,
+// so that they are carried over to the next ControllerRevision.
+func patchWithAnnotations(patch []byte, annotations map[string]string) ([]byte, error) {
+	if len(annotations) == 0 {
+		return patch, nil
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal(patch, &raw); err != nil {
+		return nil, err
+	}
+	metadata, _ := raw["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	patchAnnotations, _ := metadata["annotations"].(map[string]interface{})
+	if patchAnnotations == nil {
+		patchAnnotations = make(map[string]interface{})
+	}
+	for k, v := range annotations {
+		patchAnnotations[k] = v
+	}
+	metadata["annotations"] = patchAnnotations
+	raw["metadata"] = metadata
+	return json.Marshal(raw)
+}
+
+// findHistoryByRevisionOrName returns the controllerrevision of a specific revision from the given
+// controllerrevisions, or else the one with that number as its name or hash: the hashes of Kruise
+// revisions may consist of digits only. It returns nil if no such controllerrevision exists.
+func findHistoryByRevisionOrName(toRevision int64, allHistory []*appsv1.ControllerRevision) (*appsv1.ControllerRevision, error) {
+	if toHistory := findHistory(toRevision, allHistory); toHistory != nil || toRevision == 0 {
+		return toHistory, nil
+	}
+	return findHistoryByName(strconv.FormatInt(toRevision, 10), allHistory)
+}
+
+// findHistoryByName returns the controllerrevision with the given name or hash from the given controllerrevisions.
+// It returns an error if name is not a full name and matches more than one of them.
+func findHistoryByName(name string, allHistory []*appsv1.ControllerRevision) (*appsv1.ControllerRevision, error) {
+	var matches []*appsv1.ControllerRevision
+	for _, h := range allHistory {
+		if h.Name == name {
+			return h, nil
+		}
+		// Revisions are named <workload>-<hash>, so the bare hash is accepted as well
+		if h.Labels[historyHashLabel] == name || strings.HasSuffix(h.Name, "-"+name) {
+			matches = append(matches, h)
+		}
+	}
+	if len(matches) > 1 {
+		names := make([]string, 0, len(matches))
+		for _, h := range matches {
+			names = append(names, h.Name)
+		}
+		return nil, fmt.Errorf("revision %q is ambiguous, it matches %s", name, strings.Join(names, ", "))
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+	return matches[0], nil
+}
+
 // printPodTemplate converts a given pod template into a human-readable string.
 func printPodTemplate(specTemplate *corev1.PodTemplateSpec) (string, error) {
 	podSpec, err := printTemplate(specTemplate)
@@ -730,6 +1032,10 @@ func revisionNotFoundErr(r int64) error {
 	return fmt.Errorf("unable to find specified revision %v in history", r)
 }
 
+func revisionNameNotFoundErr(name string) error {
+	return fmt.Errorf("unable to find specified revision %q in history", name)
+}
+
 // TODO: copied from daemon controller, should extract to a library
 type historiesByRevision []*appsv1.ControllerRevision
 
@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"context"
+	"testing"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+func TestFindHistoryByName(t *testing.T) {
+	history := []*appsv1.ControllerRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-5d8f9c", Labels: map[string]string{historyHashLabel: "5d8f9c"}}, Revision: 1},
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-7b6c4d"}, Revision: 2},
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-1-99"}, Revision: 3},
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-2-99"}, Revision: 4},
+	}
+
+	tests := []struct {
+		name        string
+		expected    int64
+		expectedErr string
+	}{
+		{name: "abc-5d8f9c", expected: 1},
+		{name: "5d8f9c", expected: 1},
+		{name: "7b6c4d", expected: 2},
+		{name: "abc-1-99", expected: 3},
+		{name: "abc-unknown", expected: 0},
+		{name: "99", expectedErr: `revision "99" is ambiguous, it matches abc-1-99, abc-2-99`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			found, err := findHistoryByName(test.name, history)
+			if len(test.expectedErr) > 0 {
+				if err == nil || err.Error() != test.expectedErr {
+					t.Fatalf("expected error %q, got %v", test.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if test.expected == 0 {
+				if found != nil {
+					t.Fatalf("expected no revision, got %s", found.Name)
+				}
+				return
+			}
+			if found == nil || found.Revision != test.expected {
+				t.Fatalf("expected revision %d, got %v", test.expected, found)
+			}
+		})
+	}
+}
+
+func TestFindHistoryByRevisionOrName(t *testing.T) {
+	history := []*appsv1.ControllerRevision{
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-5d8f9c", Labels: map[string]string{historyHashLabel: "5d8f9c"}}, Revision: 1},
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-2465", Labels: map[string]string{historyHashLabel: "2465"}}, Revision: 2},
+		{ObjectMeta: metav1.ObjectMeta{Name: "abc-7b6c4d", Labels: map[string]string{historyHashLabel: "7b6c4d"}}, Revision: 3},
+	}
+
+	tests := []struct {
+		toRevision int64
+		expected   int64
+	}{
+		{toRevision: 1, expected: 1},
+		{toRevision: 3, expected: 3},
+		{toRevision: 2465, expected: 2},
+		{toRevision: 24, expected: 0},
+	}
+	for _, test := range tests {
+		found, err := findHistoryByRevisionOrName(test.toRevision, history)
+		if err != nil {
+			t.Fatalf("revision %d: unexpected error: %v", test.toRevision, err)
+		}
+		if test.expected == 0 {
+			if found != nil {
+				t.Errorf("revision %d: expected no revision, got %s", test.toRevision, found.Name)
+			}
+			continue
+		}
+		if found == nil || found.Revision != test.expected {
+			t.Errorf("revision %d: expected revision %d, got %v", test.toRevision, test.expected, found)
+		}
+	}
+}
+
+func TestPatchWithAnnotations(t *testing.T) {
+	patch := []byte(`{"spec":{"template":{"$patch":"replace","spec":{"containers":[{"name":"nginx","image":"nginx:1.19"}]}}}}`)
+
+	unchanged, err := patchWithAnnotations(patch, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(unchanged) != string(patch) {
+		t.Errorf("expected patch to be unchanged, got %s", unchanged)
+	}
+
+	annotated, err := patchWithAnnotations(patch, map[string]string{ChangeCauseAnnotation: "roll back"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := `{"metadata":{"annotations":{"kubernetes.io/change-cause":"roll back"}},"spec":{"template":{"$patch":"replace","spec":{"containers":[{"image":"nginx:1.19","name":"nginx"}]}}}}`
+	if string(annotated) != expected {
+		t.Errorf("expected %s, got %s", expected, annotated)
+	}
+}
+
+func TestApplyAdvancedStatefulSetRevision(t *testing.T) {
+	asts := &kruiseappsv1beta1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "asts"},
+		Spec: kruiseappsv1beta1.StatefulSetSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.19"}}}},
+		},
+	}
+	data, err := getAdvancedStatefulSetPatch(asts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	asts.Spec.Template.Spec.Containers[0].Image = "nginx:1.20"
+
+	applied, err := applyAdvancedStatefulSetRevision(asts, &appsv1.ControllerRevision{Data: runtime.RawExtension{Raw: data}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if applied == nil || applied.Spec.Template.Spec.Containers[0].Image != "nginx:1.19" {
+		t.Errorf("expected the template of the revision to be restored, got %v", applied)
+	}
+}
+
+// patchRecorder records the options of the patches it is asked to make
+type patchRecorder struct {
+	client.Client
+	patches []*client.PatchOptions
+}
+
+func (r *patchRecorder) Patch(_ context.Context, _ runtime.Object, _ client.Patch, opts ...client.PatchOption) error {
+	r.patches = append(r.patches, (&client.PatchOptions{}).ApplyOptions(opts))
+	return nil
+}
+
+func TestCloneSetRollbackServerDryRun(t *testing.T) {
+	cs := &kruiseappsv1alpha1.CloneSet{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "cs"},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "nginx", Image: "nginx:1.19"}}}},
+		},
+	}
+	data, err := getCloneSetPatch(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cs.Spec.Template.Spec.Containers[0].Image = "nginx:1.20"
+
+	recorder := &patchRecorder{}
+	r := &CloneSetRollbacker{c: recorder}
+	if _, err := r.rollbackTo(cs, &appsv1.ControllerRevision{Revision: 1, Data: runtime.RawExtension{Raw: data}}, nil, cmdutil.DryRunServer); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(recorder.patches) != 1 || len(recorder.patches[0].DryRun) != 1 || recorder.patches[0].DryRun[0] != metav1.DryRunAll {
+		t.Errorf("expected a single dry run patch, got %#v", recorder.patches)
+	}
+}
@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	podutil "k8s.io/kubectl/pkg/util/podutils"
+)
+
+// RolloutIssue is a cause that keeps pods of a rollout from becoming ready.
+type RolloutIssue struct {
+	// Reason is a short, machine-readable cause, e.g. ImagePullBackOff or Unschedulable.
+	Reason string `json:"reason"`
+	// Message is the detail reported for the first of the pods.
+	Message string `json:"message,omitempty"`
+	// Pods are the names of the pods affected by the issue.
+	Pods []string `json:"pods"`
+	// Terminal is true if the rollout is not expected to recover without intervention.
+	Terminal bool `json:"terminal"`
+}
+
+// String returns a single line description of the issue.
+func (i RolloutIssue) String() string {
+	if len(i.Message) == 0 {
+		return fmt.Sprintf("%s: %s", i.Reason, strings.Join(i.Pods, ", "))
+	}
+	return fmt.Sprintf("%s: %s: %s", i.Reason, strings.Join(i.Pods, ", "), i.Message)
+}
+
+const (
+	// reasonReadinessProbeFailed is reported for running pods whose readiness probe fails.
+	reasonReadinessProbeFailed = "ReadinessProbeFailed"
+	// reasonUnschedulable is reported for pods the scheduler can not place.
+	reasonUnschedulable = "Unschedulable"
+)
+
+// waitingReasons are the container waiting reasons that keep a pod from becoming ready, mapped to
+// whether a rollout is not expected to recover from them without a change to the workload.
+var waitingReasons = map[string]bool{
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CrashLoopBackOff":           true,
+	"CreateContainerConfigError": true,
+	"ErrImagePull":               false,
+	"CreateContainerError":       false,
+	"RunContainerError":          false,
+}
+
+// RolloutDiagnoser inspects the pods and events of a workload to tell why its rollout is stuck.
+type RolloutDiagnoser struct {
+	Client kubernetes.Interface
+}
+
+// Diagnose returns the issues that keep the pods of obj from becoming ready, sorted by reason.
+func (d *RolloutDiagnoser) Diagnose(obj runtime.Unstructured) ([]RolloutIssue, error) {
+	u := &unstructured.Unstructured{Object: obj.UnstructuredContent()}
+	selectorMap, found, err := unstructured.NestedMap(u.Object, "spec", "selector")
+	if err != nil || !found {
+		return nil, err
+	}
+	labelSelector := &metav1.LabelSelector{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selectorMap, labelSelector); err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+	// the pods of a Deployment are controlled by its ReplicaSets, which ControlledPods only looks
+	// up for a typed Deployment
+	var owner runtime.Object = u
+	if u.GroupVersionKind().GroupKind() == appsv1.SchemeGroupVersion.WithKind("Deployment").GroupKind() {
+		deployment := &appsv1.Deployment{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deployment); err != nil {
+			return nil, err
+		}
+		owner = deployment
+	}
+	controlled, err := ControlledPods(d.Client, owner, u.GetNamespace(), selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []*corev1.Pod
+	for _, pod := range controlled {
+		if !podutil.IsPodReady(pod) {
+			pods = append(pods, pod)
+		}
+	}
+	if len(pods) == 0 {
+		return nil, nil
+	}
+
+	var probeEvents map[types.UID]string
+	issues := make(map[string]*RolloutIssue)
+	for _, pod := range pods {
+		reason, message, terminal := podIssue(pod)
+		if len(reason) == 0 && pod.Status.Phase == corev1.PodRunning {
+			if probeEvents == nil {
+				if probeEvents, err = d.readinessProbeFailures(u.GetNamespace(), pods); err != nil {
+					return nil, err
+				}
+			}
+			if event, ok := probeEvents[pod.UID]; ok {
+				reason, message = reasonReadinessProbeFailed, event
+			}
+		}
+		if len(reason) == 0 {
+			continue
+		}
+		issue, ok := issues[reason]
+		if !ok {
+			issue = &RolloutIssue{Reason: reason, Message: message, Terminal: terminal}
+			issues[reason] = issue
+		}
+		issue.Pods = append(issue.Pods, pod.Name)
+	}
+
+	result := make([]RolloutIssue, 0, len(issues))
+	for _, issue := range issues {
+		sort.Strings(issue.Pods)
+		result = append(result, *issue)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Reason < result[j].Reason })
+	return result, nil
+}
+
+// readinessProbeFailures returns the message of the latest readiness probe failure of each of pods in
+// namespace. Pods of Advanced StatefulSets keep their names when they are recreated, so events are
+// matched by the UID of the pod and events from before the pod was created are left out.
+func (d *RolloutDiagnoser) readinessProbeFailures(namespace string, pods []*corev1.Pod) (map[types.UID]string, error) {
+	eventList, err := d.Client.CoreV1().Events(namespace).List(context.TODO(), metav1.ListOptions{
+		FieldSelector: fields.Set{"involvedObject.kind": "Pod", "reason": "Unhealthy"}.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(eventList.Items, func(i, j int) bool {
+		return eventList.Items[i].LastTimestamp.Before(&eventList.Items[j].LastTimestamp)
+	})
+	podsByUID := make(map[types.UID]*corev1.Pod, len(pods))
+	for _, pod := range pods {
+		podsByUID[pod.UID] = pod
+	}
+	failures := make(map[types.UID]string)
+	for _, event := range eventList.Items {
+		pod, ok := podsByUID[event.InvolvedObject.UID]
+		if !ok || event.LastTimestamp.Before(&pod.CreationTimestamp) {
+			continue
+		}
+		if strings.HasPrefix(event.Message, "Readiness probe failed") {
+			failures[pod.UID] = strings.TrimSpace(event.Message)
+		}
+	}
+	return failures, nil
+}
+
+// podIssue returns the reason a pod is not ready, as far as it can be told from the pod itself.
+func podIssue(pod *corev1.Pod) (reason, message string, terminal bool) {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable {
+			return reasonUnschedulable, condition.Message, false
+		}
+	}
+	statuses := append(append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...)
+	for _, status := range statuses {
+		if status.State.Waiting == nil {
+			continue
+		}
+		terminal, ok := waitingReasons[status.State.Waiting.Reason]
+		if !ok {
+			continue
+		}
+		message := status.State.Waiting.Message
+		if last := status.LastTerminationState.Terminated; status.State.Waiting.Reason == "CrashLoopBackOff" && last != nil {
+			message = fmt.Sprintf("container %q last terminated with %s (exit code %d)", status.Name, last.Reason, last.ExitCode)
+		}
+		return status.State.Waiting.Reason, message, terminal
+	}
+	return "", "", false
+}
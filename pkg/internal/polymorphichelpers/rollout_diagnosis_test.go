@@ -0,0 +1,177 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRolloutDiagnoserDiagnose(t *testing.T) {
+	isController := true
+	cs := &kruiseappsv1alpha1.CloneSet{
+		TypeMeta:   metav1.TypeMeta{APIVersion: kruiseappsv1alpha1.GroupVersion.String(), Kind: "CloneSet"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "cs", UID: types.UID("cs-uid")},
+		Spec: kruiseappsv1alpha1.CloneSetSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "cs"}},
+		},
+	}
+	newPod := func(name string, status corev1.PodStatus) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "bar",
+				Name:      name,
+				Labels:    map[string]string{"app": "cs"},
+				OwnerReferences: []metav1.OwnerReference{
+					{APIVersion: cs.APIVersion, Kind: cs.Kind, Name: cs.Name, UID: cs.UID, Controller: &isController},
+				},
+			},
+			Status: status,
+		}
+	}
+	waiting := func(reason string) corev1.PodStatus {
+		return corev1.PodStatus{
+			Phase: corev1.PodPending,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "main", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: "back-off pulling image"}}},
+			},
+		}
+	}
+
+	client := fake.NewSimpleClientset(
+		newPod("cs-a", waiting("ImagePullBackOff")),
+		newPod("cs-b", waiting("ImagePullBackOff")),
+		newPod("cs-c", corev1.PodStatus{
+			Phase: corev1.PodPending,
+			Conditions: []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable, Message: "0/3 nodes are available"},
+			},
+		}),
+		newPod("cs-d", corev1.PodStatus{
+			Phase:      corev1.PodRunning,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+		}),
+	)
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err := (&RolloutDiagnoser{Client: client}).Diagnose(&unstructured.Unstructured{Object: obj})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []RolloutIssue{
+		{Reason: "ImagePullBackOff", Message: "back-off pulling image", Pods: []string{"cs-a", "cs-b"}, Terminal: true},
+		{Reason: reasonUnschedulable, Message: "0/3 nodes are available", Pods: []string{"cs-c"}},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected issues %v, got %v", expected, issues)
+	}
+
+	// pods of a Deployment are controlled by its ReplicaSets
+	deployment := &appsv1.Deployment{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "apps/v1", Kind: "Deployment"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "web", UID: types.UID("web-uid")},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	}
+	rs := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{
+		Namespace: "bar",
+		Name:      "web-1",
+		UID:       types.UID("rs-uid"),
+		Labels:    map[string]string{"app": "web"},
+		OwnerReferences: []metav1.OwnerReference{
+			{APIVersion: deployment.APIVersion, Kind: deployment.Kind, Name: deployment.Name, UID: deployment.UID, Controller: &isController},
+		},
+	}}
+	rsPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "bar",
+			Name:      "web-1-a",
+			Labels:    map[string]string{"app": "web"},
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: rs.Name, UID: rs.UID, Controller: &isController},
+			},
+		},
+		Status: waiting("CrashLoopBackOff"),
+	}
+	obj, err = runtime.DefaultUnstructuredConverter.ToUnstructured(deployment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err = (&RolloutDiagnoser{Client: fake.NewSimpleClientset(rs, rsPod)}).Diagnose(&unstructured.Unstructured{Object: obj})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []RolloutIssue{
+		{Reason: "CrashLoopBackOff", Message: "back-off pulling image", Pods: []string{"web-1-a"}, Terminal: true},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected issues %v, got %v", expected, issues)
+	}
+
+	// pods of Advanced StatefulSets keep their names, so probe failures of an earlier pod are left out
+	created := metav1.Now()
+	earlier := metav1.NewTime(created.Add(-time.Hour))
+	runningPod := func(name string, uid types.UID) *corev1.Pod {
+		pod := newPod(name, corev1.PodStatus{Phase: corev1.PodRunning})
+		pod.UID = uid
+		pod.CreationTimestamp = created
+		return pod
+	}
+	probeEvent := func(name string, pod *corev1.Pod, uid types.UID, lastTimestamp metav1.Time, message string) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Namespace: "bar", Name: name},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Namespace: "bar", Name: pod.Name, UID: uid},
+			Reason:         "Unhealthy",
+			Message:        message,
+			LastTimestamp:  lastTimestamp,
+		}
+	}
+	podA, podB := runningPod("cs-a", "uid-a"), runningPod("cs-b", "uid-b")
+	obj, err = runtime.DefaultUnstructuredConverter.ToUnstructured(cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issues, err = (&RolloutDiagnoser{Client: fake.NewSimpleClientset(podA, podB,
+		probeEvent("a-old", podA, "uid-old", earlier, "Readiness probe failed: connection refused"),
+		probeEvent("b-old", podB, "uid-b", earlier, "Readiness probe failed: timeout"),
+		probeEvent("b-new", podB, "uid-b", created, "Readiness probe failed: HTTP probe failed with statuscode: 503"),
+	)}).Diagnose(&unstructured.Unstructured{Object: obj})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []RolloutIssue{
+		{Reason: reasonReadinessProbeFailed, Message: "Readiness probe failed: HTTP probe failed with statuscode: 503", Pods: []string{"cs-b"}},
+	}
+	if !reflect.DeepEqual(issues, expected) {
+		t.Errorf("expected issues %v, got %v", expected, issues)
+	}
+}
@@ -17,21 +17,156 @@ limitations under the License.
 package polymorphichelpers
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
 
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	"github.com/hantmac/kubectl-kruise/pkg/fetcher"
 	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	deploymentutil "k8s.io/kubectl/pkg/util/deployment"
+	podutil "k8s.io/kubectl/pkg/util/podutils"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // StatusViewer provides an interface for resources that have rollout status.
 type StatusViewer interface {
-	Status(obj runtime.Unstructured, revision int64) (string, bool, error)
+	Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error)
+}
+
+// RolloutPhase is a label for the phase of a rollout at the current time.
+type RolloutPhase string
+
+const (
+	// RolloutPending means the controller has not observed the latest spec yet.
+	RolloutPending RolloutPhase = "Pending"
+	// RolloutProgressing means pods are still being updated or becoming ready.
+	RolloutProgressing RolloutPhase = "Progressing"
+	// RolloutComplete means all pods that are meant to be updated are updated and ready.
+	RolloutComplete RolloutPhase = "Complete"
+)
+
+// OutputGroupVersion is the group version of the objects printed for structured output.
+var OutputGroupVersion = schema.GroupVersion{Group: "kubectl.kruise.io", Version: "v1alpha1"}
+
+// RolloutStatus is the structured rollout status of a workload.
+type RolloutStatus struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Phase RolloutPhase `json:"phase"`
+	// Desired is the number of pods the workload should run.
+	Desired int32 `json:"desired"`
+	// Updated is the number of pods at the update revision.
+	Updated int32 `json:"updated"`
+	// UpdatedReady is the number of ready pods at the update revision, if the workload reports it.
+	UpdatedReady *int32 `json:"updatedReady,omitempty"`
+	// Available is the number of available pods.
+	Available int32 `json:"available"`
+	// Partition is the number of pods kept at the current revision, if the workload is partitioned.
+	Partition *int32 `json:"partition,omitempty"`
+
+	CurrentRevision string `json:"currentRevision,omitempty"`
+	UpdateRevision  string `json:"updateRevision,omitempty"`
+
+	// Message is a human-readable, single line description of the status.
+	Message string `json:"message"`
+	// Issues are the diagnosed causes that keep pods of the rollout from becoming ready.
+	Issues []RolloutIssue `json:"issues,omitempty"`
+	// Subsets are the rollouts of the workloads of the subsets of a UnitedDeployment.
+	Subsets []SubsetRolloutStatus `json:"subsets,omitempty"`
+}
+
+// SubsetRolloutStatus is the rollout status of the workload of a UnitedDeployment subset.
+type SubsetRolloutStatus struct {
+	// Name is the name of the subset.
+	Name string `json:"name"`
+	// Workload is the kind and name of the workload of the subset, empty if it has not been created yet.
+	Workload string       `json:"workload,omitempty"`
+	Phase    RolloutPhase `json:"phase"`
+	Message  string       `json:"message"`
+}
+
+// String returns a single line description of the subset rollout.
+func (s SubsetRolloutStatus) String() string {
+	if len(s.Workload) == 0 {
+		return fmt.Sprintf("%s: %s", s.Name, s.Message)
+	}
+	return fmt.Sprintf("%s (%s): %s", s.Name, s.Workload, s.Message)
+}
+
+// newRolloutStatus returns a RolloutStatus carrying the type and object metadata for obj.
+func newRolloutStatus(obj metav1.Object) *RolloutStatus {
+	return &RolloutStatus{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: OutputGroupVersion.String(),
+			Kind:       "RolloutStatus",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      obj.GetName(),
+			Namespace: obj.GetNamespace(),
+		},
+	}
+}
+
+// progressing sets the status to the Progressing phase with the given message.
+func (s *RolloutStatus) progressing(format string, args ...interface{}) (*RolloutStatus, bool, error) {
+	s.Phase = RolloutProgressing
+	s.Message = fmt.Sprintf(format, args...)
+	return s, false, nil
+}
+
+// pending sets the status to the Pending phase with the given message.
+func (s *RolloutStatus) pending(format string, args ...interface{}) (*RolloutStatus, bool, error) {
+	s.Phase = RolloutPending
+	s.Message = fmt.Sprintf(format, args...)
+	return s, false, nil
+}
+
+// complete sets the status to the Complete phase with the given message.
+func (s *RolloutStatus) complete(format string, args ...interface{}) (*RolloutStatus, bool, error) {
+	s.Phase = RolloutComplete
+	s.Message = fmt.Sprintf(format, args...)
+	return s, true, nil
+}
+
+// DeepCopyObject implements runtime.Object.
+func (s *RolloutStatus) DeepCopyObject() runtime.Object {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	s.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	if s.UpdatedReady != nil {
+		v := *s.UpdatedReady
+		out.UpdatedReady = &v
+	}
+	if s.Partition != nil {
+		v := *s.Partition
+		out.Partition = &v
+	}
+	if s.Issues != nil {
+		out.Issues = make([]RolloutIssue, len(s.Issues))
+		for i := range s.Issues {
+			out.Issues[i] = s.Issues[i]
+			out.Issues[i].Pods = append([]string(nil), s.Issues[i].Pods...)
+		}
+	}
+	if s.Subsets != nil {
+		out.Subsets = append([]SubsetRolloutStatus(nil), s.Subsets...)
+	}
+	return &out
 }
 
 // StatusViewerFor returns a StatusViewer for the resource specified by kind.
@@ -49,6 +184,12 @@ func StatusViewerFor(kind schema.GroupKind) (StatusViewer, error) {
 		return &CloneSetStatusViewer{}, nil
 	case kruiseappsv1beta1.SchemeGroupVersion.WithKind("StatefulSet").GroupKind():
 		return &AdvancedStatefulSetViewer{}, nil
+	case kruiseappsv1alpha1.SchemeGroupVersion.WithKind("DaemonSet").GroupKind():
+		return &AdvancedDaemonSetStatusViewer{}, nil
+	case kruiseappsv1alpha1.SchemeGroupVersion.WithKind("BroadcastJob").GroupKind():
+		return &BroadcastJobStatusViewer{}, nil
+	case kruiseappsv1alpha1.SchemeGroupVersion.WithKind("UnitedDeployment").GroupKind():
+		return &UnitedDeploymentStatusViewer{}, nil
 	}
 	return nil, fmt.Errorf("no status viewer has been implemented for %v", kind)
 }
@@ -63,161 +204,591 @@ type DaemonSetStatusViewer struct{}
 type StatefulSetStatusViewer struct{}
 
 // CloneSetViewer implements the StatusViewer interface
-type CloneSetStatusViewer struct{}
+type CloneSetStatusViewer struct {
+	revisionReader
+}
 
 // AdvancedStatefulSetViewer implements the StatusViewer interface
-type AdvancedStatefulSetViewer struct{}
+type AdvancedStatefulSetViewer struct {
+	revisionReader
+}
 
-// Status returns a message describing deployment status, and a bool value indicating if the status is considered done.
-func (s *DeploymentStatusViewer) Status(obj runtime.Unstructured, revision int64) (string, bool, error) {
+// AdvancedDaemonSetStatusViewer implements the StatusViewer interface
+type AdvancedDaemonSetStatusViewer struct {
+	revisionReader
+}
+
+// BroadcastJobStatusViewer implements the StatusViewer interface
+type BroadcastJobStatusViewer struct{}
+
+// UnitedDeploymentStatusViewer implements the StatusViewer interface
+type UnitedDeploymentStatusViewer struct {
+	revisionReader
+}
+
+// revisionReader looks up the ControllerRevisions and pods of Kruise workloads. The client is only created
+// once it is needed, e.g. when a revision is pinned, so that plain status checks do not need the Kruise manager.
+type revisionReader struct {
+	c client.Reader
+}
+
+// Status returns the status of the deployment, and a bool value indicating if the status is considered done.
+func (s *DeploymentStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
 	deployment := &appsv1.Deployment{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), deployment)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, deployment, err)
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, deployment, err)
+	}
+
+	status := newRolloutStatus(deployment)
+	status.Desired = deployment.Status.Replicas
+	if deployment.Spec.Replicas != nil {
+		status.Desired = *deployment.Spec.Replicas
 	}
+	status.Updated = deployment.Status.UpdatedReplicas
+	status.Available = deployment.Status.AvailableReplicas
 
+	deploymentRev, err := deploymentutil.Revision(deployment)
 	if revision > 0 {
-		deploymentRev, err := deploymentutil.Revision(deployment)
 		if err != nil {
-			return "", false, fmt.Errorf("cannot get the revision of deployment %q: %v", deployment.Name, err)
+			return nil, false, fmt.Errorf("cannot get the revision of deployment %q: %v", deployment.Name, err)
 		}
 		if revision != deploymentRev {
-			return "", false, fmt.Errorf("desired revision (%d) is different from the running revision (%d)", revision, deploymentRev)
+			return nil, false, fmt.Errorf("desired revision (%d) is different from the running revision (%d)", revision, deploymentRev)
 		}
 	}
+	if err == nil && deploymentRev > 0 {
+		status.UpdateRevision = strconv.FormatInt(deploymentRev, 10)
+	}
+
 	if deployment.Generation <= deployment.Status.ObservedGeneration {
 		cond := deploymentutil.GetDeploymentCondition(deployment.Status, appsv1.DeploymentProgressing)
 		if cond != nil && cond.Reason == deploymentutil.TimedOutReason {
-			return "", false, fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
+			return nil, false, fmt.Errorf("deployment %q exceeded its progress deadline", deployment.Name)
 		}
 		if deployment.Spec.Replicas != nil && deployment.Status.UpdatedReplicas < *deployment.Spec.Replicas {
-			return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...\n", deployment.Name, deployment.Status.UpdatedReplicas, *deployment.Spec.Replicas), false, nil
+			return status.progressing("Waiting for deployment %q rollout to finish: %d out of %d new replicas have been updated...", deployment.Name, deployment.Status.UpdatedReplicas, *deployment.Spec.Replicas)
 		}
 		if deployment.Status.Replicas > deployment.Status.UpdatedReplicas {
-			return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...\n", deployment.Name, deployment.Status.Replicas-deployment.Status.UpdatedReplicas), false, nil
+			return status.progressing("Waiting for deployment %q rollout to finish: %d old replicas are pending termination...", deployment.Name, deployment.Status.Replicas-deployment.Status.UpdatedReplicas)
 		}
 		if deployment.Status.AvailableReplicas < deployment.Status.UpdatedReplicas {
-			return fmt.Sprintf("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...\n", deployment.Name, deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas), false, nil
+			return status.progressing("Waiting for deployment %q rollout to finish: %d of %d updated replicas are available...", deployment.Name, deployment.Status.AvailableReplicas, deployment.Status.UpdatedReplicas)
 		}
-		return fmt.Sprintf("deployment %q successfully rolled out\n", deployment.Name), true, nil
+		return status.complete("deployment %q successfully rolled out", deployment.Name)
 	}
-	return fmt.Sprintf("Waiting for deployment spec update to be observed...\n"), false, nil
+	return status.pending("Waiting for deployment spec update to be observed...")
 }
 
-// Status returns a message describing daemon set status, and a bool value indicating if the status is considered done.
-func (s *DaemonSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (string, bool, error) {
+// Status returns the status of the daemon set, and a bool value indicating if the status is considered done.
+func (s *DaemonSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
 	//ignoring revision as DaemonSets does not have history yet
 
 	daemon := &appsv1.DaemonSet{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), daemon)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, daemon, err)
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, daemon, err)
 	}
 
 	if daemon.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType {
-		return "", true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+		return nil, true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
 	}
+
+	status := newRolloutStatus(daemon)
+	status.Desired = daemon.Status.DesiredNumberScheduled
+	status.Updated = daemon.Status.UpdatedNumberScheduled
+	status.Available = daemon.Status.NumberAvailable
+
 	if daemon.Generation <= daemon.Status.ObservedGeneration {
 		if daemon.Status.UpdatedNumberScheduled < daemon.Status.DesiredNumberScheduled {
-			return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...\n", daemon.Name, daemon.Status.UpdatedNumberScheduled, daemon.Status.DesiredNumberScheduled), false, nil
+			return status.progressing("Waiting for daemon set %q rollout to finish: %d out of %d new pods have been updated...", daemon.Name, daemon.Status.UpdatedNumberScheduled, daemon.Status.DesiredNumberScheduled)
 		}
 		if daemon.Status.NumberAvailable < daemon.Status.DesiredNumberScheduled {
-			return fmt.Sprintf("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...\n", daemon.Name, daemon.Status.NumberAvailable, daemon.Status.DesiredNumberScheduled), false, nil
+			return status.progressing("Waiting for daemon set %q rollout to finish: %d of %d updated pods are available...", daemon.Name, daemon.Status.NumberAvailable, daemon.Status.DesiredNumberScheduled)
 		}
-		return fmt.Sprintf("daemon set %q successfully rolled out\n", daemon.Name), true, nil
+		return status.complete("daemon set %q successfully rolled out", daemon.Name)
 	}
-	return fmt.Sprintf("Waiting for daemon set spec update to be observed...\n"), false, nil
+	return status.pending("Waiting for daemon set spec update to be observed...")
 }
 
-// Status returns a message describing statefulset status, and a bool value indicating if the status is considered done.
-func (s *StatefulSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (string, bool, error) {
+// Status returns the status of the statefulset, and a bool value indicating if the status is considered done.
+func (s *StatefulSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
 	sts := &appsv1.StatefulSet{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), sts)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, sts, err)
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, sts, err)
 	}
 
 	if sts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
-		return "", true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+		return nil, true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+
+	status := newRolloutStatus(sts)
+	status.Desired = sts.Status.Replicas
+	if sts.Spec.Replicas != nil {
+		status.Desired = *sts.Spec.Replicas
+	}
+	status.Updated = sts.Status.UpdatedReplicas
+	status.Available = sts.Status.ReadyReplicas
+	status.CurrentRevision = sts.Status.CurrentRevision
+	status.UpdateRevision = sts.Status.UpdateRevision
+	if sts.Spec.UpdateStrategy.RollingUpdate != nil {
+		status.Partition = sts.Spec.UpdateStrategy.RollingUpdate.Partition
 	}
+
 	if sts.Status.ObservedGeneration == 0 || sts.Generation > sts.Status.ObservedGeneration {
-		return "Waiting for statefulset spec update to be observed...\n", false, nil
+		return status.pending("Waiting for statefulset spec update to be observed...")
 	}
 	if sts.Spec.Replicas != nil && sts.Status.ReadyReplicas < *sts.Spec.Replicas {
-		return fmt.Sprintf("Waiting for %d pods to be ready...\n", *sts.Spec.Replicas-sts.Status.ReadyReplicas), false, nil
+		return status.progressing("Waiting for %d pods to be ready...", *sts.Spec.Replicas-sts.Status.ReadyReplicas)
 	}
 	if sts.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType && sts.Spec.UpdateStrategy.RollingUpdate != nil {
 		if sts.Spec.Replicas != nil && sts.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
 			if sts.Status.UpdatedReplicas < (*sts.Spec.Replicas - *sts.Spec.UpdateStrategy.RollingUpdate.Partition) {
-				return fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...\n",
-					sts.Status.UpdatedReplicas, *sts.Spec.Replicas-*sts.Spec.UpdateStrategy.RollingUpdate.Partition), false, nil
+				return status.progressing("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...",
+					sts.Status.UpdatedReplicas, *sts.Spec.Replicas-*sts.Spec.UpdateStrategy.RollingUpdate.Partition)
 			}
 		}
-		return fmt.Sprintf("partitioned roll out complete: %d new pods have been updated...\n",
-			sts.Status.UpdatedReplicas), true, nil
+		return status.complete("partitioned roll out complete: %d new pods have been updated...",
+			sts.Status.UpdatedReplicas)
 	}
 	if sts.Status.UpdateRevision != sts.Status.CurrentRevision {
-		return fmt.Sprintf("waiting for statefulset rolling update to complete %d pods at revision %s...\n",
-			sts.Status.UpdatedReplicas, sts.Status.UpdateRevision), false, nil
+		return status.progressing("waiting for statefulset rolling update to complete %d pods at revision %s...",
+			sts.Status.UpdatedReplicas, sts.Status.UpdateRevision)
 	}
-	return fmt.Sprintf("statefulset rolling update complete %d pods at revision %s...\n", sts.Status.CurrentReplicas, sts.Status.CurrentRevision), true, nil
+	return status.complete("statefulset rolling update complete %d pods at revision %s...", sts.Status.CurrentReplicas, sts.Status.CurrentRevision)
 
 }
 
-// Status returns a message describing cloneset status, and a bool value indicating if the status is considered done.
-func (s *CloneSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (string, bool, error) {
+// Status returns the status of the cloneset, and a bool value indicating if the status is considered done.
+func (s *CloneSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
 	cs := &kruiseappsv1alpha1.CloneSet{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), cs)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, cs, err)
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, cs, err)
+	}
+
+	replicas := cs.Status.Replicas
+	if cs.Spec.Replicas != nil {
+		replicas = *cs.Spec.Replicas
+	}
+	partition, err := PartitionValue(cs.Spec.UpdateStrategy.Partition, replicas)
+	if err != nil {
+		return nil, false, err
+	}
+	expectedUpdated := replicas - partition
+
+	status := newRolloutStatus(cs)
+	status.Desired = replicas
+	status.Updated = cs.Status.UpdatedReplicas
+	status.UpdatedReady = &cs.Status.UpdatedReadyReplicas
+	status.Available = cs.Status.AvailableReplicas
+	status.CurrentRevision = cs.Status.CurrentRevision
+	status.UpdateRevision = cs.Status.UpdateRevision
+	if cs.Spec.UpdateStrategy.Partition != nil {
+		status.Partition = &partition
 	}
 
-	// check InPlaceOnly and InPlacePossible UpdateStrategy
+	if cs.Status.ObservedGeneration == 0 || cs.Generation > cs.Status.ObservedGeneration {
+		return status.pending("Waiting for CloneSet spec update to be observed...")
+	}
+	if revision > 0 {
+		if err := s.checkRevision(cs.Namespace, cs.Status.UpdateRevision, revision); err != nil {
+			return nil, false, err
+		}
+	}
+
+	update := "rolling update"
 	if cs.Spec.UpdateStrategy.Type == kruiseappsv1alpha1.InPlaceOnlyCloneSetUpdateStrategyType ||
 		cs.Spec.UpdateStrategy.Type == kruiseappsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType {
-		if cs.Spec.Replicas != nil && cs.Spec.UpdateStrategy.Partition != nil {
-			if cs.Status.UpdatedReplicas < (*cs.Spec.Replicas - cs.Spec.UpdateStrategy.Partition.IntVal) {
-				return fmt.Sprintf("Waiting for partitioned roll out to finish: %d out of %d new pods have been updated...\n",
-					cs.Status.UpdatedReplicas, *cs.Spec.Replicas-cs.Spec.UpdateStrategy.Partition.IntVal), false, nil
-			}
-		}
+		update = "in-place update"
 	}
 
-	if cs.Status.ObservedGeneration == 0 || cs.Generation > cs.Status.ObservedGeneration {
-		return "Waiting for CloneSet spec update to be observed...\n", false, nil
+	if cs.Status.UpdatedReplicas < expectedUpdated {
+		return status.progressing("Waiting for CloneSet %q %s to finish: %d out of %d new pods have been updated...",
+			cs.Name, update, cs.Status.UpdatedReplicas, expectedUpdated)
+	}
+	if cs.Status.UpdatedReadyReplicas < expectedUpdated {
+		return status.progressing("Waiting for CloneSet %q %s to finish: %d of %d updated pods are ready...",
+			cs.Name, update, cs.Status.UpdatedReadyReplicas, expectedUpdated)
 	}
-	if cs.Spec.Replicas != nil && cs.Status.ReadyReplicas < *cs.Spec.Replicas {
-		return fmt.Sprintf("Waiting for %d pods to be ready...\n", *cs.Spec.Replicas-cs.Status.ReadyReplicas), false, nil
+	if partition > 0 {
+		return status.complete("partitioned roll out complete: %d new pods have been updated, %d pods are kept at revision %s by partition...",
+			cs.Status.UpdatedReplicas, partition, cs.Status.CurrentRevision)
+	}
+	if cs.Status.Replicas > cs.Status.UpdatedReplicas {
+		return status.progressing("Waiting for CloneSet %q %s to finish: %d old pods are pending update or termination...",
+			cs.Name, update, cs.Status.Replicas-cs.Status.UpdatedReplicas)
+	}
+	if cs.Status.UpdateRevision != cs.Status.CurrentRevision {
+		return status.progressing("Waiting for CloneSet %q %s to complete %d pods at revision %s...",
+			cs.Name, update, cs.Status.UpdatedReplicas, cs.Status.UpdateRevision)
+	}
+	if cs.Status.ReadyReplicas < replicas {
+		return status.progressing("Waiting for %d pods to be ready...", replicas-cs.Status.ReadyReplicas)
 	}
 
-	return fmt.Sprintf("CloneSet rolling update complete %d pods at revision %s...\n", cs.Status.AvailableReplicas, cs.Status.UpdateRevision), true, nil
+	return status.complete("CloneSet %s complete %d pods at revision %s...", update, cs.Status.UpdatedReadyReplicas, cs.Status.UpdateRevision)
 }
 
-// Status returns a message describing advanced statefulset status, and a bool value indicating if the status is considered done.
-func (s *AdvancedStatefulSetViewer) Status(obj runtime.Unstructured, revision int64) (string, bool, error) {
+// Status returns the status of the advanced statefulset, and a bool value indicating if the status is considered done.
+func (s *AdvancedStatefulSetViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
 	asts := &kruiseappsv1beta1.StatefulSet{}
 	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), asts)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to convert %T to %T: %v", obj, asts, err)
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, asts, err)
 	}
 
-	// check InPlaceOnly and InPlacePossible UpdateStrategy
-	if asts.Spec.UpdateStrategy.Type == appsv1.RollingUpdateStatefulSetStrategyType {
-		if asts.Spec.Replicas != nil && asts.Spec.UpdateStrategy.RollingUpdate.Partition != nil {
-			if asts.Status.UpdatedReplicas < (*asts.Spec.Replicas - *asts.Spec.UpdateStrategy.RollingUpdate.Partition) {
-				return fmt.Sprintf("Waiting for partitioned roll out to finish:%d out of %d new pods has been updated...\n",
-					asts.Status.UpdatedReplicas, *asts.Spec.Replicas-*asts.Spec.UpdateStrategy.RollingUpdate.Partition), false, nil
+	if asts.Spec.UpdateStrategy.Type != "" && asts.Spec.UpdateStrategy.Type != appsv1.RollingUpdateStatefulSetStrategyType {
+		return nil, true, fmt.Errorf("rollout status is only available for %s strategy type", appsv1.RollingUpdateStatefulSetStrategyType)
+	}
+
+	replicas := asts.Status.Replicas
+	if asts.Spec.Replicas != nil {
+		replicas = *asts.Spec.Replicas
+	}
+	var partition int32
+	update := "rolling update"
+	if rollingUpdate := asts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.Partition != nil {
+			partition = *rollingUpdate.Partition
+			if partition > replicas {
+				partition = replicas
 			}
 		}
+		if rollingUpdate.PodUpdatePolicy == kruiseappsv1beta1.InPlaceIfPossiblePodUpdateStrategyType ||
+			rollingUpdate.PodUpdatePolicy == kruiseappsv1beta1.InPlaceOnlyPodUpdateStrategyType {
+			update = "in-place update"
+		}
+	}
+	expectedUpdated := replicas - partition
+
+	status := newRolloutStatus(asts)
+	status.Desired = replicas
+	status.Updated = asts.Status.UpdatedReplicas
+	status.Available = asts.Status.AvailableReplicas
+	status.CurrentRevision = asts.Status.CurrentRevision
+	status.UpdateRevision = asts.Status.UpdateRevision
+	if rollingUpdate := asts.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		status.Partition = &partition
 	}
 
 	if asts.Status.ObservedGeneration == 0 || asts.Generation > asts.Status.ObservedGeneration {
-		return "Waiting for Advanced StatefulSet spec update to be observed...\n", false, nil
+		return status.pending("Waiting for Advanced StatefulSet spec update to be observed...")
+	}
+	if revision > 0 {
+		if err := s.checkRevision(asts.Namespace, asts.Status.UpdateRevision, revision); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if asts.Status.UpdatedReplicas < expectedUpdated {
+		return status.progressing("Waiting for Advanced StatefulSet %q %s to finish: %d out of %d new pods have been updated...",
+			asts.Name, update, asts.Status.UpdatedReplicas, expectedUpdated)
+	}
+	// Advanced StatefulSet does not report how many of the updated pods are ready,
+	// so the update is only considered done once every pod is ready.
+	if asts.Status.ReadyReplicas < replicas {
+		return status.progressing("Waiting for %d pods to be ready...", replicas-asts.Status.ReadyReplicas)
+	}
+	if partition > 0 {
+		return status.complete("partitioned roll out complete: %d new pods have been updated, %d pods are kept at revision %s by partition...",
+			asts.Status.UpdatedReplicas, partition, asts.Status.CurrentRevision)
+	}
+	if asts.Status.UpdateRevision != asts.Status.CurrentRevision {
+		return status.progressing("Waiting for Advanced StatefulSet %q %s to complete %d pods at revision %s...",
+			asts.Name, update, asts.Status.UpdatedReplicas, asts.Status.UpdateRevision)
+	}
+	return status.complete("Advanced StatefulSet %s complete %d pods at revision %s...", update, asts.Status.UpdatedReplicas, asts.Status.UpdateRevision)
+}
+
+// Status returns the status of the advanced daemonset, and a bool value indicating if the status is considered done.
+func (s *AdvancedDaemonSetStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
+	ads := &kruiseappsv1alpha1.DaemonSet{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), ads)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, ads, err)
+	}
+
+	if ads.Spec.UpdateStrategy.Type != "" && ads.Spec.UpdateStrategy.Type != kruiseappsv1alpha1.RollingUpdateDaemonSetStrategyType {
+		return nil, true, fmt.Errorf("rollout status is only available for %s strategy type", kruiseappsv1alpha1.RollingUpdateDaemonSetStrategyType)
+	}
+
+	desired := ads.Status.DesiredNumberScheduled
+	var partition int32
+	surging := false
+	if rollingUpdate := ads.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.Partition != nil {
+			partition = *rollingUpdate.Partition
+			if partition > desired {
+				partition = desired
+			}
+		}
+		surging = rollingUpdate.Type == kruiseappsv1alpha1.SurgingRollingUpdateType
+	}
+	update := "rolling update"
+	if surging {
+		update = "surging rolling update"
+	}
+	expectedUpdated := desired - partition
+
+	// The ControllerRevisions of a DaemonSet are named after it and the hash of their template
+	var updateRevision string
+	if len(ads.Status.DaemonSetHash) > 0 {
+		updateRevision = ads.Name + "-" + ads.Status.DaemonSetHash
+	}
+
+	status := newRolloutStatus(ads)
+	status.Desired = desired
+	status.Updated = ads.Status.UpdatedNumberScheduled
+	status.Available = ads.Status.NumberAvailable
+	status.UpdateRevision = updateRevision
+	if rollingUpdate := ads.Spec.UpdateStrategy.RollingUpdate; rollingUpdate != nil && rollingUpdate.Partition != nil {
+		status.Partition = &partition
+	}
+
+	if ads.Status.ObservedGeneration == 0 || ads.Generation > ads.Status.ObservedGeneration {
+		return status.pending("Waiting for Advanced DaemonSet spec update to be observed...")
+	}
+	if revision > 0 {
+		if err := s.checkRevision(ads.Namespace, updateRevision, revision); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if ads.Status.UpdatedNumberScheduled < expectedUpdated {
+		return status.progressing("Waiting for Advanced DaemonSet %q %s to finish: %d out of %d new pods have been updated...",
+			ads.Name, update, ads.Status.UpdatedNumberScheduled, expectedUpdated)
+	}
+	if ads.Status.NumberAvailable < desired {
+		return status.progressing("Waiting for Advanced DaemonSet %q %s to finish: %d of %d pods are available...",
+			ads.Name, update, ads.Status.NumberAvailable, desired)
+	}
+	// A surging update starts the new pod next to the old one on each node, which keeps the node
+	// available while the new pod is not, so count the pods themselves.
+	if surging && len(ads.Status.DaemonSetHash) > 0 {
+		pods, err := s.listPods(ads, ads.Spec.Selector)
+		if err != nil {
+			return nil, false, err
+		}
+		var updatedAvailable, total int32
+		for i := range pods {
+			pod := &pods[i]
+			total++
+			if pod.Labels[appsv1.DefaultDaemonSetUniqueLabelKey] == ads.Status.DaemonSetHash &&
+				podutil.IsPodAvailable(pod, ads.Spec.MinReadySeconds, metav1.Now()) {
+				updatedAvailable++
+			}
+		}
+		if updatedAvailable < expectedUpdated {
+			return status.progressing("Waiting for Advanced DaemonSet %q %s to finish: %d of %d updated pods are available...",
+				ads.Name, update, updatedAvailable, expectedUpdated)
+		}
+		if total > desired {
+			return status.progressing("Waiting for Advanced DaemonSet %q %s to finish: %d old pods are pending termination...",
+				ads.Name, update, total-desired)
+		}
+	}
+	if partition > 0 {
+		return status.complete("partitioned roll out complete: %d new pods have been updated, %d pods are kept at their revision by partition...",
+			ads.Status.UpdatedNumberScheduled, partition)
+	}
+	return status.complete("Advanced DaemonSet %q successfully rolled out", ads.Name)
+}
+
+// Status returns the status of the broadcast job, and a bool value indicating if the status is considered done.
+// A BroadcastJob runs one pod per node, so its counts are numbers of nodes.
+func (s *BroadcastJobStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
+	job := &kruiseappsv1alpha1.BroadcastJob{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), job)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, job, err)
+	}
+
+	status := newRolloutStatus(job)
+	status.Desired = job.Status.Desired
+	status.Updated = job.Status.Succeeded + job.Status.Failed
+	status.Available = job.Status.Succeeded
+
+	switch job.Status.Phase {
+	case kruiseappsv1alpha1.PhaseFailed:
+		message := fmt.Sprintf("BroadcastJob %q failed: %d of %d nodes succeeded, %d failed", job.Name, job.Status.Succeeded, job.Status.Desired, job.Status.Failed)
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == kruiseappsv1alpha1.JobFailed && condition.Status == corev1.ConditionTrue && len(condition.Message) > 0 {
+				message += ": " + condition.Message
+			}
+		}
+		return nil, true, errors.New(message)
+	case kruiseappsv1alpha1.PhaseCompleted:
+		return status.complete("BroadcastJob %q completed: %d of %d nodes succeeded, %d failed",
+			job.Name, job.Status.Succeeded, job.Status.Desired, job.Status.Failed)
+	case kruiseappsv1alpha1.PhasePaused:
+		return status.pending("BroadcastJob %q is paused: %d of %d nodes succeeded, %d active, %d failed...",
+			job.Name, job.Status.Succeeded, job.Status.Desired, job.Status.Active, job.Status.Failed)
+	}
+	if job.Status.StartTime == nil {
+		return status.pending("Waiting for BroadcastJob %q to start...", job.Name)
+	}
+	return status.progressing("Waiting for BroadcastJob %q to finish: %d of %d nodes succeeded, %d active, %d failed...",
+		job.Name, job.Status.Succeeded, job.Status.Desired, job.Status.Active, job.Status.Failed)
+}
+
+// Status returns the status of the united deployment, and a bool value indicating if the status is considered done.
+// The rollout of a UnitedDeployment is done once the workloads of all of its subsets are rolled out.
+func (s *UnitedDeploymentStatusViewer) Status(obj runtime.Unstructured, revision int64) (*RolloutStatus, bool, error) {
+	ud := &kruiseappsv1alpha1.UnitedDeployment{}
+	err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.UnstructuredContent(), ud)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to convert %T to %T: %v", obj, ud, err)
+	}
+
+	status := newRolloutStatus(ud)
+	if ud.Spec.Replicas != nil {
+		status.Desired = *ud.Spec.Replicas
+	}
+	status.Updated = ud.Status.UpdatedReplicas
+	status.UpdatedReady = &ud.Status.UpdatedReadyReplicas
+	status.Available = ud.Status.ReadyReplicas
+	status.CurrentRevision = ud.Status.CurrentRevision
+	if ud.Status.UpdateStatus != nil {
+		status.UpdateRevision = ud.Status.UpdateStatus.UpdatedRevision
+	}
+
+	if revision > 0 {
+		if err := s.checkRevision(ud.Namespace, status.UpdateRevision, revision); err != nil {
+			return nil, false, err
+		}
+	}
+	if ud.Generation > ud.Status.ObservedGeneration {
+		return status.pending("Waiting for UnitedDeployment spec update to be observed...")
+	}
+
+	workloads, err := s.subsetWorkloads(ud)
+	if err != nil {
+		return nil, false, err
+	}
+	var updated int
+	for _, subset := range ud.Spec.Topology.Subsets {
+		subsetStatus := SubsetRolloutStatus{Name: subset.Name, Phase: RolloutPending, Message: "Waiting for the workload of the subset to be created..."}
+		if workload, ok := workloads[subset.Name]; ok {
+			subsetStatus.Workload = workload.name
+			rollout, done, err := workload.viewer.Status(workload.obj, 0)
+			if err != nil {
+				return nil, false, fmt.Errorf("subset %q: %v", subset.Name, err)
+			}
+			subsetStatus.Phase, subsetStatus.Message = rollout.Phase, rollout.Message
+			if done {
+				updated++
+			}
+		}
+		status.Subsets = append(status.Subsets, subsetStatus)
+	}
+
+	if updated < len(ud.Spec.Topology.Subsets) {
+		return status.progressing("Waiting for UnitedDeployment %q rolling update to finish: %d of %d subsets have been rolled out...",
+			ud.Name, updated, len(ud.Spec.Topology.Subsets))
+	}
+	return status.complete("UnitedDeployment %q successfully rolled out %d subsets", ud.Name, updated)
+}
+
+// subsetWorkload is the workload of a UnitedDeployment subset with the StatusViewer for its kind.
+type subsetWorkload struct {
+	name   string
+	obj    runtime.Unstructured
+	viewer StatusViewer
+}
+
+// subsetWorkloads returns the workloads of the subsets of ud by subset name.
+func (r *revisionReader) subsetWorkloads(ud *kruiseappsv1alpha1.UnitedDeployment) (map[string]subsetWorkload, error) {
+	if r.c == nil {
+		r.c = internalclient.NewManager().GetAPIReader()
+	}
+	var list runtime.Object
+	var kind string
+	var viewer StatusViewer
+	switch template := ud.Spec.Template; {
+	case template.CloneSetTemplate != nil:
+		list, kind, viewer = &kruiseappsv1alpha1.CloneSetList{}, "cloneset", &CloneSetStatusViewer{revisionReader: *r}
+	case template.AdvancedStatefulSetTemplate != nil:
+		list, kind, viewer = &kruiseappsv1beta1.StatefulSetList{}, "statefulset.apps.kruise.io", &AdvancedStatefulSetViewer{revisionReader: *r}
+	case template.StatefulSetTemplate != nil:
+		list, kind, viewer = &appsv1.StatefulSetList{}, "statefulset", &StatefulSetStatusViewer{}
+	case template.DeploymentTemplate != nil:
+		list, kind, viewer = &appsv1.DeploymentList{}, "deployment", &DeploymentStatusViewer{}
+	default:
+		return nil, fmt.Errorf("UnitedDeployment %s has no subset template", ud.Name)
+	}
+
+	if err := r.c.List(context.TODO(), list, client.InNamespace(ud.Namespace), client.HasLabels{kruiseappsv1alpha1.SubSetNameLabelKey}); err != nil {
+		return nil, fmt.Errorf("failed to list the subsets of %s: %v", ud.Name, err)
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
 	}
 
-	if asts.Spec.Replicas != nil && asts.Status.ReadyReplicas < *asts.Spec.Replicas {
-		return fmt.Sprintf("Waiting for %d pods to be ready...\n", *asts.Spec.Replicas-asts.Status.ReadyReplicas), false, nil
+	workloads := make(map[string]subsetWorkload)
+	for _, item := range items {
+		workload, err := meta.Accessor(item)
+		if err != nil {
+			return nil, err
+		}
+		if workload.GetDeletionTimestamp() != nil || !metav1.IsControlledBy(workload, ud) {
+			continue
+		}
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return nil, err
+		}
+		workloads[workload.GetLabels()[kruiseappsv1alpha1.SubSetNameLabelKey]] = subsetWorkload{
+			name:   kind + "/" + workload.GetName(),
+			obj:    &unstructured.Unstructured{Object: content},
+			viewer: viewer,
+		}
+	}
+	return workloads, nil
+}
+
+// listPods returns the pods controlled by owner that are not being deleted.
+func (r *revisionReader) listPods(owner metav1.Object, labelSelector *metav1.LabelSelector) ([]corev1.Pod, error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, err
 	}
-	return fmt.Sprintf("Advanced StatefulSet rolling update complete %d pods at revision %s...\n", asts.Status.AvailableReplicas, asts.Status.UpdateRevision), true, nil
+	if r.c == nil {
+		r.c = internalclient.NewManager().GetAPIReader()
+	}
+	podList := &corev1.PodList{}
+	if err := r.c.List(context.TODO(), podList, client.InNamespace(owner.GetNamespace()), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, fmt.Errorf("failed to list pods of %s: %v", owner.GetName(), err)
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp == nil && metav1.IsControlledBy(&pod, owner) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
 
+// checkRevision returns an error if the ControllerRevision named updateRevision is not the desired revision.
+func (r *revisionReader) checkRevision(namespace, updateRevision string, revision int64) error {
+	if len(updateRevision) == 0 {
+		return fmt.Errorf("desired revision (%d) is different from the running revision (unknown)", revision)
+	}
+	if r.c == nil {
+		r.c = internalclient.NewManager().GetAPIReader()
+	}
+	history := &appsv1.ControllerRevision{}
+	found, err := fetcher.GetResourceInCache(namespace, updateRevision, history, r.c)
+	if err != nil {
+		return fmt.Errorf("cannot get the running revision %s: %v", updateRevision, err)
+	}
+	if !found {
+		return fmt.Errorf("cannot get the running revision %s: not found", updateRevision)
+	}
+	if revision != history.Revision {
+		return fmt.Errorf("desired revision (%d) is different from the running revision (%d)", revision, history.Revision)
+	}
+	return nil
 }
@@ -0,0 +1,496 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"testing"
+
+	internalclient "github.com/hantmac/kubectl-kruise/pkg/client"
+	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
+	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func TestCloneSetStatusViewerStatus(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategyType kruiseappsv1alpha1.CloneSetUpdateStrategyType
+		partition    *intstr.IntOrString
+		status       kruiseappsv1alpha1.CloneSetStatus
+		msg          string
+		done         bool
+	}{
+		{
+			name: "old pods still running without partition",
+			status: kruiseappsv1alpha1.CloneSetStatus{
+				ObservedGeneration:   1,
+				Replicas:             3,
+				ReadyReplicas:        3,
+				AvailableReplicas:    3,
+				UpdatedReplicas:      1,
+				UpdatedReadyReplicas: 1,
+				CurrentRevision:      "cs-1",
+				UpdateRevision:       "cs-2",
+			},
+			msg:  "Waiting for CloneSet \"cs\" rolling update to finish: 1 out of 3 new pods have been updated...",
+			done: false,
+		},
+		{
+			name:         "in-place updated pods not ready",
+			strategyType: kruiseappsv1alpha1.InPlaceIfPossibleCloneSetUpdateStrategyType,
+			status: kruiseappsv1alpha1.CloneSetStatus{
+				ObservedGeneration:   1,
+				Replicas:             3,
+				ReadyReplicas:        2,
+				UpdatedReplicas:      3,
+				UpdatedReadyReplicas: 2,
+				CurrentRevision:      "cs-1",
+				UpdateRevision:       "cs-2",
+			},
+			msg:  "Waiting for CloneSet \"cs\" in-place update to finish: 2 of 3 updated pods are ready...",
+			done: false,
+		},
+		{
+			name:      "percent partition complete",
+			partition: &intstr.IntOrString{Type: intstr.String, StrVal: "50%"},
+			status: kruiseappsv1alpha1.CloneSetStatus{
+				ObservedGeneration:   1,
+				Replicas:             3,
+				ReadyReplicas:        3,
+				UpdatedReplicas:      1,
+				UpdatedReadyReplicas: 1,
+				CurrentRevision:      "cs-1",
+				UpdateRevision:       "cs-2",
+			},
+			msg:  "partitioned roll out complete: 1 new pods have been updated, 2 pods are kept at revision cs-1 by partition...",
+			done: true,
+		},
+		{
+			name: "rollout complete",
+			status: kruiseappsv1alpha1.CloneSetStatus{
+				ObservedGeneration:   1,
+				Replicas:             3,
+				ReadyReplicas:        3,
+				UpdatedReplicas:      3,
+				UpdatedReadyReplicas: 3,
+				CurrentRevision:      "cs-2",
+				UpdateRevision:       "cs-2",
+			},
+			msg:  "CloneSet rolling update complete 3 pods at revision cs-2...",
+			done: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cs := &kruiseappsv1alpha1.CloneSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "cs", Generation: 1},
+				Spec: kruiseappsv1alpha1.CloneSetSpec{
+					Replicas: int32Ptr(3),
+					UpdateStrategy: kruiseappsv1alpha1.CloneSetUpdateStrategy{
+						Type:      test.strategyType,
+						Partition: test.partition,
+					},
+				},
+				Status: test.status,
+			}
+			unstructuredCS, err := runtime.DefaultUnstructuredConverter.ToUnstructured(cs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, done, err := (&CloneSetStatusViewer{}).Status(&unstructured.Unstructured{Object: unstructuredCS}, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if done != test.done || status.Message != test.msg {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.msg, test.done, status.Message, done)
+			}
+			if status.Name != "cs" || status.Desired != 3 || status.Updated != test.status.UpdatedReplicas {
+				t.Errorf("unexpected status: %#v", status)
+			}
+		})
+	}
+}
+
+func TestAdvancedStatefulSetViewerStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition *int32
+		status    kruiseappsv1beta1.StatefulSetStatus
+		msg       string
+		done      bool
+	}{
+		{
+			name: "old pods still running without partition",
+			status: kruiseappsv1beta1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				Replicas:           3,
+				ReadyReplicas:      3,
+				UpdatedReplicas:    2,
+				CurrentRevision:    "asts-1",
+				UpdateRevision:     "asts-2",
+			},
+			msg:  "Waiting for Advanced StatefulSet \"asts\" in-place update to finish: 2 out of 3 new pods have been updated...",
+			done: false,
+		},
+		{
+			name:      "partition complete",
+			partition: int32Ptr(2),
+			status: kruiseappsv1beta1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				Replicas:           3,
+				ReadyReplicas:      3,
+				UpdatedReplicas:    1,
+				CurrentRevision:    "asts-1",
+				UpdateRevision:     "asts-2",
+			},
+			msg:  "partitioned roll out complete: 1 new pods have been updated, 2 pods are kept at revision asts-1 by partition...",
+			done: true,
+		},
+		{
+			name:      "rollout complete with zero partition",
+			partition: int32Ptr(0),
+			status: kruiseappsv1beta1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				Replicas:           3,
+				ReadyReplicas:      3,
+				UpdatedReplicas:    3,
+				CurrentRevision:    "asts-2",
+				UpdateRevision:     "asts-2",
+			},
+			msg:  "Advanced StatefulSet in-place update complete 3 pods at revision asts-2...",
+			done: true,
+		},
+		{
+			name: "rollout complete",
+			status: kruiseappsv1beta1.StatefulSetStatus{
+				ObservedGeneration: 1,
+				Replicas:           3,
+				ReadyReplicas:      3,
+				UpdatedReplicas:    3,
+				CurrentRevision:    "asts-2",
+				UpdateRevision:     "asts-2",
+			},
+			msg:  "Advanced StatefulSet in-place update complete 3 pods at revision asts-2...",
+			done: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			asts := &kruiseappsv1beta1.StatefulSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "asts", Generation: 1},
+				Spec: kruiseappsv1beta1.StatefulSetSpec{
+					Replicas: int32Ptr(3),
+					UpdateStrategy: kruiseappsv1beta1.StatefulSetUpdateStrategy{
+						Type: appsv1.RollingUpdateStatefulSetStrategyType,
+						RollingUpdate: &kruiseappsv1beta1.RollingUpdateStatefulSetStrategy{
+							Partition:       test.partition,
+							PodUpdatePolicy: kruiseappsv1beta1.InPlaceIfPossiblePodUpdateStrategyType,
+						},
+					},
+				},
+				Status: test.status,
+			}
+			unstructuredASTS, err := runtime.DefaultUnstructuredConverter.ToUnstructured(asts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, done, err := (&AdvancedStatefulSetViewer{}).Status(&unstructured.Unstructured{Object: unstructuredASTS}, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if done != test.done || status.Message != test.msg {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.msg, test.done, status.Message, done)
+			}
+			if status.Name != "asts" || status.Desired != 3 || status.Updated != test.status.UpdatedReplicas {
+				t.Errorf("unexpected status: %#v", status)
+			}
+			if (status.Partition != nil) != (test.partition != nil) {
+				t.Errorf("expected partition to be reported as it is set in the spec, got %v", status.Partition)
+			}
+		})
+	}
+}
+
+func TestAdvancedDaemonSetStatusViewerStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		partition *int32
+		status    kruiseappsv1alpha1.DaemonSetStatus
+		msg       string
+		done      bool
+	}{
+		{
+			name: "old pods still running without partition",
+			status: kruiseappsv1alpha1.DaemonSetStatus{
+				ObservedGeneration:     1,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 1,
+				NumberAvailable:        3,
+				DaemonSetHash:          "5d8f9c",
+			},
+			msg:  "Waiting for Advanced DaemonSet \"ads\" rolling update to finish: 1 out of 3 new pods have been updated...",
+			done: false,
+		},
+		{
+			name: "updated pods not available",
+			status: kruiseappsv1alpha1.DaemonSetStatus{
+				ObservedGeneration:     1,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 3,
+				NumberAvailable:        2,
+				DaemonSetHash:          "5d8f9c",
+			},
+			msg:  "Waiting for Advanced DaemonSet \"ads\" rolling update to finish: 2 of 3 pods are available...",
+			done: false,
+		},
+		{
+			name:      "partition complete",
+			partition: int32Ptr(2),
+			status: kruiseappsv1alpha1.DaemonSetStatus{
+				ObservedGeneration:     1,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 1,
+				NumberAvailable:        3,
+				DaemonSetHash:          "5d8f9c",
+			},
+			msg:  "partitioned roll out complete: 1 new pods have been updated, 2 pods are kept at their revision by partition...",
+			done: true,
+		},
+		{
+			name:      "rollout complete with zero partition",
+			partition: int32Ptr(0),
+			status: kruiseappsv1alpha1.DaemonSetStatus{
+				ObservedGeneration:     1,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 3,
+				NumberAvailable:        3,
+				DaemonSetHash:          "5d8f9c",
+			},
+			msg:  "Advanced DaemonSet \"ads\" successfully rolled out",
+			done: true,
+		},
+		{
+			name: "rollout complete",
+			status: kruiseappsv1alpha1.DaemonSetStatus{
+				ObservedGeneration:     1,
+				DesiredNumberScheduled: 3,
+				UpdatedNumberScheduled: 3,
+				NumberAvailable:        3,
+				DaemonSetHash:          "5d8f9c",
+			},
+			msg:  "Advanced DaemonSet \"ads\" successfully rolled out",
+			done: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ads := &kruiseappsv1alpha1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "ads", Generation: 1},
+				Spec: kruiseappsv1alpha1.DaemonSetSpec{
+					UpdateStrategy: kruiseappsv1alpha1.DaemonSetUpdateStrategy{
+						Type: kruiseappsv1alpha1.RollingUpdateDaemonSetStrategyType,
+						RollingUpdate: &kruiseappsv1alpha1.RollingUpdateDaemonSet{
+							Type:      kruiseappsv1alpha1.StandardRollingUpdateType,
+							Partition: test.partition,
+						},
+					},
+				},
+				Status: test.status,
+			}
+			unstructuredADS, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ads)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, done, err := (&AdvancedDaemonSetStatusViewer{}).Status(&unstructured.Unstructured{Object: unstructuredADS}, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if done != test.done || status.Message != test.msg {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.msg, test.done, status.Message, done)
+			}
+			if status.UpdateRevision != "ads-5d8f9c" || status.Desired != 3 || status.Updated != test.status.UpdatedNumberScheduled {
+				t.Errorf("unexpected status: %#v", status)
+			}
+			if (status.Partition != nil) != (test.partition != nil) {
+				t.Errorf("expected partition to be reported as it is set in the spec, got %v", status.Partition)
+			}
+		})
+	}
+}
+
+func TestBroadcastJobStatusViewerStatus(t *testing.T) {
+	startTime := metav1.Now()
+	tests := []struct {
+		name      string
+		status    kruiseappsv1alpha1.BroadcastJobStatus
+		msg       string
+		done      bool
+		expectErr bool
+	}{
+		{
+			name:   "not started",
+			status: kruiseappsv1alpha1.BroadcastJobStatus{Phase: kruiseappsv1alpha1.PhaseRunning},
+			msg:    "Waiting for BroadcastJob \"bcj\" to start...",
+		},
+		{
+			name: "running",
+			status: kruiseappsv1alpha1.BroadcastJobStatus{
+				StartTime: &startTime,
+				Phase:     kruiseappsv1alpha1.PhaseRunning,
+				Desired:   3,
+				Active:    1,
+				Succeeded: 2,
+			},
+			msg: "Waiting for BroadcastJob \"bcj\" to finish: 2 of 3 nodes succeeded, 1 active, 0 failed...",
+		},
+		{
+			name: "completed",
+			status: kruiseappsv1alpha1.BroadcastJobStatus{
+				StartTime: &startTime,
+				Phase:     kruiseappsv1alpha1.PhaseCompleted,
+				Desired:   3,
+				Succeeded: 3,
+			},
+			msg:  "BroadcastJob \"bcj\" completed: 3 of 3 nodes succeeded, 0 failed",
+			done: true,
+		},
+		{
+			name: "failed",
+			status: kruiseappsv1alpha1.BroadcastJobStatus{
+				StartTime: &startTime,
+				Phase:     kruiseappsv1alpha1.PhaseFailed,
+				Desired:   3,
+				Succeeded: 2,
+				Failed:    1,
+				Conditions: []kruiseappsv1alpha1.JobCondition{{
+					Type:    kruiseappsv1alpha1.JobFailed,
+					Status:  "True",
+					Message: "failure policy is FailurePolicyTypeFailFast and failed pod is found",
+				}},
+			},
+			done:      true,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			job := &kruiseappsv1alpha1.BroadcastJob{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "bcj", Generation: 1},
+				Status:     test.status,
+			}
+			unstructuredJob, err := runtime.DefaultUnstructuredConverter.ToUnstructured(job)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, done, err := (&BroadcastJobStatusViewer{}).Status(&unstructured.Unstructured{Object: unstructuredJob}, 0)
+			if test.expectErr {
+				if err == nil {
+					t.Fatalf("expected error, got %#v", status)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if done != test.done || status.Message != test.msg {
+				t.Errorf("expected (%q, %t), got (%q, %t)", test.msg, test.done, status.Message, done)
+			}
+		})
+	}
+}
+
+func TestUnitedDeploymentStatusViewerStatus(t *testing.T) {
+	ud := &kruiseappsv1alpha1.UnitedDeployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "bar", Name: "ud", UID: "ud-uid", Generation: 1},
+		Spec: kruiseappsv1alpha1.UnitedDeploymentSpec{
+			Replicas: int32Ptr(4),
+			Template: kruiseappsv1alpha1.SubsetTemplate{
+				CloneSetTemplate: &kruiseappsv1alpha1.CloneSetTemplateSpec{},
+			},
+			Topology: kruiseappsv1alpha1.Topology{
+				Subsets: []kruiseappsv1alpha1.Subset{{Name: "zone-a"}, {Name: "zone-b"}, {Name: "zone-c"}},
+			},
+		},
+		Status: kruiseappsv1alpha1.UnitedDeploymentStatus{ObservedGeneration: 1},
+	}
+	controllerRef := *metav1.NewControllerRef(ud, kruiseappsv1alpha1.SchemeGroupVersion.WithKind("UnitedDeployment"))
+	cloneSet := func(subset string, updated int32) *kruiseappsv1alpha1.CloneSet {
+		return &kruiseappsv1alpha1.CloneSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "bar",
+				Name:            "ud-" + subset,
+				Generation:      1,
+				Labels:          map[string]string{kruiseappsv1alpha1.SubSetNameLabelKey: subset},
+				OwnerReferences: []metav1.OwnerReference{controllerRef},
+			},
+			Spec: kruiseappsv1alpha1.CloneSetSpec{Replicas: int32Ptr(2)},
+			Status: kruiseappsv1alpha1.CloneSetStatus{
+				ObservedGeneration:   1,
+				Replicas:             2,
+				ReadyReplicas:        2,
+				AvailableReplicas:    2,
+				UpdatedReplicas:      updated,
+				UpdatedReadyReplicas: updated,
+				CurrentRevision:      "ud-" + subset + "-1",
+				UpdateRevision:       "ud-" + subset + "-2",
+			},
+		}
+	}
+	done := cloneSet("zone-a", 2)
+	done.Status.CurrentRevision = done.Status.UpdateRevision
+	c := fake.NewFakeClientWithScheme(internalclient.Scheme, done, cloneSet("zone-b", 1))
+
+	unstructuredUD, err := runtime.DefaultUnstructuredConverter.ToUnstructured(ud)
+	if err != nil {
+		t.Fatal(err)
+	}
+	viewer := &UnitedDeploymentStatusViewer{revisionReader{c: c}}
+	status, finished, err := viewer.Status(&unstructured.Unstructured{Object: unstructuredUD}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if finished || status.Message != "Waiting for UnitedDeployment \"ud\" rolling update to finish: 1 of 3 subsets have been rolled out..." {
+		t.Errorf("unexpected status (%q, %t)", status.Message, finished)
+	}
+	expected := []SubsetRolloutStatus{
+		{Name: "zone-a", Workload: "cloneset/ud-zone-a", Phase: RolloutComplete, Message: "CloneSet rolling update complete 2 pods at revision ud-zone-a-2..."},
+		{Name: "zone-b", Workload: "cloneset/ud-zone-b", Phase: RolloutProgressing, Message: "Waiting for CloneSet \"ud-zone-b\" rolling update to finish: 1 out of 2 new pods have been updated..."},
+		{Name: "zone-c", Phase: RolloutPending, Message: "Waiting for the workload of the subset to be created..."},
+	}
+	if len(status.Subsets) != len(expected) {
+		t.Fatalf("expected %d subsets, got %#v", len(expected), status.Subsets)
+	}
+	for i := range expected {
+		if status.Subsets[i] != expected[i] {
+			t.Errorf("expected subset %#v, got %#v", expected[i], status.Subsets[i])
+		}
+	}
+}
@@ -17,7 +17,9 @@ limitations under the License.
 package polymorphichelpers
 
 import (
+	"encoding/json"
 	"fmt"
+
 	kruiseappsv1alpha1 "github.com/openkruise/kruise-api/apps/v1alpha1"
 	kruiseappsv1beta1 "github.com/openkruise/kruise-api/apps/v1beta1"
 
@@ -29,9 +31,15 @@ import (
 	batchv2alpha1 "k8s.io/api/batch/v2alpha1"
 	"k8s.io/api/core/v1"
 	extensionsv1beta1 "k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 )
 
+// unitedDeploymentTemplates are the fields of the subset template of a UnitedDeployment, one of
+// which holds the workload its subsets are created from.
+var unitedDeploymentTemplates = []string{"cloneSetTemplate", "advancedStatefulSetTemplate", "statefulSetTemplate", "deploymentTemplate"}
+
 func updatePodSpecForObject(obj runtime.Object, fn func(*v1.PodSpec) error) (bool, error) {
 	switch t := obj.(type) {
 	case *v1.Pod:
@@ -95,7 +103,175 @@ func updatePodSpecForObject(obj runtime.Object, fn func(*v1.PodSpec) error) (boo
 	case *kruiseappsv1beta1.StatefulSet:
 		return true, fn(&t.Spec.Template.Spec)
 
+	// Advanced DaemonSet
+	case *kruiseappsv1alpha1.DaemonSet:
+		return true, fn(&t.Spec.Template.Spec)
+
+	// SidecarSet
+	case *kruiseappsv1alpha1.SidecarSet:
+		return true, updateSidecarSetContainers(t, fn)
+
+	// UnitedDeployment
+	case *kruiseappsv1alpha1.UnitedDeployment:
+		template := unitedDeploymentPodTemplate(t)
+		if template == nil {
+			return false, fmt.Errorf("UnitedDeployment %s has no subset template", t.Name)
+		}
+		return true, fn(&template.Spec)
+
 	default:
 		return false, fmt.Errorf("the object is not a pod or does not have a pod template: %T", t)
 	}
 }
+
+// updateSidecarSetContainers calls fn on a pod spec holding the sidecar containers and volumes of
+// sidecarSet, and copies the changes back. Sidecar containers can be changed but not added or removed.
+func updateSidecarSetContainers(sidecarSet *kruiseappsv1alpha1.SidecarSet, fn func(*v1.PodSpec) error) error {
+	spec := &v1.PodSpec{Volumes: sidecarSet.Spec.Volumes}
+	for _, c := range sidecarSet.Spec.InitContainers {
+		spec.InitContainers = append(spec.InitContainers, c.Container)
+	}
+	for _, c := range sidecarSet.Spec.Containers {
+		spec.Containers = append(spec.Containers, c.Container)
+	}
+	if err := fn(spec); err != nil {
+		return err
+	}
+
+	if len(spec.InitContainers) != len(sidecarSet.Spec.InitContainers) || len(spec.Containers) != len(sidecarSet.Spec.Containers) {
+		return fmt.Errorf("adding or removing containers of SidecarSet %s is not supported", sidecarSet.Name)
+	}
+	for i := range spec.InitContainers {
+		sidecarSet.Spec.InitContainers[i].Container = spec.InitContainers[i]
+	}
+	for i := range spec.Containers {
+		sidecarSet.Spec.Containers[i].Container = spec.Containers[i]
+	}
+	sidecarSet.Spec.Volumes = spec.Volumes
+	return nil
+}
+
+// unitedDeploymentPodTemplate returns the pod template of the workload the subsets of ud are created from.
+func unitedDeploymentPodTemplate(ud *kruiseappsv1alpha1.UnitedDeployment) *v1.PodTemplateSpec {
+	switch template := ud.Spec.Template; {
+	case template.CloneSetTemplate != nil:
+		return &template.CloneSetTemplate.Spec.Template
+	case template.AdvancedStatefulSetTemplate != nil:
+		return &template.AdvancedStatefulSetTemplate.Spec.Template
+	case template.StatefulSetTemplate != nil:
+		return &template.StatefulSetTemplate.Spec.Template
+	case template.DeploymentTemplate != nil:
+		return &template.DeploymentTemplate.Spec.Template
+	}
+	return nil
+}
+
+// UpdateSubsetPodSpecForObject returns an UpdatePodSpecForObjectFunc that calls fn on the pod spec
+// of the named subset of a UnitedDeployment, and stores the changes as the patch of the subset
+// instead of changing the template shared by all subsets. The kruise-api version used here does not
+// know subset patches, so the UnitedDeployment has to be unstructured.
+func UpdateSubsetPodSpecForObject(subset string) UpdatePodSpecForObjectFunc {
+	return func(obj runtime.Object, fn func(*v1.PodSpec) error) (bool, error) {
+		u, ok := obj.(*unstructured.Unstructured)
+		if !ok || u.GroupVersionKind().GroupKind() != kruiseappsv1alpha1.SchemeGroupVersion.WithKind("UnitedDeployment").GroupKind() {
+			return false, fmt.Errorf("only UnitedDeployments have subsets: %T", obj)
+		}
+		return true, updateSubsetPatch(u, subset, fn)
+	}
+}
+
+// WithResourceVersion adds resourceVersion to a merge patch, so that it is only applied to that version
+// of the object. Merge patches replace whole lists, e.g. the subsets of a UnitedDeployment, and would
+// otherwise overwrite concurrent changes.
+func WithResourceVersion(patch []byte, resourceVersion string) ([]byte, error) {
+	u := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patch, &u.Object); err != nil {
+		return nil, err
+	}
+	if u.Object == nil {
+		u.Object = map[string]interface{}{}
+	}
+	u.SetResourceVersion(resourceVersion)
+	return json.Marshal(u.Object)
+}
+
+// updateSubsetPatch calls fn on the pod spec of subset, i.e. the pod template of ud with the patch of
+// the subset applied, and replaces the patch with the difference between the result and the template.
+func updateSubsetPatch(ud *unstructured.Unstructured, subset string, fn func(*v1.PodSpec) error) error {
+	var template map[string]interface{}
+	for _, field := range unitedDeploymentTemplates {
+		t, found, err := unstructured.NestedMap(ud.Object, "spec", "template", field, "spec", "template")
+		if err != nil {
+			return err
+		}
+		if found {
+			template = t
+			break
+		}
+	}
+	if template == nil {
+		return fmt.Errorf("UnitedDeployment %s has no subset template", ud.GetName())
+	}
+
+	subsets, _, err := unstructured.NestedSlice(ud.Object, "spec", "topology", "subsets")
+	if err != nil {
+		return err
+	}
+	var subsetConfig map[string]interface{}
+	for _, s := range subsets {
+		if s, ok := s.(map[string]interface{}); ok && s["name"] == subset {
+			subsetConfig = s
+			break
+		}
+	}
+	if subsetConfig == nil {
+		return fmt.Errorf("UnitedDeployment %s has no subset %q", ud.GetName(), subset)
+	}
+
+	// round trip the template through its Go type, so that only the changes made by fn show up in the patch
+	base := &v1.PodTemplateSpec{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(template, base); err != nil {
+		return err
+	}
+	baseJSON, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+	patchedJSON := baseJSON
+	if patch, ok := subsetConfig["patch"]; ok && patch != nil {
+		patchJSON, err := json.Marshal(patch)
+		if err != nil {
+			return err
+		}
+		if patchedJSON, err = strategicpatch.StrategicMergePatch(baseJSON, patchJSON, &v1.PodTemplateSpec{}); err != nil {
+			return fmt.Errorf("failed to apply the patch of subset %q: %v", subset, err)
+		}
+	}
+	patched := &v1.PodTemplateSpec{}
+	if err := json.Unmarshal(patchedJSON, patched); err != nil {
+		return err
+	}
+
+	if err := fn(&patched.Spec); err != nil {
+		return err
+	}
+
+	modifiedJSON, err := json.Marshal(patched)
+	if err != nil {
+		return err
+	}
+	patchJSON, err := strategicpatch.CreateTwoWayMergePatch(baseJSON, modifiedJSON, &v1.PodTemplateSpec{})
+	if err != nil {
+		return err
+	}
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(patchJSON, &patch); err != nil {
+		return err
+	}
+	if len(patch) == 0 {
+		delete(subsetConfig, "patch")
+	} else {
+		subsetConfig["patch"] = patch
+	}
+	return unstructured.SetNestedSlice(ud.Object, subsets, "spec", "topology", "subsets")
+}
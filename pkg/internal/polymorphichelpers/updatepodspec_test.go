@@ -0,0 +1,107 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package polymorphichelpers
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestUpdateSubsetPodSpecForObject(t *testing.T) {
+	ud := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps.kruise.io/v1alpha1",
+		"kind":       "UnitedDeployment",
+		"metadata":   map[string]interface{}{"name": "web"},
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"cloneSetTemplate": map[string]interface{}{
+					"spec": map[string]interface{}{
+						"template": map[string]interface{}{
+							"spec": map[string]interface{}{
+								"containers": []interface{}{
+									map[string]interface{}{"name": "nginx", "image": "nginx:1.19"},
+									map[string]interface{}{"name": "agent", "image": "agent:1.0"},
+								},
+							},
+						},
+					},
+				},
+			},
+			"topology": map[string]interface{}{
+				"subsets": []interface{}{
+					map[string]interface{}{
+						"name": "zone-a",
+						"patch": map[string]interface{}{
+							"metadata": map[string]interface{}{"labels": map[string]interface{}{"zone": "a"}},
+						},
+					},
+					map[string]interface{}{"name": "zone-b"},
+				},
+			},
+		},
+	}}
+
+	setImage := func(image string) func(*v1.PodSpec) error {
+		return func(spec *v1.PodSpec) error {
+			for i := range spec.Containers {
+				if spec.Containers[i].Name == "nginx" {
+					spec.Containers[i].Image = image
+				}
+			}
+			return nil
+		}
+	}
+	if _, err := UpdateSubsetPodSpecForObject("zone-a")(ud, setImage("nginx:1.20")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	containers, _, _ := unstructured.NestedSlice(ud.Object, "spec", "template", "cloneSetTemplate", "spec", "template", "spec", "containers")
+	if image := containers[0].(map[string]interface{})["image"]; image != "nginx:1.19" {
+		t.Errorf("expected the shared template to be kept, got image %v", image)
+	}
+	subsets, _, _ := unstructured.NestedSlice(ud.Object, "spec", "topology", "subsets")
+	patch, ok := subsets[0].(map[string]interface{})["patch"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected subset zone-a to have a patch, got %#v", subsets[0])
+	}
+	if label, _, _ := unstructured.NestedString(patch, "metadata", "labels", "zone"); label != "a" {
+		t.Errorf("expected the existing patch to be kept, got %#v", patch)
+	}
+	patchContainers, _, _ := unstructured.NestedSlice(patch, "spec", "containers")
+	if len(patchContainers) != 1 || patchContainers[0].(map[string]interface{})["image"] != "nginx:1.20" {
+		t.Errorf("expected the patch to update the nginx image only, got %#v", patch)
+	}
+	if _, ok := subsets[1].(map[string]interface{})["patch"]; ok {
+		t.Errorf("expected subset zone-b to be kept, got %#v", subsets[1])
+	}
+
+	// reverting the change leaves only the previous patch
+	if _, err := UpdateSubsetPodSpecForObject("zone-a")(ud, setImage("nginx:1.19")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	subsets, _, _ = unstructured.NestedSlice(ud.Object, "spec", "topology", "subsets")
+	patch = subsets[0].(map[string]interface{})["patch"].(map[string]interface{})
+	if _, ok := patch["spec"]; ok {
+		t.Errorf("expected the container change to be removed from the patch, got %#v", patch)
+	}
+
+	if _, err := UpdateSubsetPodSpecForObject("zone-c")(ud, setImage("nginx:1.20")); err == nil {
+		t.Errorf("expected error for unknown subset")
+	}
+}
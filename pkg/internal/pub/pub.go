@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package pub works with Kruise PodUnavailableBudgets, which limit how many pods of a workload may
+// be unavailable at a time. A budget is reduced to the pods it protects and how many of them may
+// still be evicted, which is all a drain needs to know.
+package pub
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hantmac/kubectl-kruise/pkg/internal/dynamicutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersionResource is the resource of PodUnavailableBudgets.
+var GroupVersionResource = schema.GroupVersionResource{Group: "policy.kruise.io", Version: "v1alpha1", Resource: "podunavailablebudgets"}
+
+// Budget is a PodUnavailableBudget, reduced to what decides whether a pod may become unavailable.
+type Budget struct {
+	Namespace string
+	Name      string
+	// Selector selects the protected pods, it is nil if TargetRef is used instead.
+	Selector labels.Selector
+	// TargetRef is the workload whose pods are protected.
+	TargetRef *TargetReference
+	// UnavailableAllowed is the number of protected pods that may still become unavailable.
+	UnavailableAllowed int32
+}
+
+// TargetReference refers to the workload whose pods a budget protects.
+type TargetReference struct {
+	APIVersion string
+	Kind       string
+	Name       string
+}
+
+// FromUnstructured returns the Budget of a PodUnavailableBudget.
+func FromUnstructured(obj *unstructured.Unstructured) (*Budget, error) {
+	budget := &Budget{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+
+	selector, found, err := unstructured.NestedMap(obj.Object, "spec", "selector")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		labelSelector := &metav1.LabelSelector{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(selector, labelSelector); err != nil {
+			return nil, fmt.Errorf("invalid selector of PodUnavailableBudget %s: %v", budget.Name, err)
+		}
+		if budget.Selector, err = metav1.LabelSelectorAsSelector(labelSelector); err != nil {
+			return nil, fmt.Errorf("invalid selector of PodUnavailableBudget %s: %v", budget.Name, err)
+		}
+	}
+
+	targetRef, found, err := unstructured.NestedStringMap(obj.Object, "spec", "targetRef")
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		budget.TargetRef = &TargetReference{APIVersion: targetRef["apiVersion"], Kind: targetRef["kind"], Name: targetRef["name"]}
+	}
+
+	unavailableAllowed, _, err := unstructured.NestedInt64(obj.Object, "status", "unavailableAllowed")
+	if err != nil {
+		return nil, err
+	}
+	budget.UnavailableAllowed = int32(unavailableAllowed)
+	return budget, nil
+}
+
+// List returns the budgets in namespace, there are none if PodUnavailableBudgets are not installed.
+func List(ctx context.Context, client dynamic.Interface, namespace string) ([]*Budget, error) {
+	items, err := dynamicutil.ListIfInstalled(ctx, client, GroupVersionResource, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	budgets := make([]*Budget, 0, len(items))
+	for i := range items {
+		budget, err := FromUnstructured(&items[i])
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, budget)
+	}
+	return budgets, nil
+}
+
+// Protects returns true if the budget protects pod. workload is the controller of the pod a target
+// reference refers to, which is the owner of the ReplicaSet for pods of Deployments, or nil if the
+// pod has no controller.
+func (b *Budget) Protects(pod *corev1.Pod, workload *metav1.OwnerReference) bool {
+	if pod.Namespace != b.Namespace {
+		return false
+	}
+	if b.Selector != nil {
+		return b.Selector.Matches(labels.Set(pod.Labels))
+	}
+	if b.TargetRef == nil || workload == nil {
+		return false
+	}
+	return workload.Kind == b.TargetRef.Kind && workload.Name == b.TargetRef.Name &&
+		dynamicutil.APIGroup(workload.APIVersion) == dynamicutil.APIGroup(b.TargetRef.APIVersion)
+}
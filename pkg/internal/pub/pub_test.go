@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pub
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newBudget(name string, spec map[string]interface{}, unavailableAllowed int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   spec,
+		"status": map[string]interface{}{"unavailableAllowed": unavailableAllowed},
+	}}
+	obj.SetAPIVersion(GroupVersionResource.GroupVersion().String())
+	obj.SetKind("PodUnavailableBudget")
+	obj.SetNamespace("bar")
+	obj.SetName(name)
+	return obj
+}
+
+func TestList(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newBudget("by-selector", map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		}, 1),
+		newBudget("by-target", map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps.kruise.io/v1alpha1", "kind": "CloneSet", "name": "web"},
+		}, 0),
+	)
+
+	budgets, err := List(context.TODO(), client, "bar")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(budgets) != 2 {
+		t.Fatalf("expected 2 budgets, got %v", budgets)
+	}
+	for _, budget := range budgets {
+		switch budget.Name {
+		case "by-selector":
+			if budget.Selector == nil || budget.Selector.String() != "app=web" || budget.UnavailableAllowed != 1 {
+				t.Errorf("unexpected budget: %+v", budget)
+			}
+		case "by-target":
+			if budget.TargetRef == nil || budget.TargetRef.Kind != "CloneSet" || budget.TargetRef.Name != "web" || budget.UnavailableAllowed != 0 {
+				t.Errorf("unexpected budget: %+v", budget)
+			}
+		}
+	}
+}
+
+func TestProtects(t *testing.T) {
+	budgets := map[string]*Budget{}
+	for _, obj := range []*unstructured.Unstructured{
+		newBudget("by-selector", map[string]interface{}{
+			"selector": map[string]interface{}{"matchLabels": map[string]interface{}{"app": "web"}},
+		}, 1),
+		newBudget("by-target", map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps.kruise.io/v1alpha1", "kind": "CloneSet", "name": "web"},
+		}, 1),
+	} {
+		budget, err := FromUnstructured(obj)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		budgets[budget.Name] = budget
+	}
+	cloneSet := &metav1.OwnerReference{APIVersion: "apps.kruise.io/v1beta1", Kind: "CloneSet", Name: "web"}
+	deployment := &metav1.OwnerReference{APIVersion: "apps/v1", Kind: "Deployment", Name: "web"}
+
+	tests := []struct {
+		name      string
+		budget    string
+		namespace string
+		labels    map[string]string
+		workload  *metav1.OwnerReference
+		expected  bool
+	}{
+		{name: "matching labels", budget: "by-selector", namespace: "bar", labels: map[string]string{"app": "web"}, expected: true},
+		{name: "other labels", budget: "by-selector", namespace: "bar", labels: map[string]string{"app": "db"}},
+		{name: "other namespace", budget: "by-selector", namespace: "foo", labels: map[string]string{"app": "web"}},
+		{name: "target workload", budget: "by-target", namespace: "bar", workload: cloneSet, expected: true},
+		{name: "other workload kind", budget: "by-target", namespace: "bar", workload: deployment},
+		{name: "no workload", budget: "by-target", namespace: "bar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: tt.namespace, Name: "web-1", Labels: tt.labels}}
+			if protects := budgets[tt.budget].Protects(pod, tt.workload); protects != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, protects)
+			}
+		})
+	}
+}
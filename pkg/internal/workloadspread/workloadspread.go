@@ -0,0 +1,217 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package workloadspread works with Kruise WorkloadSpreads, which spread the pods of a workload over
+// subsets of nodes. It declares the part of the API needed to tell which subset a pod was injected
+// into, which nodes a subset may use and how many replicas a subset is missing.
+package workloadspread
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hantmac/kubectl-kruise/pkg/internal/dynamicutil"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic"
+)
+
+// GroupVersionResource is the resource of WorkloadSpreads.
+var GroupVersionResource = schema.GroupVersionResource{Group: "apps.kruise.io", Version: "v1alpha1", Resource: "workloadspreads"}
+
+// MatchedWorkloadSpreadAnnotation is set on pods by the Kruise webhook to the WorkloadSpread and
+// subset the pod was injected into.
+const MatchedWorkloadSpreadAnnotation = "apps.kruise.io/matched-workloadspread"
+
+// WorkloadSpread spreads the pods of a workload over subsets of nodes.
+type WorkloadSpread struct {
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WorkloadSpreadSpec   `json:"spec"`
+	Status WorkloadSpreadStatus `json:"status,omitempty"`
+}
+
+// WorkloadSpreadSpec is the spec of a WorkloadSpread.
+type WorkloadSpreadSpec struct {
+	TargetReference  *TargetReference               `json:"targetRef"`
+	Subsets          []WorkloadSpreadSubset         `json:"subsets"`
+	ScheduleStrategy WorkloadSpreadScheduleStrategy `json:"scheduleStrategy,omitempty"`
+}
+
+// TargetReference refers to the workload whose pods are spread.
+type TargetReference struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+}
+
+// WorkloadSpreadSubset is a group of nodes pods are spread to.
+type WorkloadSpreadSubset struct {
+	Name                       string                           `json:"name"`
+	RequiredNodeSelectorTerm   *corev1.NodeSelectorTerm         `json:"requiredNodeSelectorTerm,omitempty"`
+	PreferredNodeSelectorTerms []corev1.PreferredSchedulingTerm `json:"preferredNodeSelectorTerms,omitempty"`
+	// MaxReplicas is the maximum number of pods in the subset, it is unlimited if nil.
+	MaxReplicas *intstr.IntOrString `json:"maxReplicas,omitempty"`
+}
+
+// WorkloadSpreadScheduleStrategy decides what happens to pods that cannot be scheduled in their subset.
+type WorkloadSpreadScheduleStrategy struct {
+	Type string `json:"type,omitempty"`
+}
+
+// WorkloadSpreadStatus is the status of a WorkloadSpread.
+type WorkloadSpreadStatus struct {
+	ObservedGeneration int64                        `json:"observedGeneration,omitempty"`
+	SubsetStatuses     []WorkloadSpreadSubsetStatus `json:"subsetStatuses,omitempty"`
+}
+
+// WorkloadSpreadSubsetStatus is the status of a subset.
+type WorkloadSpreadSubsetStatus struct {
+	Name string `json:"name"`
+	// MissingReplicas is the number of pods the subset can still take, -1 if it is unlimited.
+	MissingReplicas int32 `json:"missingReplicas"`
+}
+
+// injectedWorkloadSpread is the value of MatchedWorkloadSpreadAnnotation.
+type injectedWorkloadSpread struct {
+	Name   string `json:"name"`
+	Subset string `json:"subset"`
+}
+
+// ForWorkload returns the WorkloadSpreads in namespace that target the given workload, there are
+// none if WorkloadSpreads are not installed.
+func ForWorkload(ctx context.Context, client dynamic.Interface, namespace string, workload TargetReference) ([]*WorkloadSpread, error) {
+	items, err := dynamicutil.ListIfInstalled(ctx, client, GroupVersionResource, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var spreads []*WorkloadSpread
+	for i := range items {
+		ws := &WorkloadSpread{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(items[i].Object, ws); err != nil {
+			return nil, fmt.Errorf("failed to decode WorkloadSpread %s: %v", items[i].GetName(), err)
+		}
+		ref := ws.Spec.TargetReference
+		if ref == nil || ref.Kind != workload.Kind || ref.Name != workload.Name ||
+			dynamicutil.APIGroup(ref.APIVersion) != dynamicutil.APIGroup(workload.APIVersion) {
+			continue
+		}
+		spreads = append(spreads, ws)
+	}
+	return spreads, nil
+}
+
+// MatchedSubset returns the WorkloadSpread and subset pod was injected into, which are empty if it
+// was not injected by any.
+func MatchedSubset(pod *corev1.Pod) (string, string) {
+	value, ok := pod.Annotations[MatchedWorkloadSpreadAnnotation]
+	if !ok {
+		return "", ""
+	}
+	injected := injectedWorkloadSpread{}
+	if err := json.Unmarshal([]byte(value), &injected); err != nil {
+		return "", ""
+	}
+	return injected.Name, injected.Subset
+}
+
+// Subset returns the subset of the WorkloadSpread with the given name, or nil if there is none.
+func (ws *WorkloadSpread) Subset(name string) *WorkloadSpreadSubset {
+	for i := range ws.Spec.Subsets {
+		if ws.Spec.Subsets[i].Name == name {
+			return &ws.Spec.Subsets[i]
+		}
+	}
+	return nil
+}
+
+// MissingReplicas returns the missing replicas of the subset from the status, and whether the
+// status has the subset.
+func (ws *WorkloadSpread) MissingReplicas(name string) (int32, bool) {
+	for _, status := range ws.Status.SubsetStatuses {
+		if status.Name == name {
+			return status.MissingReplicas, true
+		}
+	}
+	return 0, false
+}
+
+// MatchesNode returns true if node satisfies the required node selector term of the subset.
+func (s *WorkloadSpreadSubset) MatchesNode(node *corev1.Node) (bool, error) {
+	if s.RequiredNodeSelectorTerm == nil {
+		return true, nil
+	}
+	selector, err := nodeSelectorRequirementsAsSelector(s.RequiredNodeSelectorTerm.MatchExpressions)
+	if err != nil {
+		return false, err
+	}
+	if !selector.Matches(labels.Set(node.Labels)) {
+		return false, nil
+	}
+	for _, req := range s.RequiredNodeSelectorTerm.MatchFields {
+		if req.Key != "metadata.name" {
+			return false, fmt.Errorf("unsupported node field %q", req.Key)
+		}
+		matched := false
+		for _, value := range req.Values {
+			if value == node.Name {
+				matched = true
+			}
+		}
+		if matched != (req.Operator == corev1.NodeSelectorOpIn) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// nodeSelectorRequirementsAsSelector converts node selector requirements to a label selector.
+func nodeSelectorRequirementsAsSelector(reqs []corev1.NodeSelectorRequirement) (labels.Selector, error) {
+	selector := labels.NewSelector()
+	for _, req := range reqs {
+		var op selection.Operator
+		switch req.Operator {
+		case corev1.NodeSelectorOpIn:
+			op = selection.In
+		case corev1.NodeSelectorOpNotIn:
+			op = selection.NotIn
+		case corev1.NodeSelectorOpExists:
+			op = selection.Exists
+		case corev1.NodeSelectorOpDoesNotExist:
+			op = selection.DoesNotExist
+		case corev1.NodeSelectorOpGt:
+			op = selection.GreaterThan
+		case corev1.NodeSelectorOpLt:
+			op = selection.LessThan
+		default:
+			return nil, fmt.Errorf("%q is not a valid node selector operator", req.Operator)
+		}
+		r, err := labels.NewRequirement(req.Key, op, req.Values)
+		if err != nil {
+			return nil, err
+		}
+		selector = selector.Add(*r)
+	}
+	return selector, nil
+}
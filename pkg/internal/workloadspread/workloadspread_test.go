@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The Kruise Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package workloadspread
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newWorkloadSpread(name, targetKind, targetName string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{"apiVersion": "apps.kruise.io/v1alpha1", "kind": targetKind, "name": targetName},
+			"subsets": []interface{}{
+				map[string]interface{}{
+					"name": "zone-a",
+					"requiredNodeSelectorTerm": map[string]interface{}{
+						"matchExpressions": []interface{}{
+							map[string]interface{}{"key": "zone", "operator": "In", "values": []interface{}{"a"}},
+						},
+					},
+					"maxReplicas": int64(3),
+				},
+				map[string]interface{}{"name": "zone-b", "maxReplicas": "50%"},
+			},
+		},
+		"status": map[string]interface{}{
+			"subsetStatuses": []interface{}{
+				map[string]interface{}{"name": "zone-a", "missingReplicas": int64(1)},
+			},
+		},
+	}}
+	obj.SetAPIVersion(GroupVersionResource.GroupVersion().String())
+	obj.SetKind("WorkloadSpread")
+	obj.SetNamespace("bar")
+	obj.SetName(name)
+	return obj
+}
+
+func TestForWorkload(t *testing.T) {
+	client := fake.NewSimpleDynamicClient(runtime.NewScheme(),
+		newWorkloadSpread("abc-spread", "CloneSet", "abc"),
+		newWorkloadSpread("other-spread", "CloneSet", "other"),
+	)
+
+	spreads, err := ForWorkload(context.TODO(), client, "bar", TargetReference{APIVersion: "apps.kruise.io/v1alpha1", Kind: "CloneSet", Name: "abc"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(spreads) != 1 || spreads[0].Name != "abc-spread" {
+		t.Fatalf("expected only abc-spread, got %v", spreads)
+	}
+	ws := spreads[0]
+	if subset := ws.Subset("zone-a"); subset == nil || *subset.MaxReplicas != intstr.FromInt(3) || subset.RequiredNodeSelectorTerm == nil {
+		t.Errorf("unexpected subset zone-a: %+v", subset)
+	}
+	if subset := ws.Subset("zone-b"); subset == nil || *subset.MaxReplicas != intstr.FromString("50%") {
+		t.Errorf("unexpected subset zone-b: %+v", subset)
+	}
+	if missing, ok := ws.MissingReplicas("zone-a"); !ok || missing != 1 {
+		t.Errorf("expected 1 missing replica in zone-a, got %d", missing)
+	}
+	if _, ok := ws.MissingReplicas("zone-b"); ok {
+		t.Errorf("expected no status for zone-b")
+	}
+}
+
+func TestMatchedSubset(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		MatchedWorkloadSpreadAnnotation: `{"name":"abc-spread","subset":"zone-a"}`,
+	}}}
+	if name, subset := MatchedSubset(pod); name != "abc-spread" || subset != "zone-a" {
+		t.Errorf("expected abc-spread and zone-a, got %q and %q", name, subset)
+	}
+	if name, subset := MatchedSubset(&corev1.Pod{}); name != "" || subset != "" {
+		t.Errorf("expected no subset, got %q and %q", name, subset)
+	}
+}
+
+func TestMatchesNode(t *testing.T) {
+	subset := &WorkloadSpreadSubset{RequiredNodeSelectorTerm: &corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{
+			{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+			{Key: "spot", Operator: corev1.NodeSelectorOpDoesNotExist},
+		},
+		MatchFields: []corev1.NodeSelectorRequirement{
+			{Key: "metadata.name", Operator: corev1.NodeSelectorOpNotIn, Values: []string{"node-3"}},
+		},
+	}}
+	node := func(name string, labels map[string]string) *corev1.Node {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+	}
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		expected bool
+	}{
+		{name: "matching node", node: node("node-1", map[string]string{"zone": "a"}), expected: true},
+		{name: "other zone", node: node("node-2", map[string]string{"zone": "b"})},
+		{name: "spot node", node: node("node-1", map[string]string{"zone": "a", "spot": "true"})},
+		{name: "excluded node name", node: node("node-3", map[string]string{"zone": "a"})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := subset.MatchesNode(tt.node)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if matches != tt.expected {
+				t.Errorf("expected %t, got %t", tt.expected, matches)
+			}
+		})
+	}
+}